@@ -0,0 +1,154 @@
+// Package analysis describes positions in the terms players learn them by, for training material and for finding the
+// features an evaluation could score
+package analysis
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/alejandrodavidmalavet/GoChess/internal/game"
+)
+
+// Island is a group of pawns of a side on adjacent files, between two files without any, by file from 0 for the
+// a-file to 7 for the h-file
+type Island struct {
+	From, To int
+}
+
+func (i Island) String() string {
+	if i.From == i.To {
+		return fileName(i.From)
+	}
+	return fileName(i.From) + "-" + fileName(i.To)
+}
+
+// Side is the pawn structure of a player
+type Side struct {
+	Color game.Color
+
+	// the pawns from the a-file to the h-file, and on a file from the player's side of the board forward, with their
+	// features as the evaluation sees them
+	Pawns []game.PawnInfo
+
+	// the islands from the a-file to the h-file
+	Islands []Island
+
+	// the files without pawns of the player but with some of the opponent's, on which its rooks can attack them
+	HalfOpenFiles []int
+}
+
+// Count returns the number of pawns of the side with all the features of the class
+func (s Side) Count(class game.PawnClass) int {
+	var count int
+	for _, pawn := range s.Pawns {
+		if pawn.Class&class == class {
+			count++
+		}
+	}
+	return count
+}
+
+// PawnStructure is the pawn structure of a position for both players
+type PawnStructure struct {
+	White, Black Side
+
+	// the files without any pawn
+	OpenFiles []int
+}
+
+// pawnFiles counts the pawns of each player by color index and file
+type pawnFiles [2][8]int
+
+// Pawns analyzes the pawn structure of the position
+func Pawns(gs *game.GameState) PawnStructure {
+	// 1. the pawns on each file
+	var files pawnFiles
+	for rank := 0; rank < 8; rank++ {
+		for file := 0; file < 8; file++ {
+			if piece, ok := gs.PieceAt(square(file, rank)); ok && piece.Type == game.Pawn {
+				files[sideIndex(piece.Color)][file]++
+			}
+		}
+	}
+
+	// 2. each side on its own, its pawns classified by the game, then the files shared by both
+	ps := PawnStructure{White: files.side(gs, game.White), Black: files.side(gs, game.Black)}
+	for file := 0; file < 8; file++ {
+		if files[0][file] == 0 && files[1][file] == 0 {
+			ps.OpenFiles = append(ps.OpenFiles, file)
+		}
+	}
+	return ps
+}
+
+// side returns the pawn structure of the player of the color
+func (f *pawnFiles) side(gs *game.GameState, color game.Color) Side {
+	s := Side{Color: color, Pawns: gs.ClassifyPawns(color)}
+	own, enemy := f[sideIndex(color)], f[sideIndex(-color)]
+	for file := 0; file < 8; file++ {
+		switch {
+		case own[file] == 0:
+			if enemy[file] > 0 {
+				s.HalfOpenFiles = append(s.HalfOpenFiles, file)
+			}
+		case file > 0 && own[file-1] > 0:
+			s.Islands[len(s.Islands)-1].To = file
+		default:
+			s.Islands = append(s.Islands, Island{From: file, To: file})
+		}
+	}
+	return s
+}
+
+func (ps PawnStructure) String() string {
+	var sb strings.Builder
+	for _, s := range []Side{ps.White, ps.Black} {
+		fmt.Fprintf(&sb, "%v pawns\n", s.Color)
+		for _, pawn := range s.Pawns {
+			fmt.Fprintf(&sb, "  %-4s%v\n", game.SquareName(pawn.Square), pawn.Class)
+		}
+		islands := make([]string, len(s.Islands))
+		for i, island := range s.Islands {
+			islands[i] = island.String()
+		}
+		fmt.Fprintf(&sb, "  Islands: %s\n", list(islands))
+		fmt.Fprintf(&sb, "  Half-open files: %s\n", fileList(s.HalfOpenFiles))
+	}
+	fmt.Fprintf(&sb, "Open files: %s\n", fileList(ps.OpenFiles))
+	return sb.String()
+}
+
+// square returns the square of the board of a file and rank counted from 0 at a1
+func square(file, rank int) int8 {
+	return int8((9-rank)*12 - 10 + file)
+}
+
+// sideIndex returns the index of the color into the pawn counts
+func sideIndex(color game.Color) int {
+	if color == game.White {
+		return 0
+	}
+	return 1
+}
+
+// fileName returns the letter of a file counted from 0 for the a-file
+func fileName(file int) string {
+	return string(rune('a' + file))
+}
+
+// fileList returns the letters of the files separated by spaces, or "-" if there are none
+func fileList(files []int) string {
+	names := make([]string, len(files))
+	for i, file := range files {
+		names[i] = fileName(file)
+	}
+	return list(names)
+}
+
+// list returns the words separated by spaces, or "-" if there are none
+func list(words []string) string {
+	if len(words) == 0 {
+		return "-"
+	}
+	return strings.Join(words, " ")
+}
@@ -0,0 +1,124 @@
+package analysis
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/alejandrodavidmalavet/GoChess/internal/game"
+)
+
+func TestPawns(t *testing.T) {
+	tests := []struct {
+		name                         string
+		fen                          string
+		whiteIslands                 []Island
+		blackIslands                 []Island
+		whiteHalfOpen, blackHalfOpen []int
+		open                         []int
+	}{
+		{"start position", game.StartFEN, []Island{{0, 7}}, []Island{{0, 7}}, nil, nil, nil},
+		{"no pawns", "4k3/8/8/8/8/8/8/4K3 w - - 0 1", nil, nil, nil, nil, []int{0, 1, 2, 3, 4, 5, 6, 7}},
+		{"islands", "8/p1p3pp/1p1k4/3P4/2P2pP1/8/PP4PP/4K3 w - - 0 1", []Island{{0, 3}, {6, 7}},
+			[]Island{{0, 2}, {5, 7}}, []int{5}, []int{3}, []int{4}},
+		{"isolated pawns", "4k3/p1p1p1p1/8/8/8/8/1P1P1P1P/4K3 w - - 0 1",
+			[]Island{{1, 1}, {3, 3}, {5, 5}, {7, 7}}, []Island{{0, 0}, {2, 2}, {4, 4}, {6, 6}},
+			[]int{0, 2, 4, 6}, []int{1, 3, 5, 7}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gs, err := game.NewGameFromFEN(tt.fen)
+			if err != nil {
+				t.Fatal(err)
+			}
+			ps := Pawns(gs)
+			if ps.White.Color != game.White || ps.Black.Color != game.Black {
+				t.Errorf("sides %v and %v", ps.White.Color, ps.Black.Color)
+			}
+			checks := []struct {
+				what      string
+				got, want any
+			}{
+				{"white islands", ps.White.Islands, tt.whiteIslands},
+				{"black islands", ps.Black.Islands, tt.blackIslands},
+				{"white half-open files", ps.White.HalfOpenFiles, tt.whiteHalfOpen},
+				{"black half-open files", ps.Black.HalfOpenFiles, tt.blackHalfOpen},
+				{"open files", ps.OpenFiles, tt.open},
+			}
+			for _, c := range checks {
+				if !reflect.DeepEqual(c.got, c.want) {
+					t.Errorf("%s %v, want %v", c.what, c.got, c.want)
+				}
+			}
+
+			// the pawns are those the game classifies
+			if !reflect.DeepEqual(ps.White.Pawns, gs.ClassifyPawns(game.White)) ||
+				!reflect.DeepEqual(ps.Black.Pawns, gs.ClassifyPawns(game.Black)) {
+				t.Errorf("pawns %v and %v, want those of ClassifyPawns", ps.White.Pawns, ps.Black.Pawns)
+			}
+		})
+	}
+}
+
+func TestSideCount(t *testing.T) {
+	gs, err := game.NewGameFromFEN("8/p1p3pp/1p1k4/3P4/2P2pP1/8/PP4PP/4K3 w - - 0 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ps := Pawns(gs)
+
+	tests := []struct {
+		side  Side
+		class game.PawnClass
+		want  int
+	}{
+		{ps.White, game.Connected, 6},
+		{ps.White, game.Doubled, 2},
+		{ps.White, game.Doubled | game.Connected, 1},
+		{ps.White, game.Passed, 0},
+		{ps.Black, game.Backward, 1},
+		{ps.Black, game.Candidate, 1},
+		{ps.Black, game.Isolated, 0},
+	}
+	for _, tt := range tests {
+		if got := tt.side.Count(tt.class); got != tt.want {
+			t.Errorf("%v Count(%v) = %d, want %d", tt.side.Color, tt.class, got, tt.want)
+		}
+	}
+}
+
+func TestPawnStructureString(t *testing.T) {
+	gs, err := game.NewGameFromFEN("4k3/8/8/3pP3/8/8/8/4K3 w - - 0 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `White pawns
+  e5  passed, isolated
+  Islands: e
+  Half-open files: d
+Black pawns
+  d5  passed, isolated
+  Islands: d
+  Half-open files: e
+Open files: a b c f g h
+`
+	if got := Pawns(gs).String(); got != want {
+		t.Errorf("String() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestIslandString(t *testing.T) {
+	tests := []struct {
+		island Island
+		want   string
+	}{
+		{Island{0, 0}, "a"},
+		{Island{2, 5}, "c-f"},
+		{Island{0, 7}, "a-h"},
+	}
+	for _, tt := range tests {
+		if got := tt.island.String(); got != tt.want {
+			t.Errorf("%+v.String() = %q, want %q", tt.island, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,138 @@
+package game
+
+import (
+	"fmt"
+	"math"
+)
+
+// Judgement classifies a move by how much worse it scores than the best move of the position
+type Judgement int
+
+const (
+	Good Judgement = iota
+	Inaccuracy
+	Mistake
+	Blunder
+)
+
+func (j Judgement) String() string {
+	return [...]string{"Good", "Inaccuracy", "Mistake", "Blunder"}[j]
+}
+
+// NAG returns the numeric annotation glyph of the judgement, 0 for a good move
+func (j Judgement) NAG() int {
+	return [...]int{0, NAGDubious, NAGMistake, NAGBlunder}[j]
+}
+
+// AnnotateOptions set how deep the positions of a game are searched and from how many centipawns lost a move is
+// an inaccuracy, a mistake or a blunder
+type AnnotateOptions struct {
+	Depth      int8
+	Inaccuracy int
+	Mistake    int
+	Blunder    int
+}
+
+// DefaultAnnotateOptions search each position to depth 2 and judge moves losing half a pawn, a pawn and three pawns
+var DefaultAnnotateOptions = AnnotateOptions{Depth: 2, Inaccuracy: 50, Mistake: 100, Blunder: 300}
+
+// annotateCap bounds the scores compared by the annotation: once a side is this far ahead, giving some of it back
+// changes nothing and missing a mate is no worse than missing a rook
+const annotateCap = 1000
+
+// AnnotatedMove is a move of a game judged against the best move the engine found in its position. Scores are from
+// the perspective of the player making the move
+type AnnotatedMove struct {
+	Move      Move
+	Score     int
+	Best      Line
+	Loss      int // centipawns lost against the best move, scores being capped at 10 pawns either way
+	Judgement Judgement
+}
+
+// Analyze searches the position before each move of the game and judges the move played against the best one.
+// Progress is called after each move with the number of moves judged so far
+func (g *PGNGame) Analyze(options AnnotateOptions, progress func(done, total int)) ([]AnnotatedMove, error) {
+	gs, err := g.Start()
+	if err != nil {
+		return nil, err
+	}
+
+	analysis := make([]AnnotatedMove, 0, len(g.Moves))
+	for ply, move := range g.Moves {
+		// 1. every move of the position scored, the best first
+		lines := gs.MultiPV(options.Depth, math.MaxInt)
+		a := AnnotatedMove{Move: move, Best: lines[0]}
+		found := false
+		for _, line := range lines {
+			if line.Move == move {
+				a.Score, found = line.Score, true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("ply %d: %v is not a legal move", ply+1, move)
+		}
+
+		// 2. the judgement of the move by the score it lost
+		capped := func(score int) int {
+			return max(min(score, annotateCap), -annotateCap)
+		}
+		a.Loss = capped(a.Best.Score) - capped(a.Score)
+		switch {
+		case a.Loss >= options.Blunder:
+			a.Judgement = Blunder
+		case a.Loss >= options.Mistake:
+			a.Judgement = Mistake
+		case a.Loss >= options.Inaccuracy:
+			a.Judgement = Inaccuracy
+		}
+		analysis = append(analysis, a)
+
+		gs.executeMove(move.Origin, move.Destination, move.MoveType)
+		if progress != nil {
+			progress(ply+1, len(g.Moves))
+		}
+	}
+	return analysis, nil
+}
+
+// Annotate returns a copy of the game in which the inaccuracies, mistakes and blunders of the analysis carry their
+// NAG, a comment with the score of the move and of the best one, and the engine's line as a variation. The
+// annotations the game already had are kept
+func (g *PGNGame) Annotate(analysis []AnnotatedMove) (*PGNGame, error) {
+	gs, err := g.Start()
+	if err != nil {
+		return nil, err
+	}
+
+	annotated := &PGNGame{Tags: map[string]string{}, Moves: g.Moves, Result: g.Result}
+	for name, value := range g.Tags {
+		annotated.Tags[name] = value
+	}
+	annotated.Tags["Annotator"] = "GoChess"
+
+	annotated.Annotations = make([]PGNAnnotation, len(g.Moves))
+	for ply, move := range g.Moves {
+		annotation := g.annotation(ply)
+		annotation.NAGs = append([]int(nil), annotation.NAGs...)
+		annotation.Variations = append([][]Move(nil), annotation.Variations...)
+
+		if ply < len(analysis) && analysis[ply].Judgement != Good {
+			a := analysis[ply]
+			// comments give the scores from white's perspective, as PGN readers expect
+			sign := int(gs.currColor)
+			comment := fmt.Sprintf("%v (%s). %s was best (%s).", a.Judgement, FormatScore(a.Score*sign),
+				gs.SAN(a.Best.Move), FormatScore(a.Best.Score*sign))
+			if annotation.Comment != "" {
+				comment = annotation.Comment + " " + comment
+			}
+			annotation.NAGs = append(annotation.NAGs, a.Judgement.NAG())
+			annotation.Comment = comment
+			annotation.Variations = append(annotation.Variations, a.Best.PV)
+		}
+		annotated.Annotations[ply] = annotation
+		gs.executeMove(move.Origin, move.Destination, move.MoveType)
+	}
+	return annotated, nil
+}
@@ -0,0 +1,91 @@
+package game
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAnnotate(t *testing.T) {
+	const pgn = `[Event "Scholar's mate"]
+[Result "1-0"]
+
+1. e4 e5 2. Qh5 Nc6 3. Bc4 {Aiming at f7.} Nf6 4. Qxf7# 1-0
+`
+	g, err := NewPGNReader(strings.NewReader(pgn)).Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	analysis, err := g.Analyze(DefaultAnnotateOptions, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(analysis) != len(g.Moves) {
+		t.Fatalf("%d moves analyzed, want %d", len(analysis), len(g.Moves))
+	}
+
+	tests := []struct {
+		ply       int
+		judgement Judgement
+		score     int
+	}{
+		{0, Good, 0},
+		{5, Blunder, MateIn(-1)}, // Nf6 allows the mate
+		{6, Good, MateIn(1)},
+	}
+	for _, tt := range tests {
+		a := analysis[tt.ply]
+		if a.Judgement != tt.judgement || (tt.score != 0 && a.Score != tt.score) {
+			t.Errorf("ply %d: %v scoring %s, want %v scoring %s", tt.ply+1, a.Judgement, FormatScore(a.Score),
+				tt.judgement, FormatScore(tt.score))
+		}
+		if a.Judgement == Good && a.Loss >= DefaultAnnotateOptions.Inaccuracy {
+			t.Errorf("ply %d: losing %d is not good", tt.ply+1, a.Loss)
+		}
+	}
+	if best := analysis[6].Best.Move.String(); best != "h5f7" {
+		t.Errorf("best move at the end %s, want h5f7", best)
+	}
+
+	annotated, err := g.Annotate(analysis)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if annotated.Tags["Annotator"] != "GoChess" || annotated.Tags["Event"] != "Scholar's mate" {
+		t.Errorf("tags %v", annotated.Tags)
+	}
+	if got := annotated.annotation(4).Comment; got != "Aiming at f7." {
+		t.Errorf("comment of Bc4 %q, want the one of the game", got)
+	}
+	blunder := annotated.annotation(5)
+	if len(blunder.NAGs) != 1 || blunder.NAGs[0] != NAGBlunder {
+		t.Errorf("NAGs of Nf6 %v, want [%d]", blunder.NAGs, NAGBlunder)
+	}
+	if !strings.HasPrefix(blunder.Comment, "Blunder (#1). ") {
+		t.Errorf("comment of Nf6 %q, want it to give the mate from white's side", blunder.Comment)
+	}
+	if len(blunder.Variations) != 1 || blunder.Variations[0][0] != analysis[5].Best.Move {
+		t.Errorf("variations of Nf6 %v, want the best line %v", blunder.Variations, analysis[5].Best.PV)
+	}
+	if len(g.annotation(5).NAGs) != 0 {
+		t.Error("Annotate changed the annotations of the game")
+	}
+}
+
+func TestJudgement(t *testing.T) {
+	tests := []struct {
+		judgement Judgement
+		name      string
+		nag       int
+	}{
+		{Good, "Good", 0},
+		{Inaccuracy, "Inaccuracy", NAGDubious},
+		{Mistake, "Mistake", NAGMistake},
+		{Blunder, "Blunder", NAGBlunder},
+	}
+	for _, tt := range tests {
+		if tt.judgement.String() != tt.name || tt.judgement.NAG() != tt.nag {
+			t.Errorf("%v: NAG %d, want %s and %d", tt.judgement, tt.judgement.NAG(), tt.name, tt.nag)
+		}
+	}
+}
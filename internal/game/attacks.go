@@ -0,0 +1,49 @@
+package game
+
+// AttackersOf returns the squares of the pieces of the color that attack the square, whether or not it holds a piece.
+// Squares are those of the board, like the squares of moves
+func (gs *GameState) AttackersOf(square int8, color Color) []int8 {
+	if square < 0 || int(square) >= len(to64) || to64[square] < 0 {
+		return nil
+	}
+	return squares(gs.attackersTo(to64[square], color, gs.colors[0]|gs.colors[1]))
+}
+
+// Checkers returns the squares of the pieces giving check to the current player, none if it is not in check
+func (gs *GameState) Checkers() []int8 {
+	king := gs.pieces[gs.currColor.index()][King]
+	if king == 0 {
+		return nil
+	}
+	return gs.AttackersOf(to120[king.lsb()], -gs.currColor)
+}
+
+// PinnedPieces returns the squares of the pieces of the color that cannot leave the line between an enemy slider and
+// their king
+func (gs *GameState) PinnedPieces(color Color) []int8 {
+	king := gs.pieces[color.index()][King]
+	if king == 0 {
+		return nil
+	}
+	k := king.lsb()
+	occupied := gs.colors[0] | gs.colors[1]
+	own := gs.colors[color.index()]
+	enemy := gs.pieces[(-color).index()]
+
+	var pinned Bitboard
+	for _, slider := range []struct {
+		attacks func(int8, Bitboard) Bitboard
+		pieces  Bitboard
+	}{
+		{rookAttacks, enemy[Rook] | enemy[Queen]},
+		{bishopAttacks, enemy[Bishop] | enemy[Queen]},
+	} {
+		// the sliders seen from the king through the first of its own pieces on each ray pin the piece between them,
+		// the only square both see
+		rays := slider.attacks(k, occupied)
+		for pinners := slider.attacks(k, occupied&^(rays&own)) & slider.pieces &^ rays; pinners != 0; {
+			pinned |= slider.attacks(pinners.popLsb(), occupied) & rays & own
+		}
+	}
+	return squares(pinned)
+}
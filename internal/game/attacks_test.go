@@ -0,0 +1,105 @@
+package game
+
+import (
+	"reflect"
+	"testing"
+)
+
+// squareNames returns the names of the squares, for comparing lists of squares
+func squareNames(squares []int8) []string {
+	var names []string
+	for _, sq := range squares {
+		names = append(names, SquareName(sq))
+	}
+	return names
+}
+
+func TestAttackersOf(t *testing.T) {
+	tests := []struct {
+		name   string
+		fen    string
+		square string
+		color  Color
+		want   []string
+	}{
+		{"empty square", StartFEN, "f3", White, []string{"g1", "e2", "g2"}},
+		{"nothing attacks", StartFEN, "e4", White, nil},
+		{"pawns of the color only", "4k3/8/8/3p1p2/4P3/3P4/8/4K3 w - - 0 1", "e4", Black, []string{"d5", "f5"}},
+		{"slider behind a piece", "4k3/8/8/4p3/8/8/4Q3/4RK2 w - - 0 1", "e5", White, []string{"e2"}},
+		{"bishop and rook", "4k3/8/8/4p3/8/2B5/8/4RK2 w - - 0 1", "e5", White, []string{"e1", "c3"}},
+		{"knight", "4k3/8/8/8/8/5n2/4K3/8 w - - 0 1", "e1", Black, []string{"f3"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sq, _ := parseSquare(tt.square)
+			got := squareNames(mustFEN(t, tt.fen).AttackersOf(sq, tt.color))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("AttackersOf(%s, %v) = %v, want %v", tt.square, tt.color, got, tt.want)
+			}
+		})
+	}
+
+	if got := mustFEN(t, StartFEN).AttackersOf(0, White); got != nil {
+		t.Errorf("AttackersOf off the board = %v, want none", got)
+	}
+}
+
+func TestCheckers(t *testing.T) {
+	tests := []struct {
+		name string
+		fen  string
+		want []string
+	}{
+		{"no check", StartFEN, nil},
+		{"rook check", "4k3/8/8/8/8/8/8/4RK2 b - - 0 1", []string{"e1"}},
+		{"knight check", "4k3/8/3N4/8/8/8/8/5K2 b - - 0 1", []string{"d6"}},
+		{"pawn check", "4k3/3P4/8/8/8/8/8/5K2 b - - 0 1", []string{"d7"}},
+		{"double check", "4k3/8/5N2/8/8/8/8/4RK2 b - - 0 1", []string{"e1", "f6"}},
+		{"blocked", "4k3/4n3/8/8/8/8/8/4RK2 b - - 0 1", nil},
+		{"white in check", "4k3/8/8/8/8/8/6q1/5K2 w - - 0 1", []string{"g2"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gs := mustFEN(t, tt.fen)
+			got := squareNames(gs.Checkers())
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Checkers() = %v, want %v", got, tt.want)
+			}
+			if check := gs.InCheck(); check != (len(tt.want) > 0) {
+				t.Errorf("InCheck() = %v with checkers %v", check, tt.want)
+			}
+		})
+	}
+}
+
+func TestPinnedPieces(t *testing.T) {
+	tests := []struct {
+		name  string
+		fen   string
+		color Color
+		want  []string
+	}{
+		{"no pins", StartFEN, White, nil},
+		{"rook pins a knight", "4k3/4n3/8/8/8/8/8/4RK2 w - - 0 1", Black, []string{"e7"}},
+		{"bishop pins a pawn", "4k3/8/8/8/7b/8/5P2/4K3 w - - 0 1", White, []string{"f2"}},
+		{"queens pin on a file and a diagonal", "k7/4q3/8/q7/8/8/3PN3/4K3 w - - 0 1", White, []string{"d2", "e2"}},
+		{"rook and bishop pins", "4k3/4r3/8/b7/8/2P5/4N3/4K3 w - - 0 1", White, []string{"e2", "c3"}},
+		{"two pieces in the way", "4k3/4r3/8/8/4N3/8/4P3/4K3 w - - 0 1", White, nil},
+		{"enemy piece in the way", "4k3/4r3/8/4n3/8/8/4P3/4K3 w - - 0 1", White, nil},
+		{"piece of the pinner's color", "4k3/4r3/8/8/8/8/4p3/4K3 w - - 0 1", White, nil},
+		{"slider that cannot move along the line", "4k3/4b3/8/8/8/8/4P3/4K3 w - - 0 1", White, nil},
+		{"relative pin", "3qk3/8/8/3n4/8/8/8/3RK3 w - - 0 1", Black, nil},
+		{"checker is no pin", "4k3/8/8/8/8/8/8/r3K3 w - - 0 1", White, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := squareNames(mustFEN(t, tt.fen).PinnedPieces(tt.color))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("PinnedPieces(%v) = %v, want %v", tt.color, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,41 @@
+package game
+
+import "time"
+
+// BenchResult is the node count and time taken by one benchmark run
+type BenchResult struct {
+	Name    string
+	Nodes   uint64
+	Elapsed time.Duration
+}
+
+// NodesPerSecond returns the node rate of the run
+func (r BenchResult) NodesPerSecond() float64 {
+	return float64(r.Nodes) / r.Elapsed.Seconds()
+}
+
+// Bench measures the perft and search node rates over the perft suite positions, for the command line. The same
+// measurements run as BenchmarkPerft and BenchmarkSearch, which record the rates of the mailbox board as a baseline
+func Bench(perftDepth int, searchDepth int8) []BenchResult {
+	perft := BenchResult{Name: "perft"}
+	search := BenchResult{Name: "search"}
+
+	for _, position := range PerftSuite {
+		gs, err := NewGameFromFEN(position.FEN)
+		if err != nil {
+			continue
+		}
+
+		start := time.Now()
+		perft.Nodes += gs.Perft(perftDepth)
+		perft.Elapsed += time.Since(start)
+
+		start = time.Now()
+		gs.nodes = 0
+		gs.MultiPV(searchDepth, 1)
+		search.Nodes += uint64(gs.nodes)
+		search.Elapsed += time.Since(start)
+	}
+
+	return []BenchResult{perft, search}
+}
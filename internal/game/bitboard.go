@@ -0,0 +1,195 @@
+package game
+
+import "math/bits"
+
+// Bitboard is a set of squares, one bit per square with a1 as bit 0 and h8 as bit 63
+type Bitboard uint64
+
+// lsb returns the index of the lowest set square
+func (b Bitboard) lsb() int8 {
+	return int8(bits.TrailingZeros64(uint64(b)))
+}
+
+// popLsb clears the lowest set square and returns its index
+func (b *Bitboard) popLsb() int8 {
+	sq := b.lsb()
+	*b &= *b - 1
+	return sq
+}
+
+// count returns the number of squares in the set
+func (b Bitboard) count() int {
+	return bits.OnesCount64(uint64(b))
+}
+
+func bit(sq int8) Bitboard {
+	return 1 << uint(sq)
+}
+
+const (
+	rank1 Bitboard = 0xff
+	rank2 Bitboard = rank1 << 8
+	rank7 Bitboard = rank1 << 48
+	rank8 Bitboard = rank1 << 56
+	fileA Bitboard = 0x0101010101010101
+	fileH Bitboard = fileA << 7
+)
+
+// fileMask returns the squares of the file, 0 to 7 from a to h
+func fileMask(file int8) Bitboard {
+	return fileA << file
+}
+
+// rankMask returns the squares of the rank, 0 to 7 from 1 to 8, and none off the board
+func rankMask(rank int8) Bitboard {
+	if rank < 0 || rank > 7 {
+		return 0
+	}
+	return rank1 << (8 * rank)
+}
+
+// adjacentFiles returns the squares of the files beside the file
+func adjacentFiles(file int8) Bitboard {
+	var b Bitboard
+	if file > 0 {
+		b |= fileMask(file - 1)
+	}
+	if file < 7 {
+		b |= fileMask(file + 1)
+	}
+	return b
+}
+
+// ranksAhead returns the squares of the ranks in front of the rank, as seen by the color
+func ranksAhead(color Color, rank int8) Bitboard {
+	if color == White {
+		return ^Bitboard(0) << (8 * (rank + 1))
+	}
+	return ^Bitboard(0) >> (8 * (8 - rank))
+}
+
+// square conversions between the 120 square board and the 64 square bitboards
+var (
+	to64  [120]int8
+	to120 [64]int8
+)
+
+// attack tables of the leaping pieces, pawns indexed by color index
+var (
+	knightAttacks [64]Bitboard
+	kingAttacks   [64]Bitboard
+	pawnAttacks   [2][64]Bitboard
+)
+
+// magic holds the lookup of a sliding piece on one square
+type magic struct {
+	mask    Bitboard
+	magic   uint64
+	shift   uint8
+	attacks []Bitboard
+}
+
+func (m *magic) index(occupied Bitboard) uint64 {
+	return (uint64(occupied&m.mask) * m.magic) >> m.shift
+}
+
+var (
+	rookMagics   [64]magic
+	bishopMagics [64]magic
+
+	rookDirections   = [][2]int8{{1, 0}, {-1, 0}, {0, 1}, {0, -1}}
+	bishopDirections = [][2]int8{{1, 1}, {1, -1}, {-1, 1}, {-1, -1}}
+)
+
+func init() {
+	for sq := range to64 {
+		to64[sq] = -1
+	}
+	for sq := int8(0); sq < 64; sq++ {
+		sq120 := (8-sq/8)*12 + sq%8 + 2
+		to120[sq] = sq120
+		to64[sq120] = sq
+	}
+
+	for sq := int8(0); sq < 64; sq++ {
+		knightAttacks[sq] = leaperAttacks(sq, [][2]int8{{1, 2}, {2, 1}, {2, -1}, {1, -2}, {-1, -2}, {-2, -1}, {-2, 1}, {-1, 2}})
+		kingAttacks[sq] = leaperAttacks(sq, [][2]int8{{1, 0}, {1, 1}, {0, 1}, {-1, 1}, {-1, 0}, {-1, -1}, {0, -1}, {1, -1}})
+		pawnAttacks[White.index()][sq] = leaperAttacks(sq, [][2]int8{{-1, 1}, {1, 1}})
+		pawnAttacks[Black.index()][sq] = leaperAttacks(sq, [][2]int8{{-1, -1}, {1, -1}})
+	}
+
+	for sq := int8(0); sq < 64; sq++ {
+		initMagic(&rookMagics[sq], sq, rookDirections, rookMagicNumbers[sq])
+		initMagic(&bishopMagics[sq], sq, bishopDirections, bishopMagicNumbers[sq])
+	}
+}
+
+// leaperAttacks returns the squares reached from sq by the given file and rank steps
+func leaperAttacks(sq int8, steps [][2]int8) Bitboard {
+	var attacks Bitboard
+	for _, step := range steps {
+		file, rank := sq%8+step[0], sq/8+step[1]
+		if file >= 0 && file < 8 && rank >= 0 && rank < 8 {
+			attacks |= bit(rank*8 + file)
+		}
+	}
+	return attacks
+}
+
+// rayAttacks returns the squares a slider on sq attacks along the given directions, stopping at occupied squares
+func rayAttacks(sq int8, occupied Bitboard, directions [][2]int8) Bitboard {
+	var attacks Bitboard
+	for _, direction := range directions {
+		file, rank := sq%8+direction[0], sq/8+direction[1]
+		for file >= 0 && file < 8 && rank >= 0 && rank < 8 {
+			target := bit(rank*8 + file)
+			attacks |= target
+			if occupied&target != 0 {
+				break
+			}
+			file, rank = file+direction[0], rank+direction[1]
+		}
+	}
+	return attacks
+}
+
+// initMagic fills the attack table of the slider on sq, indexed by its magic multiplier
+func initMagic(m *magic, sq int8, directions [][2]int8, number uint64) {
+	// 1. the relevant occupancy excludes the board edges, which never block anything further
+	edges := ((rank1 | rank8) &^ (rank1 << (8 * (sq / 8)))) | ((fileA | fileH) &^ (fileA << (sq % 8)))
+	m.mask = rayAttacks(sq, 0, directions) &^ edges
+	m.shift = uint8(64 - m.mask.count())
+	m.magic = number
+
+	// 2. every subset of the mask, along with its attacks
+	m.attacks = make([]Bitboard, 1<<(64-m.shift))
+	used := make([]bool, len(m.attacks))
+	for subset := Bitboard(0); ; {
+		index, attacks := m.index(subset), rayAttacks(sq, subset, directions)
+		if used[index] && m.attacks[index] != attacks {
+			panic("bitboard: a magic multiplier maps different attacks to the same index")
+		}
+		used[index] = true
+		m.attacks[index] = attacks
+
+		subset = (subset - m.mask) & m.mask
+		if subset == 0 {
+			break
+		}
+	}
+}
+
+func rookAttacks(sq int8, occupied Bitboard) Bitboard {
+	m := &rookMagics[sq]
+	return m.attacks[m.index(occupied)]
+}
+
+func bishopAttacks(sq int8, occupied Bitboard) Bitboard {
+	m := &bishopMagics[sq]
+	return m.attacks[m.index(occupied)]
+}
+
+// index returns the index of the color into per color arrays, 0 for white and 1 for black
+func (c Color) index() int {
+	return int(1-c) / 2
+}
@@ -0,0 +1,146 @@
+package game
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math/rand"
+	"os"
+	"sort"
+)
+
+// Opening books in the Polyglot format: a file of 16 byte entries sorted by the Polyglot key of their position,
+// each holding a move and a weight, all numbers big-endian
+
+const polyglotEntrySize = 16
+
+// bookEntry is one move of a position in a Polyglot book
+type bookEntry struct {
+	key    uint64
+	move   uint16
+	weight uint16
+}
+
+// Book is a Polyglot opening book held in memory
+type Book struct {
+	entries []bookEntry
+}
+
+// BookMove is a move of the book with its weight, the chance of playing it being proportional to the weight
+type BookMove struct {
+	Move   Move
+	Weight int
+}
+
+// LoadBook reads a Polyglot .bin opening book
+func LoadBook(path string) (*Book, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(data)%polyglotEntrySize != 0 {
+		return nil, fmt.Errorf("%s: not a Polyglot book, its size is not a multiple of %d", path, polyglotEntrySize)
+	}
+
+	book := &Book{entries: make([]bookEntry, len(data)/polyglotEntrySize)}
+	for i := range book.entries {
+		entry := data[i*polyglotEntrySize:]
+		book.entries[i] = bookEntry{
+			key:    binary.BigEndian.Uint64(entry[0:8]),
+			move:   binary.BigEndian.Uint16(entry[8:10]),
+			weight: binary.BigEndian.Uint16(entry[10:12]),
+		}
+	}
+
+	// lookups rely on the entries being sorted, which some tools do not guarantee
+	sort.SliceStable(book.entries, func(i, j int) bool { return book.entries[i].key < book.entries[j].key })
+
+	return book, nil
+}
+
+// Len returns the number of entries of the book
+func (b *Book) Len() int {
+	return len(b.entries)
+}
+
+// Moves returns the legal moves the book gives for the current position, from the heaviest to the lightest
+func (b *Book) Moves(gs *GameState) []BookMove {
+	key := gs.PolyglotKey()
+	first := sort.Search(len(b.entries), func(i int) bool { return b.entries[i].key >= key })
+
+	var moves []BookMove
+	for i := first; i < len(b.entries) && b.entries[i].key == key; i++ {
+		if move, ok := gs.polyglotMove(b.entries[i].move); ok {
+			moves = append(moves, BookMove{Move: move, Weight: int(b.entries[i].weight)})
+		}
+	}
+	sort.SliceStable(moves, func(i, j int) bool { return moves[i].Weight > moves[j].Weight })
+	return moves
+}
+
+// Pick draws one of the book moves of the current position at random, in proportion to their weights.
+// A nil rng uses the shared source of math/rand
+func (b *Book) Pick(gs *GameState, rng *rand.Rand) (Move, bool) {
+	moves := b.Moves(gs)
+	if len(moves) == 0 {
+		return Move{}, false
+	}
+
+	total := 0
+	for _, move := range moves {
+		total += move.Weight
+	}
+
+	// a book with only zero weights still has moves to play
+	if total == 0 {
+		return moves[randIntn(rng, len(moves))].Move, true
+	}
+
+	r := randIntn(rng, total)
+	for _, move := range moves {
+		if r < move.Weight {
+			return move.Move, true
+		}
+		r -= move.Weight
+	}
+	return moves[len(moves)-1].Move, true
+}
+
+// polyglotMove decodes a book move into the matching legal move. Bits 0-5 hold the destination square and bits 6-11
+// the origin, both with a1 as 0, and bits 12-14 the promotion piece. Castling is written as the king taking its rook
+func (gs *GameState) polyglotMove(encoded uint16) (Move, bool) {
+	destination := to120[encoded&63]
+	origin := to120[(encoded>>6)&63]
+	promotion := (encoded >> 12) & 7
+	if promotion > 4 {
+		return Move{}, false
+	}
+
+	if piece := gs.board[origin]; piece != nil && piece.Type == King {
+		switch {
+		case origin == 102 && destination == 105:
+			destination = 104
+		case origin == 102 && destination == 98:
+			destination = 100
+		case origin == 18 && destination == 21:
+			destination = 20
+		case origin == 18 && destination == 14:
+			destination = 16
+		}
+	}
+
+	s := SquareName(origin) + SquareName(destination)
+	if promotion != 0 {
+		s += string(" nbrq"[promotion])
+	}
+	move, err := gs.ParseMove(s)
+	return move, err == nil
+}
+
+// BookMove returns a move of the book of the limits for the current position, drawn from their random source, if
+// they set a book and the game is still within its depth
+func (gs *GameState) BookMove(limits Limits) (Move, bool) {
+	if limits.Book == nil || (limits.BookDepth > 0 && gs.fullMoveNumber > limits.BookDepth) {
+		return Move{}, false
+	}
+	return limits.Book.Pick(gs, limits.Rand)
+}
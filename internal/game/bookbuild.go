@@ -0,0 +1,161 @@
+package game
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+)
+
+// BookOptions choose the positions and moves of a book built from games
+type BookOptions struct {
+	MaxPly   int     // only the positions of the first MaxPly plies of each game are taken, all of them if 0
+	MinGames int     // moves played in fewer games are left out
+	MinScore float64 // moves scoring less for the side that played them are left out, from 0 to 1
+}
+
+// DefaultBookOptions take the first 20 plies, moves played in at least 2 games and any score
+var DefaultBookOptions = BookOptions{MaxPly: 20, MinGames: 2}
+
+// BookReport counts the games a book was built from
+type BookReport struct {
+	Games   int // games taken
+	Skipped int // games without a result or with moves that could not be read
+}
+
+// bookTally counts the games a move was played in, and how they ended for the side that played it
+type bookTally struct {
+	games, wins, draws int
+}
+
+// BuildBook builds a book from the games of a PGN file. Each move is weighted like Polyglot does, two points for
+// every win and one for every draw of the side that played it
+func BuildBook(r io.Reader, options BookOptions) (*Book, BookReport, error) {
+	var report BookReport
+	tallies := map[uint64]map[uint16]*bookTally{}
+
+	// 1. the moves played from each position of the opening, and their results
+	pr := NewPGNReader(r)
+	for {
+		g, err := pr.Next()
+		if err == io.EOF {
+			break
+		}
+		var pgnErr *PGNError
+		if errors.As(err, &pgnErr) || (err == nil && g.Result == Unfinished) {
+			report.Skipped++
+			continue
+		}
+		if err != nil {
+			return nil, report, err
+		}
+		gs, _ := g.Start()
+		report.Games++
+
+		for ply, move := range g.Moves {
+			if options.MaxPly > 0 && ply >= options.MaxPly {
+				break
+			}
+			key := gs.PolyglotKey()
+			if tallies[key] == nil {
+				tallies[key] = map[uint16]*bookTally{}
+			}
+			encoded := polyglotEncode(move)
+			tally := tallies[key][encoded]
+			if tally == nil {
+				tally = &bookTally{}
+				tallies[key][encoded] = tally
+			}
+
+			tally.games++
+			switch {
+			case g.Result == Drawn:
+				tally.draws++
+			case (g.Result == WhiteWon) == (gs.currColor == White):
+				tally.wins++
+			}
+			gs.executeMove(move.Origin, move.Destination, move.MoveType)
+		}
+	}
+
+	// 2. the moves passing the filters, with their weights scaled down when a position has too many games
+	book := &Book{}
+	for key, moves := range tallies {
+		var entries []bookEntry
+		heaviest := 0
+		for encoded, tally := range moves {
+			score := float64(tally.wins) + float64(tally.draws)/2
+			if tally.games < options.MinGames || score < options.MinScore*float64(tally.games) {
+				continue
+			}
+			entries = append(entries, bookEntry{key: key, move: encoded})
+			heaviest = max(heaviest, 2*tally.wins+tally.draws)
+		}
+		for i := range entries {
+			tally := moves[entries[i].move]
+			weight := 2*tally.wins + tally.draws
+			if heaviest > 0xffff {
+				weight = weight * 0xffff / heaviest
+			}
+			entries[i].weight = uint16(weight)
+		}
+		book.entries = append(book.entries, entries...)
+	}
+	sort.Slice(book.entries, func(i, j int) bool {
+		a, b := book.entries[i], book.entries[j]
+		if a.key != b.key {
+			return a.key < b.key
+		}
+		return a.weight > b.weight
+	})
+
+	return book, report, nil
+}
+
+// Save writes the book in the Polyglot format
+func (b *Book) Save(path string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	w := bufio.NewWriter(file)
+	var entry [polyglotEntrySize]byte
+	for _, e := range b.entries {
+		binary.BigEndian.PutUint64(entry[0:8], e.key)
+		binary.BigEndian.PutUint16(entry[8:10], e.move)
+		binary.BigEndian.PutUint16(entry[10:12], e.weight)
+		if _, err := w.Write(entry[:]); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	return file.Close()
+}
+
+// polyglotEncode returns the move as written in a Polyglot book, castling being the king taking its own rook
+func polyglotEncode(move Move) uint16 {
+	destination := move.Destination
+	switch move.MoveType {
+	case WhiteKingSideCastle:
+		destination = 105
+	case WhiteQueenSideCastle:
+		destination = 98
+	case BlackKingSideCastle:
+		destination = 21
+	case BlackQueenSideCastle:
+		destination = 14
+	}
+
+	encoded := uint16(to64[destination]) | uint16(to64[move.Origin])<<6
+	if promotion := move.MoveType.promotion(); promotion != Pawn {
+		encoded |= uint16(5-promotion) << 12
+	}
+	return encoded
+}
@@ -0,0 +1,108 @@
+package game
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const bookGames = `[Event "1"]
+[Result "1-0"]
+
+1. e4 e5 1-0
+
+[Event "2"]
+[Result "0-1"]
+
+1. e4 c5 0-1
+
+[Event "3"]
+[Result "1/2-1/2"]
+
+1. e4 e5 1/2-1/2
+
+[Event "4"]
+[Result "1-0"]
+
+1. d4 d5 1-0
+
+[Event "unfinished"]
+[Result "*"]
+
+1. c4 *
+
+[Event "illegal"]
+[Result "1-0"]
+
+1. e5 1-0
+
+[Event "castling"]
+[Result "1-0"]
+
+1. e4 e5 2. Nf3 Nc6 3. Bc4 Bc5 4. O-O 1-0
+`
+
+func TestBuildBook(t *testing.T) {
+	afterE4 := []string{"e2e4"}
+	beforeCastling := []string{"e2e4", "e7e5", "g1f3", "b8c6", "f1c4", "f8c5"}
+
+	tests := []struct {
+		name    string
+		options BookOptions
+		moves   []string       // the moves played to the position
+		want    map[string]int // the book moves of the position and their weights
+	}{
+		{"every move", BookOptions{MinGames: 1}, nil, map[string]int{"e2e4": 5, "d2d4": 2}},
+		{"replies", BookOptions{MinGames: 1}, afterE4, map[string]int{"e7e5": 1, "c7c5": 2}},
+		{"castling", BookOptions{MinGames: 1}, beforeCastling, map[string]int{"e1g1": 2}},
+		{"played twice", BookOptions{MinGames: 2}, nil, map[string]int{"e2e4": 5}},
+		{"replies played twice", BookOptions{MinGames: 2}, afterE4, map[string]int{"e7e5": 1}},
+		{"scoring half", BookOptions{MinGames: 1, MinScore: 0.5}, afterE4, map[string]int{"c7c5": 2}},
+		{"first ply only", BookOptions{MaxPly: 1, MinGames: 1}, afterE4, map[string]int{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			book, report, err := BuildBook(strings.NewReader(bookGames), tt.options)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if report.Games != 5 || report.Skipped != 2 {
+				t.Errorf("report %+v, want 5 games and 2 skipped", report)
+			}
+
+			// the book reads back the same from its file
+			path := filepath.Join(t.TempDir(), "book.bin")
+			if err := book.Save(path); err != nil {
+				t.Fatal(err)
+			}
+			if book, err = LoadBook(path); err != nil {
+				t.Fatal(err)
+			}
+
+			gs := NewGame()
+			for _, s := range tt.moves {
+				move, err := gs.ParseMove(s)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if err := gs.Play(move); err != nil {
+					t.Fatal(err)
+				}
+			}
+			got := map[string]int{}
+			for _, move := range book.Moves(gs) {
+				got[move.Move.String()] = move.Weight
+			}
+			if len(got) != len(tt.want) {
+				t.Errorf("book moves %v, want %v", got, tt.want)
+			}
+			for move, weight := range tt.want {
+				if got[move] != weight {
+					t.Errorf("book moves %v, want %v", got, tt.want)
+					break
+				}
+			}
+		})
+	}
+}
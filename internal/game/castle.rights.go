@@ -1,22 +1,35 @@
 package game
 
-func (gs *GameState) whiteQueenSide() bool {
-	// 1. Validate the king
-	if piece := gs.board[102]; piece == nil || piece.Type != King || piece.HasMoved {
-		return false
-	}
+// castling rights, one bit for each side of each king
+const (
+	castleWhiteKing uint8 = 1 << iota
+	castleWhiteQueen
+	castleBlackKing
+	castleBlackQueen
+)
+
+// castlingLoss holds the castling rights lost when a piece moves from or onto each square
+var castlingLoss = [120]uint8{
+	14:  castleBlackQueen,
+	18:  castleBlackKing | castleBlackQueen,
+	21:  castleBlackKing,
+	98:  castleWhiteQueen,
+	102: castleWhiteKing | castleWhiteQueen,
+	105: castleWhiteKing,
+}
 
-	// 2. validate the rook
-	if piece := gs.board[98]; piece == nil || piece.Type != Rook || piece.HasMoved {
+func (gs *GameState) whiteQueenSide() bool {
+	// 1. Validate the king and rook have not moved
+	if gs.castling&castleWhiteQueen == 0 {
 		return false
 	}
 
-	// 3. validate the squares between the king and rook are empty
+	// 2. validate the squares between the king and rook are empty
 	if gs.board[101] != nil || gs.board[100] != nil || gs.board[99] != nil {
 		return false
 	}
 
-	// 4. validate that the king will not move through or into check
+	// 3. validate that the king will not move through or into check
 	if gs.isDangerous(102, Black) || gs.isDangerous(101, Black) || gs.isDangerous(100, Black) {
 		return false
 	}
@@ -25,22 +38,17 @@ func (gs *GameState) whiteQueenSide() bool {
 }
 
 func (gs *GameState) whiteKingSide() bool {
-	// 1. Validate the king
-	if piece := gs.board[102]; piece == nil || piece.Type != King || piece.HasMoved {
-		return false
-	}
-
-	// 2. validate the rook
-	if piece := gs.board[105]; piece == nil || piece.Type != Rook || piece.HasMoved {
+	// 1. Validate the king and rook have not moved
+	if gs.castling&castleWhiteKing == 0 {
 		return false
 	}
 
-	// 3. validate the squares between the king and rook are empty
+	// 2. validate the squares between the king and rook are empty
 	if gs.board[103] != nil || gs.board[104] != nil {
 		return false
 	}
 
-	// 4. validate that the king will not move through or into check
+	// 3. validate that the king will not move through or into check
 	if gs.isDangerous(102, Black) || gs.isDangerous(103, Black) || gs.isDangerous(104, Black) {
 		return false
 	}
@@ -49,22 +57,17 @@ func (gs *GameState) whiteKingSide() bool {
 }
 
 func (gs *GameState) blackQueenSide() bool {
-	// 1. Validate the king
-	if piece := gs.board[18]; piece == nil || piece.Type != King || piece.HasMoved {
-		return false
-	}
-
-	// 2. validate the rook
-	if piece := gs.board[14]; piece == nil || piece.Type != Rook || piece.HasMoved {
+	// 1. Validate the king and rook have not moved
+	if gs.castling&castleBlackQueen == 0 {
 		return false
 	}
 
-	// 3. validate the squares between the king and rook are empty
+	// 2. validate the squares between the king and rook are empty
 	if gs.board[17] != nil || gs.board[16] != nil || gs.board[15] != nil {
 		return false
 	}
 
-	// 4. validate that the king will not move through or into check
+	// 3. validate that the king will not move through or into check
 	if gs.isDangerous(18, White) || gs.isDangerous(17, White) || gs.isDangerous(16, White) {
 		return false
 	}
@@ -74,22 +77,17 @@ func (gs *GameState) blackQueenSide() bool {
 }
 
 func (gs *GameState) blackKingSide() bool {
-	// 1. Validate the king
-	if piece := gs.board[18]; piece == nil || piece.Type != King || piece.HasMoved {
-		return false
-	}
-
-	// 2. validate the rook
-	if piece := gs.board[21]; piece == nil || piece.Type != Rook || piece.HasMoved {
+	// 1. Validate the king and rook have not moved
+	if gs.castling&castleBlackKing == 0 {
 		return false
 	}
 
-	// 3. validate the squares between the king and rook are empty
+	// 2. validate the squares between the king and rook are empty
 	if gs.board[19] != nil || gs.board[20] != nil {
 		return false
 	}
 
-	// 4. validate that the king will not move through or into check
+	// 3. validate that the king will not move through or into check
 	if gs.isDangerous(18, White) || gs.isDangerous(19, White) || gs.isDangerous(20, White) {
 		return false
 	}
@@ -1,5 +1,8 @@
 package game
 
+// startingBoard is the board of the starting position, to compare other positions with
+var startingBoard = newBoard()
+
 func newBoard() [120]*Piece {
 	return [120]*Piece{
 		__(), __(), __(), __(), __(), __(), __(), __(), __(), __(), __(), __(),
@@ -15,54 +18,41 @@ func newBoard() [120]*Piece {
 	}
 }
 
-func bR() *Piece { return &Piece{Type: Rook, Color: Black, Value: 5} }
-func wR() *Piece { return &Piece{Type: Rook, Color: White, Value: 5} }
-func bK() *Piece { return &Piece{Type: King, Color: Black, Value: 10000} }
-func wK() *Piece { return &Piece{Type: King, Color: White, Value: 10000} }
-func bQ() *Piece { return &Piece{Type: Queen, Color: Black, Value: 9} }
-func wQ() *Piece { return &Piece{Type: Queen, Color: White, Value: 9} }
-func bB() *Piece { return &Piece{Type: Bishop, Color: Black, Value: 3} }
-func wB() *Piece { return &Piece{Type: Bishop, Color: White, Value: 3} }
-func bN() *Piece { return &Piece{Type: Knight, Color: Black, Value: 3} }
-func wN() *Piece { return &Piece{Type: Knight, Color: White, Value: 3} }
-func bP() *Piece { return &Piece{Type: Pawn, Color: Black, Value: 1} }
-func wP() *Piece { return &Piece{Type: Pawn, Color: White, Value: 1} }
+// pieceValues are the nominal values of the pieces in centipawns, used for material, SEE and move ordering. The king
+// is never captured, losing it being scored as mate instead
+var pieceValues = [6]int{King: 0, Queen: 900, Rook: 500, Bishop: 300, Knight: 300, Pawn: 100}
+
+func bR() *Piece { return &Piece{Type: Rook, Color: Black, Value: pieceValues[Rook]} }
+func wR() *Piece { return &Piece{Type: Rook, Color: White, Value: pieceValues[Rook]} }
+func bK() *Piece { return &Piece{Type: King, Color: Black, Value: pieceValues[King]} }
+func wK() *Piece { return &Piece{Type: King, Color: White, Value: pieceValues[King]} }
+func bQ() *Piece { return &Piece{Type: Queen, Color: Black, Value: pieceValues[Queen]} }
+func wQ() *Piece { return &Piece{Type: Queen, Color: White, Value: pieceValues[Queen]} }
+func bB() *Piece { return &Piece{Type: Bishop, Color: Black, Value: pieceValues[Bishop]} }
+func wB() *Piece { return &Piece{Type: Bishop, Color: White, Value: pieceValues[Bishop]} }
+func bN() *Piece { return &Piece{Type: Knight, Color: Black, Value: pieceValues[Knight]} }
+func wN() *Piece { return &Piece{Type: Knight, Color: White, Value: pieceValues[Knight]} }
+func bP() *Piece { return &Piece{Type: Pawn, Color: Black, Value: pieceValues[Pawn]} }
+func wP() *Piece { return &Piece{Type: Pawn, Color: White, Value: pieceValues[Pawn]} }
 func __() *Piece { return nil }
 
-var moveVectors = map[Type][][]int8{
-	King: {
-		{1},  // horizontal
-		{12}, // vertical
-		{11}, // diagonal 1
-		{13}, // diagonal 2
-	},
-	Queen: {
-		{1, 2, 3, 4, 5, 6, 7},        // horizontal
-		{12, 24, 36, 48, 60, 72, 84}, // vertical
-		{11, 22, 33, 44, 55, 66, 77}, // diagonal 1
-		{13, 26, 39, 52, 65, 78, 91}, // diagonal 2
-	},
-	Rook: {
-		{1, 2, 3, 4, 5, 6, 7},        // horizontal
-		{12, 24, 36, 48, 60, 72, 84}, // vertical
-	},
-	Bishop: {
-		{11, 22, 33, 44, 55, 66, 77}, // diagonal 1
-		{13, 26, 39, 52, 65, 78, 91}, // diagonal 2
-	},
-	Knight: {
-		{10},
-		{14},
-		{23},
-		{25},
-	},
-	Pawn: {
-		{12, 24}, // vertical
-		{11},     // diagonal 1
-		{13},     // diagonal 2
-	},
+// pieceSet holds one piece of each type by color index, shared by every square it is placed on
+var pieceSet = [2][6]*Piece{
+	{wK(), wQ(), wR(), wB(), wN(), wP()},
+	{bK(), bQ(), bR(), bB(), bN(), bP()},
 }
 
+// startingMaterial is the material of each player in the starting position
+var startingMaterial = func() int {
+	var material int
+	for _, piece := range newBoard() {
+		if piece != nil && piece.Color == White {
+			material += piece.Value
+		}
+	}
+	return material
+}()
+
 var validSquares = map[int8]struct{}{
 	14: {}, 15: {}, 16: {}, 17: {}, 18: {}, 19: {}, 20: {}, 21: {},
 	26: {}, 27: {}, 28: {}, 29: {}, 30: {}, 31: {}, 32: {}, 33: {},
@@ -73,8 +63,3 @@ var validSquares = map[int8]struct{}{
 	86: {}, 87: {}, 88: {}, 89: {}, 90: {}, 91: {}, 92: {}, 93: {},
 	98: {}, 99: {}, 100: {}, 101: {}, 102: {}, 103: {}, 104: {}, 105: {},
 }
-
-var promotionSquares = map[int8]struct{}{
-	14: {}, 15: {}, 16: {}, 17: {}, 18: {}, 19: {}, 20: {}, 21: {},
-	98: {}, 99: {}, 100: {}, 101: {}, 102: {}, 103: {}, 104: {}, 105: {},
-}
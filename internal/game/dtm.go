@@ -0,0 +1,731 @@
+package game
+
+import (
+	"bufio"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Depth to mate tables of small endings, generated by retrograde analysis from the engine's own attack tables and
+// stored in a local directory as one <material>.dtm file per ending, e.g. KQvKR.dtm. En passant is not considered
+
+// maxDTMPieces bounds the size of the tables, which index every placement of the pieces without symmetries
+const maxDTMPieces = 4
+
+// dtmMagic starts every table file, followed by the length and name of the material and the gzipped values
+const dtmMagic = "DTM1"
+
+// DefaultDTMEndings are the endings generated when none are given
+var DefaultDTMEndings = []string{"KQvK", "KRvK", "KPvK", "KBNvK", "KQvKR"}
+
+// dtmPiece is a piece of a table's material, in the order its square is indexed
+type dtmPiece struct {
+	Color Color
+	Type  Type
+}
+
+// DTMTable holds the distance to mate of every placement of its material, with either player to move
+type DTMTable struct {
+	Name   string
+	pieces []dtmPiece
+
+	// values by index: 0 for draws and impossible positions, otherwise the distance to mate in plies plus one,
+	// which is odd when the player to move is mated and even when they mate
+	values []uint8
+}
+
+// parseDTMMaterial returns the pieces of the material named like KBNvK, white first, and its canonical name
+func parseDTMMaterial(name string) ([]dtmPiece, string, error) {
+	sides := strings.Split(name, "v")
+	if len(sides) != 2 {
+		return nil, "", fmt.Errorf("ending %q: expected the material of both sides, like KQvK", name)
+	}
+
+	var pieces []dtmPiece
+	for i, side := range sides {
+		color := White
+		if i == 1 {
+			color = Black
+		}
+		if strings.Count(side, "K") != 1 {
+			return nil, "", fmt.Errorf("ending %q: each side needs exactly one king", name)
+		}
+		for _, c := range side {
+			pieceType := strings.IndexRune("KQRBNP", c)
+			if pieceType < 0 {
+				return nil, "", fmt.Errorf("ending %q: invalid piece %q", name, c)
+			}
+			pieces = append(pieces, dtmPiece{color, Type(pieceType)})
+		}
+	}
+	if len(pieces) > maxDTMPieces {
+		return nil, "", fmt.Errorf("ending %q: at most %d pieces are supported", name, maxDTMPieces)
+	}
+
+	sort.SliceStable(pieces, func(i, j int) bool {
+		if pieces[i].Color != pieces[j].Color {
+			return pieces[i].Color == White
+		}
+		return pieces[i].Type < pieces[j].Type
+	})
+	return pieces, dtmMaterialName(pieces, White) + "v" + dtmMaterialName(pieces, Black), nil
+}
+
+// dtmMaterialName returns the pieces of the color as used in table names, e.g. KBN
+func dtmMaterialName(pieces []dtmPiece, color Color) string {
+	var types []int
+	for _, piece := range pieces {
+		if piece.Color == color {
+			types = append(types, int(piece.Type))
+		}
+	}
+	sort.Ints(types)
+
+	var sb strings.Builder
+	for _, pieceType := range types {
+		sb.WriteByte("KQRBNP"[pieceType])
+	}
+	return sb.String()
+}
+
+func (t *DTMTable) size() int {
+	return 2 << (6 * len(t.pieces))
+}
+
+// index returns the index of the position with the pieces on the squares
+func (t *DTMTable) index(squares []int8, stm Color) int {
+	idx := 0
+	if stm == Black {
+		idx = 1
+	}
+	for _, sq := range squares {
+		idx = idx<<6 | int(sq)
+	}
+	return idx
+}
+
+// decode fills in the squares of the position with the index and returns the color to move
+func (t *DTMTable) decode(idx int, squares []int8) Color {
+	for i := len(t.pieces) - 1; i >= 0; i-- {
+		squares[i] = int8(idx & 63)
+		idx >>= 6
+	}
+	if idx == 1 {
+		return Black
+	}
+	return White
+}
+
+// dtmOccupancy returns the squares occupied by all the pieces and by those of the color
+func dtmOccupancy(pieces []dtmPiece, squares []int8, color Color) (occupied, own Bitboard) {
+	for i, sq := range squares {
+		occupied |= bit(sq)
+		if pieces[i].Color == color {
+			own |= bit(sq)
+		}
+	}
+	return occupied, own
+}
+
+// dtmLegal returns true if the pieces can stand on the squares with the color to move
+func dtmLegal(pieces []dtmPiece, squares []int8, stm Color) bool {
+	var occupied Bitboard
+	for i, sq := range squares {
+		if occupied&bit(sq) != 0 {
+			return false
+		}
+		occupied |= bit(sq)
+		if pieces[i].Type == Pawn && bit(sq)&(rank1|rank8) != 0 {
+			return false
+		}
+	}
+	return !dtmInCheck(pieces, squares, -stm, occupied)
+}
+
+// dtmInCheck returns true if the king of the color is attacked
+func dtmInCheck(pieces []dtmPiece, squares []int8, color Color, occupied Bitboard) bool {
+	for i, piece := range pieces {
+		if piece.Type == King && piece.Color == color {
+			return dtmAttacked(pieces, squares, squares[i], -color, occupied)
+		}
+	}
+	return false
+}
+
+// dtmAttacked returns true if a piece of the given color attacks the target square
+func dtmAttacked(pieces []dtmPiece, squares []int8, target int8, attacker Color, occupied Bitboard) bool {
+	for i, piece := range pieces {
+		if piece.Color != attacker {
+			continue
+		}
+		var attacks Bitboard
+		if piece.Type == Pawn {
+			attacks = pawnAttacks[attacker.index()][squares[i]]
+		} else {
+			attacks = attacksFrom(piece.Type, squares[i], occupied)
+		}
+		if attacks&bit(target) != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// dtmPawnTargets returns the squares a pawn of the color can move to
+func dtmPawnTargets(color Color, from int8, occupied, enemy Bitboard) Bitboard {
+	targets := pawnAttacks[color.index()][from] & enemy
+	step, start := int8(8), rank2
+	if color == Black {
+		step, start = -8, rank7
+	}
+	if bit(from+step)&occupied == 0 {
+		targets |= bit(from + step)
+		if bit(from)&start != 0 && bit(from+2*step)&occupied == 0 {
+			targets |= bit(from + 2*step)
+		}
+	}
+	return targets
+}
+
+// dtmPawnOrigins returns the squares a pawn of the color now on the square can have been pushed from
+func dtmPawnOrigins(color Color, to int8, occupied Bitboard) Bitboard {
+	step, fourth := int8(8), rank1<<24
+	if color == Black {
+		step, fourth = -8, rank1<<32
+	}
+	from := to - step
+	if from < 8 || from >= 56 || bit(from)&occupied != 0 {
+		return 0
+	}
+	origins := bit(from)
+	if bit(to)&fourth != 0 && bit(from-step)&occupied == 0 {
+		origins |= bit(from - step)
+	}
+	return origins
+}
+
+// dtmMaterial is the signature of a material, the number of pieces of each type of the white side then of the black
+// side, four bits each, to find tables by without building their names
+type dtmMaterial uint64
+
+// dtmMaterialOf returns the signature of the pieces, with the colors swapped if first is black
+func dtmMaterialOf(pieces []dtmPiece, first Color) dtmMaterial {
+	var m dtmMaterial
+	for _, piece := range pieces {
+		shift := 4 * uint(piece.Type)
+		if piece.Color != first {
+			shift += 24
+		}
+		m += 1 << shift
+	}
+	return m
+}
+
+// dtmBareKings is the signature of the material of the two kings alone
+var dtmBareKings = dtmMaterialOf([]dtmPiece{{White, King}, {Black, King}}, White)
+
+// dtmLookup returns the value of a position in the table that find returns for its material, or a draw if it has bare
+// kings or no table
+func dtmLookup(find func(dtmMaterial) *DTMTable, pieces []dtmPiece, squares []int8, stm Color) uint8 {
+	table, flip := find(dtmMaterialOf(pieces, White)), false
+	if table == nil {
+		table, flip = find(dtmMaterialOf(pieces, Black)), true
+	}
+	if table == nil {
+		return 0
+	}
+
+	// with the colors flipped, the board is mirrored so that pawns keep their direction
+	var mapped [maxDTMPieces]int8
+	used := 0
+	for j, want := range table.pieces {
+		for i, piece := range pieces {
+			color, sq := piece.Color, squares[i]
+			if flip {
+				color, sq = -color, sq^56
+			}
+			if used&(1<<i) == 0 && color == want.Color && piece.Type == want.Type {
+				used |= 1 << i
+				mapped[j] = sq
+				break
+			}
+		}
+	}
+	if flip {
+		stm = -stm
+	}
+	return table.values[table.index(mapped[:len(table.pieces)], stm)]
+}
+
+// dtmDependencies returns the endings reached by captures and promotions from the material
+func dtmDependencies(pieces []dtmPiece) []string {
+	var names []string
+	add := func(next []dtmPiece) {
+		names = append(names, dtmMaterialName(next, White)+"v"+dtmMaterialName(next, Black))
+	}
+	for i, piece := range pieces {
+		if piece.Type == King {
+			continue
+		}
+		add(append(append([]dtmPiece{}, pieces[:i]...), pieces[i+1:]...))
+		if piece.Type == Pawn {
+			for _, promotion := range []Type{Queen, Rook, Bishop, Knight} {
+				next := append([]dtmPiece{}, pieces...)
+				next[i].Type = promotion
+				add(next)
+			}
+		}
+	}
+	return names
+}
+
+// generateDTM builds the table of the material by retrograde analysis, given the tables of its dependencies
+func generateDTM(pieces []dtmPiece, name string, tables map[dtmMaterial]*DTMTable) *DTMTable {
+	t := &DTMTable{Name: name, pieces: pieces}
+	find := func(material dtmMaterial) *DTMTable { return tables[material] }
+	n := len(pieces)
+	size := t.size()
+	t.values = make([]uint8, size)
+
+	legal := make([]bool, size)
+	remaining := make([]uint8, size)
+	extLoss := make([]uint8, size)
+
+	// positions are resolved in order of distance to mate, so that the first distance found is the shortest
+	var levels [256][]uint32
+	queue := func(ply int, idx int) {
+		if ply < len(levels) {
+			levels[ply] = append(levels[ply], uint32(idx))
+		}
+	}
+
+	var squares [maxDTMPieces]int8
+
+	// 1. the positions that can occur
+	for idx := range legal {
+		stm := t.decode(idx, squares[:n])
+		legal[idx] = dtmLegal(pieces, squares[:n], stm)
+	}
+
+	// 2. the moves of every position, where mates and the outcomes of captures and promotions are known right away
+	var childPieces [maxDTMPieces]dtmPiece
+	var childSquares [maxDTMPieces]int8
+	for idx := range legal {
+		if !legal[idx] {
+			continue
+		}
+		stm := t.decode(idx, squares[:n])
+		occupied, own := dtmOccupancy(pieces, squares[:n], stm)
+
+		moves, internal, avoidLoss, loss := 0, 0, false, 0
+		for i, piece := range pieces {
+			if piece.Color != stm {
+				continue
+			}
+			from := squares[i]
+			var targets Bitboard
+			if piece.Type == Pawn {
+				targets = dtmPawnTargets(stm, from, occupied, occupied&^own)
+			} else {
+				targets = attacksFrom(piece.Type, from, occupied) &^ own
+			}
+
+			for targets != 0 {
+				to := targets.popLsb()
+				captured := -1
+				for j, sq := range squares[:n] {
+					if sq == to {
+						captured = j
+					}
+				}
+				promotion := piece.Type == Pawn && bit(to)&(rank1|rank8) != 0
+
+				// a quiet move stays within the table
+				if captured < 0 && !promotion {
+					squares[i] = to
+					child := t.index(squares[:n], -stm)
+					squares[i] = from
+					if legal[child] {
+						moves++
+						internal++
+					}
+					continue
+				}
+
+				// a capture or promotion leads into another table
+				types := []Type{piece.Type}
+				if promotion {
+					types = []Type{Queen, Rook, Bishop, Knight}
+				}
+				for _, pieceType := range types {
+					m := 0
+					for j := 0; j < n; j++ {
+						if j == captured {
+							continue
+						}
+						childPieces[m], childSquares[m] = pieces[j], squares[j]
+						if j == i {
+							childPieces[m].Type, childSquares[m] = pieceType, to
+						}
+						m++
+					}
+					childOccupied, _ := dtmOccupancy(childPieces[:m], childSquares[:m], stm)
+					if dtmInCheck(childPieces[:m], childSquares[:m], stm, childOccupied) {
+						continue
+					}
+					moves++
+
+					value := int(dtmLookup(find, childPieces[:m], childSquares[:m], -stm))
+					switch {
+					case value == 0:
+						avoidLoss = true
+					case value%2 == 1:
+						// the opponent is mated in value-1 plies
+						queue(value, idx)
+						avoidLoss = true
+					default:
+						loss = max(loss, value)
+					}
+				}
+			}
+		}
+
+		switch {
+		case moves == 0:
+			if dtmInCheck(pieces, squares[:n], stm, occupied) {
+				queue(0, idx)
+			}
+		case internal == 0 && !avoidLoss:
+			queue(loss, idx)
+		default:
+			remaining[idx] = uint8(internal)
+			if avoidLoss {
+				remaining[idx]++
+			}
+			extLoss[idx] = uint8(loss)
+		}
+	}
+
+	// 3. walk back from each resolved position to the positions that lead to it
+	for ply := range levels {
+		for _, idx := range levels[ply] {
+			if t.values[idx] != 0 {
+				continue
+			}
+			t.values[idx] = uint8(ply + 1)
+
+			stm := t.decode(int(idx), squares[:n])
+			occupied, _ := dtmOccupancy(pieces, squares[:n], stm)
+			for i, piece := range pieces {
+				if piece.Color != -stm {
+					continue
+				}
+				to := squares[i]
+				var origins Bitboard
+				if piece.Type == Pawn {
+					origins = dtmPawnOrigins(-stm, to, occupied)
+				} else {
+					origins = attacksFrom(piece.Type, to, occupied) &^ occupied
+				}
+
+				for origins != 0 {
+					squares[i] = origins.popLsb()
+					parent := t.index(squares[:n], -stm)
+					squares[i] = to
+					if !legal[parent] || t.values[parent] != 0 {
+						continue
+					}
+
+					if ply%2 == 0 {
+						// the position is lost, so moving into it wins
+						queue(ply+1, parent)
+						continue
+					}
+					// the position is won, and the parent is lost once all its moves are
+					remaining[parent]--
+					if remaining[parent] == 0 {
+						queue(max(ply+1, int(extLoss[parent])), parent)
+					}
+				}
+			}
+		}
+		levels[ply] = nil
+	}
+
+	return t
+}
+
+// GenerateDTM generates the tables of the named endings, and of the endings they lead to, into the directory.
+// Tables already in the directory are reused
+func GenerateDTM(dir string, names ...string) error {
+	tables := map[dtmMaterial]*DTMTable{}
+
+	var build func(name string) error
+	build = func(name string) error {
+		pieces, name, err := parseDTMMaterial(name)
+		if err != nil {
+			return err
+		}
+		material := dtmMaterialOf(pieces, White)
+		if material == dtmBareKings || tables[material] != nil || tables[dtmMaterialOf(pieces, Black)] != nil {
+			return nil
+		}
+
+		// 1. reuse a table generated before, with either side as white
+		white, black := dtmMaterialName(pieces, White), dtmMaterialName(pieces, Black)
+		for _, existing := range []string{name, black + "v" + white} {
+			if table, err := readDTM(filepath.Join(dir, existing+".dtm")); err == nil {
+				tables[dtmMaterialOf(table.pieces, White)] = table
+				return nil
+			}
+		}
+
+		// 2. the endings reached by captures and promotions come first
+		for _, dependency := range dtmDependencies(pieces) {
+			if err := build(dependency); err != nil {
+				return err
+			}
+		}
+
+		table := generateDTM(pieces, name, tables)
+		tables[material] = table
+		return table.write(filepath.Join(dir, name+".dtm"))
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	for _, name := range names {
+		if err := build(name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// write saves the table to the file
+func (t *DTMTable) write(path string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	w := bufio.NewWriter(file)
+	w.WriteString(dtmMagic)
+	w.WriteByte(byte(len(t.Name)))
+	w.WriteString(t.Name)
+
+	zw := gzip.NewWriter(w)
+	if _, err := zw.Write(t.values); err != nil {
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	return file.Close()
+}
+
+// readDTM loads the table saved in the file
+func readDTM(path string) (*DTMTable, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	r := bufio.NewReader(file)
+	header := make([]byte, len(dtmMagic)+1)
+	if _, err := io.ReadFull(r, header); err != nil || string(header[:len(dtmMagic)]) != dtmMagic {
+		return nil, fmt.Errorf("%s: not a DTM table", path)
+	}
+	name := make([]byte, header[len(dtmMagic)])
+	if _, err := io.ReadFull(r, name); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	pieces, canonical, err := parseDTMMaterial(string(name))
+	if err != nil || canonical != string(name) {
+		return nil, fmt.Errorf("%s: invalid material %q", path, name)
+	}
+	table := &DTMTable{Name: canonical, pieces: pieces}
+	table.values = make([]uint8, table.size())
+
+	zr, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if _, err := io.ReadFull(zr, table.values); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return table, nil
+}
+
+// dtmEntry is a table in the configured directory, loaded on first use
+type dtmEntry struct {
+	path  string
+	once  sync.Once
+	table *DTMTable
+}
+
+func (e *dtmEntry) load() *DTMTable {
+	e.once.Do(func() {
+		e.table, _ = readDTM(e.path)
+	})
+	return e.table
+}
+
+// dtmTables holds the tables found in the configured directory
+var dtmTables struct {
+	sync.RWMutex
+	entries   map[dtmMaterial]*dtmEntry
+	maxPieces int
+}
+
+// SetDTMPath configures the directory to read DTM tables from and returns the number of tables found.
+// An empty path disables probing
+func SetDTMPath(dir string) (int, error) {
+	entries := map[dtmMaterial]*dtmEntry{}
+	maxPieces := 0
+
+	if dir != "" {
+		if _, err := os.Stat(dir); err != nil {
+			return 0, err
+		}
+		paths, err := filepath.Glob(filepath.Join(dir, "*.dtm"))
+		if err != nil {
+			return 0, err
+		}
+		for _, path := range paths {
+			pieces, _, err := parseDTMMaterial(strings.TrimSuffix(filepath.Base(path), ".dtm"))
+			if err != nil {
+				continue
+			}
+			entries[dtmMaterialOf(pieces, White)] = &dtmEntry{path: path}
+			maxPieces = max(maxPieces, len(pieces))
+		}
+	}
+
+	dtmTables.Lock()
+	dtmTables.entries, dtmTables.maxPieces = entries, maxPieces
+	dtmTables.Unlock()
+
+	return len(entries), nil
+}
+
+// ProbeDTM returns the outcome of the position for the current player and the number of plies to mate, from the
+// DTM tables
+func (gs *GameState) ProbeDTM() (WDL, int, bool) {
+	dtmTables.RLock()
+	entries, maxPieces := dtmTables.entries, dtmTables.maxPieces
+	dtmTables.RUnlock()
+
+	all := gs.colors[0] | gs.colors[1]
+	if maxPieces == 0 || all.count() > maxPieces || gs.castling != 0 {
+		return Draw, 0, false
+	}
+	if gs.enPassantSquare != 0 && gs.pieces[0][Pawn] != 0 && gs.pieces[1][Pawn] != 0 {
+		return Draw, 0, false
+	}
+
+	var pieces [maxDTMPieces]dtmPiece
+	var squares [maxDTMPieces]int8
+	n := 0
+	for b := all; b != 0; n++ {
+		sq := b.popLsb()
+		piece := gs.board[to120[sq]]
+		pieces[n], squares[n] = dtmPiece{piece.Color, piece.Type}, sq
+	}
+
+	find := func(material dtmMaterial) *DTMTable {
+		if entry := entries[material]; entry != nil {
+			return entry.load()
+		}
+		return nil
+	}
+	white := dtmMaterialOf(pieces[:n], White)
+	if white != dtmBareKings && find(white) == nil && find(dtmMaterialOf(pieces[:n], Black)) == nil {
+		return Draw, 0, false
+	}
+
+	value := int(dtmLookup(find, pieces[:n], squares[:n], gs.currColor))
+	switch {
+	case value == 0:
+		return Draw, 0, true
+	case value%2 == 1:
+		return Loss, value - 1, true
+	}
+	return Win, value - 1, true
+}
+
+// DTMMove returns the move that mates the soonest, otherwise a drawing move, otherwise the loss that holds out the
+// longest, along with the outcome and plies to mate after playing it
+func (gs *GameState) DTMMove() (Move, WDL, int, bool) {
+	if _, _, ok := gs.ProbeDTM(); !ok {
+		return Move{}, Draw, 0, false
+	}
+
+	var moves MoveList
+	gs.legalMoves(&moves)
+
+	var best Move
+	bestDistance, found := 0, false
+	for _, move := range moves.Moves() {
+		gs.executeMove(move.Origin, move.Destination, move.MoveType)
+		wdl, plies, ok := gs.ProbeDTM()
+		gs.Undo()
+		if !ok {
+			return Move{}, Draw, 0, false
+		}
+
+		// signed distance for the player moving, counting the move itself
+		distance := 0
+		switch wdl {
+		case Loss:
+			distance = plies + 1
+		case Win:
+			distance = -(plies + 1)
+		}
+		if !found || dtzBetter(distance, bestDistance) {
+			best, bestDistance, found = move, distance, true
+		}
+	}
+	if !found {
+		return Move{}, Draw, 0, false
+	}
+
+	switch {
+	case bestDistance > 0:
+		return best, Win, bestDistance, true
+	case bestDistance < 0:
+		return best, Loss, -bestDistance, true
+	}
+	return best, Draw, 0, true
+}
+
+// probeDTMSearch returns the exact score of the position from white's perspective, for use inside the search. The
+// tables know the distance to mate, so their wins and losses are scored as mates
+func (gs *GameState) probeDTMSearch(ply int) (int, bool) {
+	wdl, plies, ok := gs.ProbeDTM()
+	if !ok {
+		return 0, false
+	}
+
+	var score int
+	switch wdl {
+	case Win:
+		score = MateScore - (ply + plies)
+	case Loss:
+		score = -MateScore + ply + plies
+	}
+	return score * int(gs.currColor), true
+}
@@ -0,0 +1,91 @@
+package game
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestDTM(t *testing.T) {
+	t.Cleanup(func() { SetDTMPath("") })
+
+	dir := t.TempDir()
+	if err := GenerateDTM(dir, "KQvK", "KRvK"); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := SetDTMPath(dir); n != 2 || err != nil {
+		t.Fatalf("SetDTMPath = %d, %v, want 2 tables", n, err)
+	}
+
+	t.Run("longest mates", func(t *testing.T) {
+		tests := []struct {
+			name  string
+			moves int
+		}{
+			{"KQvK", 10},
+			{"KRvK", 16},
+		}
+		for _, tt := range tests {
+			table, err := readDTM(filepath.Join(dir, tt.name+".dtm"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			longest := 0
+			for _, value := range table.values {
+				if value > 0 && value%2 == 0 {
+					longest = max(longest, int(value)-1)
+				}
+			}
+			if want := 2*tt.moves - 1; longest != want {
+				t.Errorf("%s: longest mate in %d plies, want %d", tt.name, longest, want)
+			}
+		}
+	})
+
+	t.Run("probe", func(t *testing.T) {
+		tests := []struct {
+			name  string
+			fen   string
+			wdl   WDL
+			plies int
+			ok    bool
+		}{
+			{"mate in one", "k7/8/1K6/8/8/8/8/7R w - - 0 1", Win, 1, true},
+			{"mated", "R6k/8/6K1/8/8/8/8/8 b - - 0 1", Loss, 0, true},
+			{"black mates in one", "7r/8/8/8/8/1k6/8/K7 b - - 0 1", Win, 1, true},
+			{"stalemate", "7k/5Q2/6K1/8/8/8/8/8 b - - 0 1", Draw, 0, true},
+			{"rook about to be taken", "8/8/8/8/8/8/k7/R3K3 b - - 0 1", Draw, 0, true},
+			{"bare kings", "8/8/8/3k4/8/8/8/4K3 w - - 0 1", Draw, 0, true},
+			{"no table", "8/8/8/3k4/8/8/4P3/4K3 w - - 0 1", Draw, 0, false},
+			{"too many pieces", "8/8/8/3k4/8/8/8/R2QK3 w - - 0 1", Draw, 0, false},
+		}
+		for _, tt := range tests {
+			wdl, plies, ok := mustFEN(t, tt.fen).ProbeDTM()
+			if wdl != tt.wdl || plies != tt.plies || ok != tt.ok {
+				t.Errorf("%s: ProbeDTM = %v, %d, %v, want %v, %d, %v", tt.name, wdl, plies, ok, tt.wdl, tt.plies, tt.ok)
+			}
+		}
+	})
+
+	// the best moves of both players bring the mate one ply closer each time, until it is played
+	t.Run("play out", func(t *testing.T) {
+		for _, fen := range []string{"8/8/8/3k4/8/8/8/R3K3 w - - 0 1", "8/8/2k5/8/8/8/8/Q6K b - - 0 1"} {
+			gs := mustFEN(t, fen)
+			_, plies, ok := gs.ProbeDTM()
+			if !ok {
+				t.Fatalf("%s: no table", fen)
+			}
+			for ; plies > 0; plies-- {
+				move, _, after, ok := gs.DTMMove()
+				if !ok || after != plies {
+					t.Fatalf("%s after %v: DTMMove = %v, %d plies, %v, want %d plies", fen, gs.Moves(), move, after, ok, plies)
+				}
+				if err := gs.Play(move); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if _, reason := gs.Result(); reason != "checkmate" {
+				t.Errorf("%s: %v ends in %q, want checkmate", fen, gs.Moves(), reason)
+			}
+		}
+	})
+}
@@ -7,6 +7,13 @@ const (
 	Black Color = -1
 )
 
+func (c Color) String() string {
+	if c == White {
+		return "White"
+	}
+	return "Black"
+}
+
 type MoveType int8
 
 const (
@@ -29,6 +36,21 @@ const (
 	KnightPromotion
 )
 
+// promotion returns the type of piece a promotion move promotes to
+func (mt MoveType) promotion() Type {
+	switch mt {
+	case QueenPromotion:
+		return Queen
+	case RookPromotion:
+		return Rook
+	case BishopPromotion:
+		return Bishop
+	case KnightPromotion:
+		return Knight
+	}
+	return Pawn
+}
+
 type Type int
 
 const (
@@ -39,3 +61,7 @@ const (
 	Knight
 	Pawn
 )
+
+func (t Type) String() string {
+	return [...]string{"King", "Queen", "Rook", "Bishop", "Knight", "Pawn"}[t]
+}
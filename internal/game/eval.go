@@ -0,0 +1,388 @@
+package game
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// EvalParams are the weights of the evaluation, in centipawns. Piece-square tables are from white's point of view and
+// listed like a printed board, a8 first and h1 last; black uses them mirrored
+type EvalParams struct {
+	PieceValues [6]int
+	PST         [6][64]int
+
+	Mobility     [6]int // per square attacked by a queen, rook, bishop or knight, other than those of its own pieces
+	KingShield   int    // per pawn one or two ranks in front of its king, on the king's file or one beside it
+	DoubledPawn  int    // per pawn on a file beyond the first of its side
+	IsolatedPawn int    // per pawn without pawns of its side on the files beside it
+	PassedPawn   [8]int // per pawn without enemy pawns ahead on its file or beside it, by its rank counted from its side
+}
+
+// DefaultEvalParams returns the hand-set weights the engine plays with until tuned ones are loaded: the nominal piece
+// values, piece-square tables that bring the pieces to the centre, the pawns forward and the king to safety, and
+// small bonuses for mobility, a pawn shield and healthy or passed pawns
+func DefaultEvalParams() *EvalParams {
+	return &EvalParams{
+		PieceValues:  pieceValues,
+		PST:          defaultPST,
+		Mobility:     [6]int{Queen: 1, Rook: 2, Bishop: 3, Knight: 3},
+		KingShield:   10,
+		DoubledPawn:  -10,
+		IsolatedPawn: -15,
+		PassedPawn:   [8]int{0, 5, 10, 20, 35, 60, 100, 0},
+	}
+}
+
+// defaultPST are the piece-square tables of the default weights, listed like those of EvalParams
+var defaultPST = [6][64]int{
+	King: {
+		-30, -40, -40, -50, -50, -40, -40, -30,
+		-30, -40, -40, -50, -50, -40, -40, -30,
+		-30, -40, -40, -50, -50, -40, -40, -30,
+		-30, -40, -40, -50, -50, -40, -40, -30,
+		-20, -30, -30, -40, -40, -30, -30, -20,
+		-10, -20, -20, -20, -20, -20, -20, -10,
+		20, 20, 0, 0, 0, 0, 20, 20,
+		20, 30, 10, 0, 0, 10, 30, 20,
+	},
+	Queen: {
+		-20, -10, -10, -5, -5, -10, -10, -20,
+		-10, 0, 0, 0, 0, 0, 0, -10,
+		-10, 0, 5, 5, 5, 5, 0, -10,
+		-5, 0, 5, 5, 5, 5, 0, -5,
+		0, 0, 5, 5, 5, 5, 0, -5,
+		-10, 5, 5, 5, 5, 5, 0, -10,
+		-10, 0, 5, 0, 0, 0, 0, -10,
+		-20, -10, -10, -5, -5, -10, -10, -20,
+	},
+	Rook: {
+		0, 0, 0, 0, 0, 0, 0, 0,
+		5, 10, 10, 10, 10, 10, 10, 5,
+		-5, 0, 0, 0, 0, 0, 0, -5,
+		-5, 0, 0, 0, 0, 0, 0, -5,
+		-5, 0, 0, 0, 0, 0, 0, -5,
+		-5, 0, 0, 0, 0, 0, 0, -5,
+		-5, 0, 0, 0, 0, 0, 0, -5,
+		0, 0, 0, 5, 5, 0, 0, 0,
+	},
+	Bishop: {
+		-20, -10, -10, -10, -10, -10, -10, -20,
+		-10, 0, 0, 0, 0, 0, 0, -10,
+		-10, 0, 5, 10, 10, 5, 0, -10,
+		-10, 5, 5, 10, 10, 5, 5, -10,
+		-10, 0, 10, 10, 10, 10, 0, -10,
+		-10, 10, 10, 10, 10, 10, 10, -10,
+		-10, 5, 0, 0, 0, 0, 5, -10,
+		-20, -10, -10, -10, -10, -10, -10, -20,
+	},
+	Knight: {
+		-50, -40, -30, -30, -30, -30, -40, -50,
+		-40, -20, 0, 0, 0, 0, -20, -40,
+		-30, 0, 10, 15, 15, 10, 0, -30,
+		-30, 5, 15, 20, 20, 15, 5, -30,
+		-30, 0, 15, 20, 20, 15, 0, -30,
+		-30, 5, 10, 15, 15, 10, 5, -30,
+		-40, -20, 0, 5, 5, 0, -20, -40,
+		-50, -40, -30, -30, -30, -30, -40, -50,
+	},
+	Pawn: {
+		0, 0, 0, 0, 0, 0, 0, 0,
+		50, 50, 50, 50, 50, 50, 50, 50,
+		10, 10, 20, 30, 30, 20, 10, 10,
+		5, 5, 10, 25, 25, 10, 5, 5,
+		0, 0, 0, 20, 20, 0, 0, 0,
+		5, -5, -10, 0, 0, -10, -5, 5,
+		5, 10, 10, -20, -20, 10, 10, 5,
+		0, 0, 0, 0, 0, 0, 0, 0,
+	},
+}
+
+// defaultEvalParams are given to every game created from here on
+var defaultEvalParams = DefaultEvalParams()
+
+// UseEvalParams makes the weights the evaluation of every game created from here on
+func UseEvalParams(params *EvalParams) {
+	defaultEvalParams = params
+}
+
+// SetEvalParams makes the weights the evaluation of the game
+func (gs *GameState) SetEvalParams(params *EvalParams) {
+	if gs.params != params {
+		gs.params = params
+		gs.clearTT()
+	}
+}
+
+// pstIndex returns the index into a piece-square table of a piece of the color on the square of the bitboards
+func pstIndex(color Color, sq int8) int {
+	if color == White {
+		return int(sq) ^ 56
+	}
+	return int(sq)
+}
+
+// EvalTerm is a part of the evaluation, as broken down by Explain
+type EvalTerm int
+
+const (
+	Material EvalTerm = iota
+	PieceSquare
+	Mobility
+	KingSafety
+	PawnStructure
+	numEvalTerms
+)
+
+var evalTermNames = [numEvalTerms]string{"Material", "Piece-square", "Mobility", "King safety", "Pawn structure"}
+
+func (t EvalTerm) String() string {
+	return evalTermNames[t]
+}
+
+// evaluate returns the static evaluation of the position from white's perspective
+func (gs *GameState) evaluate() int {
+	white, black := gs.sideTerms(White), gs.sideTerms(Black)
+	var score int
+	for term := range white {
+		score += white[term] - black[term]
+	}
+	return score
+}
+
+// sideTerms returns each term of the evaluation of the pieces of the color. The terms whose weights are all 0 are
+// not computed, which keeps the untuned evaluation fast
+func (gs *GameState) sideTerms(color Color) [numEvalTerms]int {
+	params := gs.params
+	var terms [numEvalTerms]int
+
+	// 1. material and piece-square tables
+	for pieceType := King; pieceType <= Pawn; pieceType++ {
+		for b := gs.pieces[color.index()][pieceType]; b != 0; {
+			sq := b.popLsb()
+			terms[Material] += params.PieceValues[pieceType]
+			terms[PieceSquare] += params.PST[pieceType][pstIndex(color, sq)]
+		}
+	}
+
+	// 2. mobility
+	if params.Mobility != [6]int{} {
+		for pieceType, squares := range gs.mobility(color) {
+			terms[Mobility] += params.Mobility[pieceType] * squares
+		}
+	}
+
+	// 3. king safety
+	if params.KingShield != 0 {
+		terms[KingSafety] = params.KingShield * gs.kingShield(color)
+	}
+
+	// 4. pawn structure
+	if params.DoubledPawn != 0 || params.IsolatedPawn != 0 || params.PassedPawn != [8]int{} {
+		doubled, isolated, passed := gs.pawnStructure(color)
+		terms[PawnStructure] = params.DoubledPawn*doubled + params.IsolatedPawn*isolated
+		for rank, pawns := range passed {
+			terms[PawnStructure] += params.PassedPawn[rank] * pawns
+		}
+	}
+
+	return terms
+}
+
+// mobility returns the number of squares attacked by the pieces of the color other than the king and pawns, not
+// counting those of their own pieces, by piece type
+func (gs *GameState) mobility(color Color) [6]int {
+	var squares [6]int
+	own := gs.colors[color.index()]
+	occupied := gs.colors[0] | gs.colors[1]
+	for pieceType := Queen; pieceType < Pawn; pieceType++ {
+		for b := gs.pieces[color.index()][pieceType]; b != 0; {
+			squares[pieceType] += (attacksFrom(pieceType, b.popLsb(), occupied) &^ own).count()
+		}
+	}
+	return squares
+}
+
+// kingShield returns the number of pawns of the color one or two ranks in front of their king, on its file or one
+// beside it
+func (gs *GameState) kingShield(color Color) int {
+	king := gs.pieces[color.index()][King]
+	if king == 0 {
+		return 0
+	}
+	sq := king.lsb()
+	file, rank, forward := sq%8, sq/8, int8(color)
+	shield := (fileMask(file) | adjacentFiles(file)) & (rankMask(rank+forward) | rankMask(rank+2*forward))
+	return (shield & gs.pieces[color.index()][Pawn]).count()
+}
+
+// pawnStructure returns the number of doubled and isolated pawns of the color, and of passed pawns by their rank
+// counted from its side, as classified by ClassifyPawns. Of the pawns doubled on a file, the first from its side is
+// not counted
+func (gs *GameState) pawnStructure(color Color) (doubled, isolated int, passed [8]int) {
+	pawns := gs.pieces[color.index()][Pawn]
+	for b := pawns; b != 0; {
+		sq := b.popLsb()
+		file, rank := sq%8, sq/8
+		class := gs.pawnClass(color, sq)
+		if class&Doubled != 0 && pawns&fileMask(file)&^ranksAhead(color, rank)&^rankMask(rank) != 0 {
+			doubled++
+		}
+		if class&Isolated != 0 {
+			isolated++
+		}
+		if class&Passed != 0 {
+			if color == Black {
+				rank = 7 - rank
+			}
+			passed[rank]++
+		}
+	}
+	return doubled, isolated, passed
+}
+
+// Save writes the weights to a text file that LoadEvalParams reads back
+func (p *EvalParams) Save(path string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	w := bufio.NewWriter(file)
+	fmt.Fprintln(w, "# evaluation weights in centipawns")
+	for pieceType := Queen; pieceType <= Pawn; pieceType++ {
+		fmt.Fprintf(w, "value %s %d\n", strings.ToLower(pieceType.String()), p.PieceValues[pieceType])
+	}
+	for pieceType := King; pieceType <= Pawn; pieceType++ {
+		fmt.Fprintf(w, "\npst %s\n", strings.ToLower(pieceType.String()))
+		for rank := 0; rank < 8; rank++ {
+			for file := 0; file < 8; file++ {
+				fmt.Fprintf(w, "%5d", p.PST[pieceType][rank*8+file])
+			}
+			fmt.Fprintln(w)
+		}
+	}
+
+	fmt.Fprintln(w)
+	for pieceType := Queen; pieceType < Pawn; pieceType++ {
+		fmt.Fprintf(w, "mobility %s %d\n", strings.ToLower(pieceType.String()), p.Mobility[pieceType])
+	}
+	fmt.Fprintf(w, "shield king %d\n", p.KingShield)
+	fmt.Fprintf(w, "doubled pawn %d\n", p.DoubledPawn)
+	fmt.Fprintf(w, "isolated pawn %d\n", p.IsolatedPawn)
+	fmt.Fprint(w, "passed pawn")
+	for _, weight := range p.PassedPawn {
+		fmt.Fprintf(w, " %d", weight)
+	}
+	fmt.Fprintln(w)
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	return file.Close()
+}
+
+// LoadEvalParams reads weights written by Save. Terms missing from the file keep their default
+func LoadEvalParams(path string) (*EvalParams, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	// 1. the file as a stream of words, without comments
+	var words []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		words = append(words, strings.Fields(line)...)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	pieceTypes := map[string]Type{}
+	for pieceType := King; pieceType <= Pawn; pieceType++ {
+		pieceTypes[strings.ToLower(pieceType.String())] = pieceType
+	}
+
+	// 2. the terms, each a keyword and piece followed by its numbers, e.g. value queen 900 or shield king 10
+	params := DefaultEvalParams()
+	numbers := func(values []int, at int) error {
+		if at+len(values) > len(words) {
+			return fmt.Errorf("%s: expected %d numbers after %q", path, len(values), words[at-1])
+		}
+		for i := range values {
+			value, err := strconv.Atoi(words[at+i])
+			if err != nil {
+				return fmt.Errorf("%s: invalid number %q", path, words[at+i])
+			}
+			values[i] = value
+		}
+		return nil
+	}
+	for i := 0; i < len(words); {
+		if i+1 >= len(words) {
+			return nil, fmt.Errorf("%s: unexpected %q at the end", path, words[i])
+		}
+		pieceType, ok := pieceTypes[words[i+1]]
+		if !ok {
+			return nil, fmt.Errorf("%s: invalid piece %q", path, words[i+1])
+		}
+
+		switch words[i] {
+		case "value":
+			if pieceType == King {
+				return nil, fmt.Errorf("%s: the king's value is fixed", path)
+			}
+			if err := numbers(params.PieceValues[pieceType:pieceType+1], i+2); err != nil {
+				return nil, err
+			}
+			i += 3
+		case "pst":
+			if err := numbers(params.PST[pieceType][:], i+2); err != nil {
+				return nil, err
+			}
+			i += 2 + 64
+		case "mobility":
+			if pieceType == King || pieceType == Pawn {
+				return nil, fmt.Errorf("%s: the %s has no mobility term", path, words[i+1])
+			}
+			if err := numbers(params.Mobility[pieceType:pieceType+1], i+2); err != nil {
+				return nil, err
+			}
+			i += 3
+		case "shield", "doubled", "isolated":
+			want, weight := Pawn, &params.DoubledPawn
+			switch words[i] {
+			case "shield":
+				want, weight = King, &params.KingShield
+			case "isolated":
+				weight = &params.IsolatedPawn
+			}
+			if pieceType != want {
+				return nil, fmt.Errorf("%s: the %s term is for the %s", path, words[i], strings.ToLower(want.String()))
+			}
+			value := []int{0}
+			if err := numbers(value, i+2); err != nil {
+				return nil, err
+			}
+			*weight = value[0]
+			i += 3
+		case "passed":
+			if pieceType != Pawn {
+				return nil, fmt.Errorf("%s: the passed term is for the pawn", path)
+			}
+			if err := numbers(params.PassedPawn[:], i+2); err != nil {
+				return nil, err
+			}
+			i += 2 + 8
+		default:
+			return nil, fmt.Errorf("%s: unknown term %q", path, words[i])
+		}
+	}
+
+	return params, nil
+}
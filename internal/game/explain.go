@@ -0,0 +1,40 @@
+package game
+
+import (
+	"fmt"
+	"strings"
+)
+
+// TermScore is a term of the evaluation as scored for each player, in centipawns
+type TermScore struct {
+	Term         EvalTerm
+	White, Black int
+}
+
+// Explanation is the static evaluation of a position broken down into its terms
+type Explanation struct {
+	Terms []TermScore
+	Total int // from white's perspective, as the search sees the position before looking at any move
+}
+
+// Explain breaks the static evaluation of the position down into its terms for each player
+func (gs *GameState) Explain() Explanation {
+	white, black := gs.sideTerms(White), gs.sideTerms(Black)
+	e := Explanation{Total: gs.evaluate()}
+	for term := Material; term < numEvalTerms; term++ {
+		e.Terms = append(e.Terms, TermScore{Term: term, White: white[term], Black: black[term]})
+	}
+	return e
+}
+
+// String prints the explanation as a table in pawns, the balance of each term from white's perspective
+func (e Explanation) String() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%-16s%8s%8s%9s\n", "Term", "White", "Black", "Balance")
+	for _, t := range e.Terms {
+		fmt.Fprintf(&sb, "%-16s%8.2f%8.2f%+9.2f\n", t.Term, float64(t.White)/100, float64(t.Black)/100,
+			float64(t.White-t.Black)/100)
+	}
+	fmt.Fprintf(&sb, "%-16s%25s\n", "Total", FormatScore(e.Total))
+	return sb.String()
+}
@@ -0,0 +1,64 @@
+package game
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestExplain(t *testing.T) {
+	tests := []struct {
+		name string
+		fen  string
+	}{
+		{"start", StartFEN},
+		{"extra rook", "4k3/pppppppp/8/8/8/8/PPPPPPPP/R3K3 w - - 0 1"},
+		{"passed pawn", "4k3/8/8/3P4/8/8/8/4K3 b - - 0 1"},
+		{"middlegame", "r1bqkb1r/pppp1ppp/2n2n2/4p2Q/2B1P3/8/PPPP1PPP/RNB1K1NR w KQkq - 4 4"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := mustFEN(t, tt.fen).Explain()
+			if len(e.Terms) != int(numEvalTerms) {
+				t.Fatalf("got %d terms, want %d", len(e.Terms), numEvalTerms)
+			}
+			var balance int
+			for i, term := range e.Terms {
+				if term.Term != EvalTerm(i) {
+					t.Errorf("term %d is %s", i, term.Term)
+				}
+				balance += term.White - term.Black
+			}
+			if balance != e.Total {
+				t.Errorf("terms add up to %d, total is %d", balance, e.Total)
+			}
+		})
+	}
+}
+
+func TestExplainBalance(t *testing.T) {
+	e := mustFEN(t, StartFEN).Explain()
+	for _, term := range e.Terms {
+		if term.White != term.Black {
+			t.Errorf("%s: white %d, black %d in the start position", term.Term, term.White, term.Black)
+		}
+	}
+
+	gs := mustFEN(t, "4k3/pppppppp/8/8/8/8/PPPPPPPP/R3K3 w - - 0 1")
+	material := gs.Explain().Terms[Material]
+	if got, want := material.White-material.Black, gs.params.PieceValues[Rook]; got != want {
+		t.Errorf("material balance with an extra rook = %d, want %d", got, want)
+	}
+}
+
+func TestExplainString(t *testing.T) {
+	s := mustFEN(t, StartFEN).Explain().String()
+	for term := Material; term < numEvalTerms; term++ {
+		if !strings.Contains(s, term.String()) {
+			t.Errorf("explanation is missing %s:\n%s", term, s)
+		}
+	}
+	if !strings.Contains(s, "Total") {
+		t.Errorf("explanation is missing the total:\n%s", s)
+	}
+}
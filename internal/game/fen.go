@@ -0,0 +1,203 @@
+package game
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// StartFEN is the FEN of the starting position
+const StartFEN = "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1"
+
+var fenPieces = map[rune]func() *Piece{
+	'K': wK, 'Q': wQ, 'R': wR, 'B': wB, 'N': wN, 'P': wP,
+	'k': bK, 'q': bQ, 'r': bR, 'b': bB, 'n': bN, 'p': bP,
+}
+
+// NewGameFromFEN returns a new game state with the board in the position described by the given FEN
+func NewGameFromFEN(fen string) (*GameState, error) {
+	fields := strings.Fields(fen)
+	if len(fields) < 4 {
+		return nil, fmt.Errorf("fen %q: expected at least 4 fields, got %d", fen, len(fields))
+	}
+
+	gs := &GameState{fullMoveNumber: 1, params: defaultEvalParams}
+
+	// 1. piece placement, from the 8th rank down to the 1st
+	ranks := strings.Split(fields[0], "/")
+	if len(ranks) != 8 {
+		return nil, fmt.Errorf("fen %q: expected 8 ranks, got %d", fen, len(ranks))
+	}
+	for i, rank := range ranks {
+		square := int8(12*(i+1) + 2)
+		for _, c := range rank {
+			if c >= '1' && c <= '8' {
+				square += int8(c - '0')
+				continue
+			}
+			newPiece, ok := fenPieces[c]
+			if !ok {
+				return nil, fmt.Errorf("fen %q: invalid piece %q", fen, c)
+			}
+			if _, ok := validSquares[square]; !ok {
+				return nil, fmt.Errorf("fen %q: rank %d is too long", fen, 8-i)
+			}
+			gs.putPiece(square, newPiece())
+			square++
+		}
+		if square != int8(12*(i+1)+10) {
+			return nil, fmt.Errorf("fen %q: rank %d does not have 8 squares", fen, 8-i)
+		}
+	}
+	for _, color := range []Color{White, Black} {
+		if gs.pieces[color.index()][King].count() != 1 {
+			return nil, fmt.Errorf("fen %q: %v needs exactly one king", fen, color)
+		}
+	}
+
+	// 2. active color
+	switch fields[1] {
+	case "w":
+		gs.currColor = White
+	case "b":
+		gs.currColor = Black
+	default:
+		return nil, fmt.Errorf("fen %q: invalid active color %q", fen, fields[1])
+	}
+
+	// 3. castling rights
+	if fields[2] != "-" && strings.Trim(fields[2], "KQkq") != "" {
+		return nil, fmt.Errorf("fen %q: invalid castling rights %q", fen, fields[2])
+	}
+	for _, right := range []struct {
+		symbol     string
+		right      uint8
+		king, rook *Piece
+	}{
+		{"K", castleWhiteKing, gs.board[102], gs.board[105]},
+		{"Q", castleWhiteQueen, gs.board[102], gs.board[98]},
+		{"k", castleBlackKing, gs.board[18], gs.board[21]},
+		{"q", castleBlackQueen, gs.board[18], gs.board[14]},
+	} {
+		if !strings.Contains(fields[2], right.symbol) {
+			continue
+		}
+		color := White
+		if right.right&(castleBlackKing|castleBlackQueen) != 0 {
+			color = Black
+		}
+		if right.king == nil || right.king.Type != King || right.king.Color != color ||
+			right.rook == nil || right.rook.Type != Rook || right.rook.Color != color {
+			return nil, fmt.Errorf("fen %q: castling right %q without king and rook in place", fen, right.symbol)
+		}
+		gs.castling |= right.right
+	}
+
+	// 4. en passant square
+	if fields[3] != "-" {
+		square, ok := parseSquare(fields[3])
+		if !ok {
+			return nil, fmt.Errorf("fen %q: invalid en passant square %q", fen, fields[3])
+		}
+		// the square was just crossed by a pawn of the opponent moving two squares, from behind it to in front of it
+		forward, rank := int8(gs.currColor)*12, int8(6)
+		if gs.currColor == Black {
+			rank = 3
+		}
+		pawn := gs.board[square+forward]
+		if 9-square/12 != rank || gs.board[square] != nil || gs.board[square-forward] != nil ||
+			pawn == nil || pawn.Type != Pawn || pawn.Color != -gs.currColor {
+			return nil, fmt.Errorf("fen %q: en passant square %q without a pawn that just moved two squares", fen, fields[3])
+		}
+		gs.enPassantSquare = square
+	}
+
+	// 5. move counters
+	if len(fields) >= 6 {
+		halfMoveClock, err := strconv.Atoi(fields[4])
+		if err != nil || halfMoveClock < 0 {
+			return nil, fmt.Errorf("fen %q: invalid halfmove clock %q", fen, fields[4])
+		}
+		fullMoveNumber, err := strconv.Atoi(fields[5])
+		if err != nil || fullMoveNumber < 1 {
+			return nil, fmt.Errorf("fen %q: invalid fullmove number %q", fen, fields[5])
+		}
+		gs.halfMoveClock, gs.fullMoveNumber = halfMoveClock, fullMoveNumber
+	}
+
+	gs.hash = gs.computeHash()
+
+	return gs, nil
+}
+
+// FEN returns the FEN of the current position
+func (gs *GameState) FEN() string {
+	var sb strings.Builder
+
+	// 1. piece placement
+	for row := 1; row <= 8; row++ {
+		empty := 0
+		for col := 2; col <= 9; col++ {
+			piece := gs.board[row*12+col]
+			if piece == nil {
+				empty++
+				continue
+			}
+			if empty > 0 {
+				sb.WriteByte(byte('0' + empty))
+				empty = 0
+			}
+			sb.WriteByte(pieceLetter(piece))
+		}
+		if empty > 0 {
+			sb.WriteByte(byte('0' + empty))
+		}
+		if row < 8 {
+			sb.WriteByte('/')
+		}
+	}
+
+	// 2. active color
+	if gs.currColor == White {
+		sb.WriteString(" w ")
+	} else {
+		sb.WriteString(" b ")
+	}
+
+	// 3. castling rights
+	castling := ""
+	for i, symbol := range "KQkq" {
+		if gs.castling&(1<<i) != 0 {
+			castling += string(symbol)
+		}
+	}
+	if castling == "" {
+		castling = "-"
+	}
+	sb.WriteString(castling)
+
+	// 4. en passant square
+	sb.WriteString(" " + SquareName(gs.enPassantSquare))
+
+	// 5. move counters
+	fmt.Fprintf(&sb, " %d %d", gs.halfMoveClock, gs.fullMoveNumber)
+
+	return sb.String()
+}
+
+// pieceLetter returns the FEN letter of the piece, upper case for white and lower case for black
+func pieceLetter(piece *Piece) byte {
+	letter := "kqrbnp"[piece.Type]
+	if piece.Color == White {
+		letter -= 'a' - 'A'
+	}
+	return letter
+}
+
+// parseSquare returns the square on the 120 square board with the given algebraic name, e.g. e1 is 102
+func parseSquare(name string) (int8, bool) {
+	if len(name) != 2 || name[0] < 'a' || name[0] > 'h' || name[1] < '1' || name[1] > '8' {
+		return 0, false
+	}
+	return int8((9-int(name[1]-'0'))*12 + int(name[0]-'a') + 2), true
+}
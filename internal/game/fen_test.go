@@ -0,0 +1,78 @@
+package game
+
+import "testing"
+
+func TestFENRoundTrip(t *testing.T) {
+	fens := []string{
+		StartFEN,
+		"rnbqkbnr/pppp1ppp/8/4p3/8/8/PPPPPPPP/RNBQKBNR w KQkq e6 0 2",
+		"rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq e3 0 1",
+		"r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1",
+		"r3k2r/8/8/8/8/8/8/R3K2R b Kq - 12 40",
+		"8/2p5/3p4/KP5r/1R3p1k/8/4P1P1/8 w - - 0 1",
+	}
+	for _, p := range PerftSuite {
+		fens = append(fens, p.FEN)
+	}
+
+	for _, fen := range fens {
+		gs, err := NewGameFromFEN(fen)
+		if err != nil {
+			t.Errorf("%s: %v", fen, err)
+			continue
+		}
+		if got := gs.FEN(); got != fen {
+			t.Errorf("%s: read back as %s", fen, got)
+		}
+	}
+}
+
+func TestFENAfterMoves(t *testing.T) {
+	gs := NewGame()
+	for _, s := range []string{"e2e4", "c7c5", "g1f3"} {
+		move, err := gs.ParseMove(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := gs.Play(move); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got, want := gs.FEN(), "rnbqkbnr/pp1ppppp/8/2p5/4P3/5N2/PPPP1PPP/RNBQKB1R b KQkq - 1 2"; got != want {
+		t.Errorf("FEN() = %s, want %s", got, want)
+	}
+}
+
+func TestFENRejected(t *testing.T) {
+	tests := []struct {
+		name string
+		fen  string
+	}{
+		{"too few fields", "8/8/8/8/8/8/8/8 w"},
+		{"short rank", "rnbqkbnr/ppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1"},
+		{"unknown piece", "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBXKBNR w KQkq - 0 1"},
+		{"invalid color", "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR x KQkq - 0 1"},
+		{"castling without rook", "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBN1 w KQkq - 0 1"},
+		{"en passant on the back rank", "k7/3P4/8/8/8/8/8/4K3 w - e8 0 1"},
+		{"en passant on the mover's side", "k7/8/8/8/8/8/3P4/4K3 w - e3 0 1"},
+		{"en passant without a pawn", "4k3/8/8/8/8/8/8/4K3 w - e6 0 1"},
+		{"en passant with the pawn's square taken", "4k3/4p3/8/4p3/8/8/8/4K3 w - e6 0 1"},
+		{"negative halfmove clock", "4k3/8/8/8/8/8/8/4K3 w - - -1 1"},
+		{"zero fullmove number", "4k3/8/8/8/8/8/8/4K3 w - - 0 0"},
+	}
+	for _, test := range tests {
+		if _, err := NewGameFromFEN(test.fen); err == nil {
+			t.Errorf("%s: %s was accepted", test.name, test.fen)
+		}
+	}
+}
+
+// mustFEN returns the position of the FEN, failing the test if it is invalid
+func mustFEN(t *testing.T, fen string) *GameState {
+	t.Helper()
+	gs, err := NewGameFromFEN(fen)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return gs
+}
@@ -2,44 +2,79 @@ package game
 
 import (
 	"fmt"
-	"math"
+	"time"
 )
 
 type Piece struct {
-	Type     Type
-	Color    Color
-	Value    float64
+	Type  Type
+	Color Color
+	Value int
+
+	// HasMoved is filled in on the copies PieceAt returns, as the pieces on the board are shared between squares
 	HasMoved bool
 }
 
 type GameState struct {
 	board           [120]*Piece
 	enPassantSquare int8
+	castling        uint8
 	currColor       Color
 
-	kingSquares map[Color]int8
+	// bitboards of the pieces by color index and type, and of all pieces of each color
+	pieces [2][6]Bitboard
+	colors [2]Bitboard
+
+	// material of each player by color index, kept in step with the pieces
+	score [2]int
+
+	hash uint64
+
+	// weights of the evaluation
+	params *EvalParams
+
+	history []HistoryEntry
 
-	score map[Color]float64
+	// move counters, as in FEN
+	halfMoveClock  int
+	fullMoveNumber int
 
-	allMoves         map[Color]map[int8]map[int8]map[MoveType]struct{}
-	attackingSquares map[Color]map[int8]struct{}
+	// search statistics and limits
+	nodes      int
+	maxNodes   int
+	deadline   time.Time
+	stopped    bool
+	stopSignal <-chan struct{} // closed from outside the search to end it early
 
-	history []*HistoryEntry
+	// positions already searched, allocated by the first search
+	tt []ttEntry
+
+	// triangular table of the principal variations found at each ply of the search
+	pvTable  [maxPly][maxPly]Move
+	pvLength [maxPly]int
+
+	// how deep each quiet move has refuted the moves before it, by color index, origin and destination on the
+	// bitboards, to try the most successful first
+	historyTable [2][64][64]int
 }
 
+// maxPly bounds the depth of the search
+const maxPly = 64
+
 // NewGame returns a new game state with the board in the starting position
 func NewGame() *GameState {
-	board := newBoard()
 	gs := &GameState{
-		board:            board,
-		currColor:        White,
-		kingSquares:      map[Color]int8{White: 102, Black: 18},
-		score:            map[Color]float64{White: 0, Black: 0},
-		allMoves:         map[Color]map[int8]map[int8]map[MoveType]struct{}{White: {}, Black: {}},
-		attackingSquares: map[Color]map[int8]struct{}{White: {}, Black: {}},
+		currColor:      White,
+		castling:       castleWhiteKing | castleWhiteQueen | castleBlackKing | castleBlackQueen,
+		fullMoveNumber: 1,
+		params:         defaultEvalParams,
 	}
 
-	gs.update()
+	for square, piece := range newBoard() {
+		if piece != nil {
+			gs.putPiece(int8(square), piece)
+		}
+	}
+	gs.hash = gs.computeHash()
 
 	return gs
 }
@@ -117,138 +152,87 @@ func (gs *GameState) PrettyPrint() {
 		}
 	}
 	fmt.Print(reset)
-	fmt.Printf("\nWhite Score: %f\n", gs.score[White])
-	fmt.Printf("Black Score: %f\n", gs.score[Black])
+	fmt.Printf("\nWhite Score: %d\n", gs.score[White.index()]-startingMaterial)
+	fmt.Printf("Black Score: %d\n", gs.score[Black.index()]-startingMaterial)
 
 }
 
 // CurrentPlayer returns the current player as a string
 func (gs *GameState) CurrentPlayer() string {
-	if gs.currColor == White {
-		return "White"
-	}
-	return "Black"
+	return gs.currColor.String()
 }
 
-// updateValidMoves returns a list of valid moves for the piece at the given square, without checking for check
-func (gs *GameState) updateValidMoves(square int8) {
-	// You cannot move a piece that doesn't exist
-	if gs.board[square] == nil {
-		return
+// SideToMove returns the color of the current player
+func (gs *GameState) SideToMove() Color {
+	return gs.currColor
+}
+
+// PieceAt returns the piece on a square of the 120 square board, and false if there is none
+func (gs *GameState) PieceAt(square int8) (Piece, bool) {
+	if square < 0 || int(square) >= len(gs.board) || gs.board[square] == nil {
+		return Piece{}, false
 	}
+	piece := *gs.board[square]
+	piece.HasMoved = gs.hasMoved(square)
+	return piece, true
+}
 
-	currPiece := gs.board[square]
-
-	gs.allMoves[currPiece.Color][square] = make(map[int8]map[MoveType]struct{})
-
-	// Handle the movement vectors
-	for _, vector := range moveVectors[currPiece.Type] {
-		for _, direction := range []int8{1, -1} {
-			for _, offset := range vector {
-				target := square + offset*direction
-
-				// 1. Ensure the move is on the board
-				if _, ok := validSquares[target]; !ok {
-					break
-				}
-
-				// 2. Ensure the move does not capture a friendly piece
-				if gs.board[target] != nil && gs.board[target].Color == currPiece.Color {
-					break
-				}
-
-				// 3. Handle pawns
-				if currPiece.Type == Pawn {
-
-					// a. Ensure that a pawn does not move backwards
-					if int8(currPiece.Color) == direction {
-						break
-					}
-
-					// b. Ensure the pawn does not move forward if the square is occupied
-					if offset%12 == 0 && gs.board[target] != nil {
-						break
-					}
-
-					// c. The pawn moves forward one square
-					if offset == 12 {
-
-						// handle promotions
-						if _, ok := promotionSquares[target]; ok {
-							gs.allMoves[currPiece.Color][square][target] = map[MoveType]struct{}{
-								QueenPromotion:  {},
-								RookPromotion:   {},
-								BishopPromotion: {},
-								KnightPromotion: {},
-							}
-						} else {
-							gs.allMoves[currPiece.Color][square][target] = map[MoveType]struct{}{Neutral: {}}
-						}
-
-						// d. The pawn moves forward two squares
-					} else if offset == 24 && !currPiece.HasMoved {
-						gs.allMoves[currPiece.Color][square][target] = map[MoveType]struct{}{EnPassantPrimer: {}}
-
-						// e. The pawn captures diagonally
-					} else if gs.board[target] != nil {
-						gs.attackingSquares[currPiece.Color][target] = struct{}{}
-						// handle promotions
-						if _, ok := promotionSquares[target]; ok {
-							gs.allMoves[currPiece.Color][square][target] = map[MoveType]struct{}{
-								QueenPromotion:  {},
-								RookPromotion:   {},
-								BishopPromotion: {},
-								KnightPromotion: {},
-							}
-						} else {
-							gs.allMoves[currPiece.Color][square][target] = map[MoveType]struct{}{Neutral: {}}
-						}
-
-						// f. The pawn captures en passant
-					} else if target == gs.enPassantSquare && gs.currColor == currPiece.Color { // en passant capture
-						gs.allMoves[currPiece.Color][square][target] = map[MoveType]struct{}{EnPassantAttack: {}}
-						// g. Mark the pawns diagonal as an attacking square
-					} else {
-						gs.attackingSquares[currPiece.Color][target] = struct{}{}
-					}
-				} else {
-					gs.allMoves[currPiece.Color][square][target] = map[MoveType]struct{}{Neutral: {}}
-					gs.attackingSquares[currPiece.Color][target] = struct{}{}
-				}
-
-				// if the move vector is blocked, we should break
-				if gs.board[target] != nil {
-					break
-				}
+// hasMoved returns true if the piece on the square has moved: a move brought it there, or it stood away from its
+// starting square when the game was set up, or it is a king or rook that had already lost the castling rights that
+// need it home
+func (gs *GameState) hasMoved(square int8) bool {
+	castling := gs.castling
+	for i := len(gs.history) - 1; i >= 0; i-- {
+		entry := &gs.history[i]
+		for _, action := range entry.Actions[:entry.actionCount] {
+			if action.To == square && action.enPassantPawn == nil {
+				return true
 			}
 		}
+		castling = entry.castling
+	}
+
+	piece, start := gs.board[square], startingBoard[square]
+	if start == nil || start.Type != piece.Type || start.Color != piece.Color {
+		return true
 	}
+	return castlingLoss[square] != 0 && castling&castlingLoss[square] == 0
 }
 
 // executeMove executes a move on the board w/o doing any validation
 func (gs *GameState) executeMove(origin, destination int8, moveType MoveType) {
 
-	changeLog := &HistoryEntry{
-		Actions:         []Action{{From: origin, To: destination, hasMoved: gs.board[origin].HasMoved, capture: gs.board[destination]}},
+	changeLog := HistoryEntry{
+		Actions:         [2]Action{{From: origin, To: destination, capture: gs.board[destination]}},
+		actionCount:     1,
 		enPassantSquare: gs.enPassantSquare,
-		whiteKingSquare: gs.kingSquares[White],
-		blackKingSquare: gs.kingSquares[Black],
-		blackScore:      gs.score[Black],
-		whiteScore:      gs.score[White],
+		castling:        gs.castling,
+		halfMoveClock:   gs.halfMoveClock,
+		hash:            gs.hash,
+		moveType:        moveType,
 	}
 
-	// handle a typical move
-	if capture := gs.board[destination]; capture != nil {
-		gs.score[capture.Color] -= capture.Value
+	// take the castling rights and en passant square out of the hash, they are put back once the move is made
+	gs.hash ^= gs.stateHash()
+
+	// update the move counters
+	if gs.board[origin].Type == Pawn || gs.board[destination] != nil {
+		gs.halfMoveClock = 0
+	} else {
+		gs.halfMoveClock++
+	}
+	if gs.currColor == Black {
+		gs.fullMoveNumber++
 	}
-	gs.board[destination] = gs.board[origin]
-	gs.board[origin] = nil
-	gs.board[destination].HasMoved = true
 
-	// set the king square
-	if king := gs.board[destination]; king.Type == King {
-		gs.kingSquares[king.Color] = destination
+	// handle a typical move
+	if gs.board[destination] != nil {
+		gs.removePiece(destination)
 	}
+	gs.movePiece(origin, destination)
+
+	// moving a king or rook, or capturing a rook, gives up castling on that side
+	gs.castling &^= castlingLoss[origin] | castlingLoss[destination]
 
 	// reset the en passant square
 	gs.enPassantSquare = 0
@@ -257,53 +241,29 @@ func (gs *GameState) executeMove(origin, destination int8, moveType MoveType) {
 	switch moveType {
 	// castling
 	case WhiteKingSideCastle:
-		gs.board[103] = gs.board[105]
-		gs.board[105] = nil
-		gs.board[103].HasMoved = true
-		changeLog.Actions = append(changeLog.Actions, Action{From: 105, To: 103, hasMoved: false, capture: nil})
+		gs.movePiece(105, 103)
+		changeLog.Actions[1], changeLog.actionCount = Action{From: 105, To: 103}, 2
 	case WhiteQueenSideCastle:
-		gs.board[101] = gs.board[98]
-		gs.board[98] = nil
-		gs.board[101].HasMoved = true
-		changeLog.Actions = append(changeLog.Actions, Action{From: 98, To: 101, hasMoved: false, capture: nil})
+		gs.movePiece(98, 101)
+		changeLog.Actions[1], changeLog.actionCount = Action{From: 98, To: 101}, 2
 	case BlackKingSideCastle:
-		gs.board[19] = gs.board[21]
-		gs.board[21] = nil
-		gs.board[19].HasMoved = true
-		changeLog.Actions = append(changeLog.Actions, Action{From: 21, To: 19, hasMoved: false, capture: nil})
+		gs.movePiece(21, 19)
+		changeLog.Actions[1], changeLog.actionCount = Action{From: 21, To: 19}, 2
 	case BlackQueenSideCastle:
-		gs.board[17] = gs.board[14]
-		gs.board[14] = nil
-		gs.board[17].HasMoved = true
-		changeLog.Actions = append(changeLog.Actions, Action{From: 14, To: 17, hasMoved: false, capture: nil})
+		gs.movePiece(14, 17)
+		changeLog.Actions[1], changeLog.actionCount = Action{From: 14, To: 17}, 2
 
 	// en passant
 	case EnPassantAttack:
 		square := destination + 12*int8(gs.currColor)
-		enPassantPawn := gs.board[square]
-		gs.score[enPassantPawn.Color] -= enPassantPawn.Value
-		changeLog.Actions = append(changeLog.Actions, Action{From: square, To: square, hasMoved: false, enPassantPawn: enPassantPawn})
-		gs.board[square] = nil
+		changeLog.Actions[1], changeLog.actionCount = Action{From: square, To: square, enPassantPawn: gs.removePiece(square)}, 2
 	case EnPassantPrimer:
 		gs.enPassantSquare = destination + 12*int8(gs.currColor)
 
 	// promotions
-	case QueenPromotion:
-		changeLog.Actions[0].promotionPawn = gs.board[destination]
-		gs.score[gs.currColor] += 8
-		gs.board[destination] = &Piece{Type: Queen, Color: gs.currColor, Value: 9, HasMoved: true}
-	case RookPromotion:
-		changeLog.Actions[0].promotionPawn = gs.board[destination]
-		gs.score[gs.currColor] += 4
-		gs.board[destination] = &Piece{Type: Rook, Color: gs.currColor, Value: 5, HasMoved: true}
-	case BishopPromotion:
-		changeLog.Actions[0].promotionPawn = gs.board[destination]
-		gs.score[gs.currColor] += 2
-		gs.board[destination] = &Piece{Type: Bishop, Color: gs.currColor, Value: 3, HasMoved: true}
-	case KnightPromotion:
-		changeLog.Actions[0].promotionPawn = gs.board[destination]
-		gs.score[gs.currColor] += 2
-		gs.board[destination] = &Piece{Type: Knight, Color: gs.currColor, Value: 3, HasMoved: true}
+	case QueenPromotion, RookPromotion, BishopPromotion, KnightPromotion:
+		changeLog.Actions[0].promotionPawn = gs.removePiece(destination)
+		gs.putPiece(destination, pieceSet[gs.currColor.index()][moveType.promotion()])
 	}
 
 	// update the history
@@ -311,128 +271,200 @@ func (gs *GameState) executeMove(origin, destination int8, moveType MoveType) {
 
 	// update the current player
 	gs.currColor *= -1
+	gs.hash ^= zobristSide ^ gs.stateHash()
+}
 
-	gs.update()
+// putPiece places the piece on the given empty square
+func (gs *GameState) putPiece(square int8, piece *Piece) {
+	gs.board[square] = piece
+	sq := to64[square]
+	gs.pieces[piece.Color.index()][piece.Type] |= bit(sq)
+	gs.colors[piece.Color.index()] |= bit(sq)
+	gs.score[piece.Color.index()] += piece.Value
+	gs.hash ^= zobristPieces[piece.Color.index()][piece.Type][sq]
 }
 
-// update updates the game state to reflect the current board
-func (gs *GameState) update() {
+// removePiece removes the piece on the given square and returns it
+func (gs *GameState) removePiece(square int8) *Piece {
+	piece := gs.board[square]
+	gs.board[square] = nil
+	sq := to64[square]
+	gs.pieces[piece.Color.index()][piece.Type] &^= bit(sq)
+	gs.colors[piece.Color.index()] &^= bit(sq)
+	gs.score[piece.Color.index()] -= piece.Value
+	gs.hash ^= zobristPieces[piece.Color.index()][piece.Type][sq]
+	return piece
+}
 
-	// reset the valid moves state
-	gs.allMoves = map[Color]map[int8]map[int8]map[MoveType]struct{}{White: {}, Black: {}}
-	gs.attackingSquares = map[Color]map[int8]struct{}{White: {}, Black: {}}
+// movePiece moves the piece on the origin square to the empty destination square
+func (gs *GameState) movePiece(origin, destination int8) {
+	gs.putPiece(destination, gs.removePiece(origin))
+}
 
-	for i := range gs.board {
-		gs.updateValidMoves(int8(i))
-	}
+// isDangerous returns true if the given square is being attacked by the given color
+func (gs *GameState) isDangerous(square int8, attacker Color) bool {
+	return gs.attackersTo(to64[square], attacker, gs.colors[0]|gs.colors[1]) != 0
+}
 
-	// check if white can castle on king side
-	if gs.whiteKingSide() {
-		gs.allMoves[White][102][104] = map[MoveType]struct{}{WhiteKingSideCastle: {}}
+// inCheck returns true if the king of the given color is attacked, or has been captured
+func (gs *GameState) inCheck(color Color) bool {
+	king := gs.pieces[color.index()][King]
+	if king == 0 {
+		return true
 	}
+	return gs.attackersTo(king.lsb(), -color, gs.colors[0]|gs.colors[1]) != 0
+}
 
-	// check if white can castle on queen side
-	if gs.whiteQueenSide() {
-		gs.allMoves[White][102][100] = map[MoveType]struct{}{WhiteQueenSideCastle: {}}
+func (gs *GameState) ExecuteMove(origin, destination int8, moveType MoveType) bool {
+	var moves MoveList
+	gs.generateMoves(&moves)
+	for _, move := range moves.Moves() {
+		if move == (Move{Origin: origin, Destination: destination, MoveType: moveType}) {
+			gs.executeMove(origin, destination, moveType)
+			return true
+		}
 	}
+	return false
+}
 
-	// check if black can castle on king side
-	if gs.blackKingSide() {
-		gs.allMoves[Black][18][20] = map[MoveType]struct{}{BlackKingSideCastle: {}}
-	}
+// ExecuteBestMove plays the best move found by deepening the search to the given depth, or the move of the
+// tablebases
+func (gs *GameState) ExecuteBestMove(depth int8) {
+	gs.ExecuteBestMoveWith(Limits{Depth: depth})
+}
 
-	// check if black can castle on queen side
-	if gs.blackQueenSide() {
-		gs.allMoves[Black][18][16] = map[MoveType]struct{}{BlackQueenSideCastle: {}}
+// ExecuteBestMoveWith plays the move Search finds within the limits, which may come from their opening book
+func (gs *GameState) ExecuteBestMoveWith(limits Limits) {
+	line, ok := gs.Search(limits)
+	if !ok {
+		return
 	}
+	gs.executeMove(line.Move.Origin, line.Move.Destination, line.Move.MoveType)
 }
 
-// isDangerous returns true if the given square is being attacked by the given color
-func (gs *GameState) isDangerous(square int8, attacker Color) bool {
-	_, ok := gs.attackingSquares[attacker][square]
-	return ok
-}
+// alphaBeta returns the minimax value of the position, leaving the principal variation that leads to it in pvTable[ply].
+// A player without legal moves is mated, scored by the plies from the root so that quicker mates score higher, or
+// stalemated
+func (gs *GameState) alphaBeta(depth int8, ply int, a, b int) int {
+	gs.nodes++
+	gs.pvLength[ply] = 0
 
-func (gs *GameState) ExecuteRandomMove() bool {
-	// get a random move
-	for origin, moves := range gs.allMoves[gs.currColor] {
-		for destination := range moves {
-			for moveType := range moves[destination] {
-				gs.executeMove(origin, destination, moveType)
-				return true
-			}
+	// once the node budget is spent, the remaining positions are only evaluated statically
+	if ply == maxPly-1 || gs.outOfBudget() {
+		return gs.evaluate()
+	}
+
+	// positions covered by the tablebases are scored by their outcome
+	if ply > 0 {
+		if score, ok := gs.probeDTMSearch(ply); ok {
+			return score
+		}
+		if score, ok := gs.probeSearch(ply); ok {
+			return score
 		}
 	}
 
-	return false
-}
+	// at the horizon, play out the captures before trusting the evaluation
+	if depth == 0 {
+		return gs.quiesce(ply, a, b)
+	}
 
-func (gs *GameState) ExecuteMove(origin, destination int8, moveType MoveType) bool {
-	if _, ok := gs.allMoves[gs.currColor][origin][destination][moveType]; !ok {
-		return false
+	// a position searched before may settle the window, and otherwise its best move is tried first
+	score, ttMove, ok := gs.probeTT(depth, ply, a, b)
+	if ok {
+		gs.pvFromTT(ply, depth)
+		return score
 	}
-	gs.executeMove(origin, destination, moveType)
-	return true
-}
 
-func (gs *GameState) ExecuteBestMove(depth int8) {
-	bestScore := math.Inf(-1)
-	var bestOrigin, bestDestination int8
-	var bestMoveType MoveType
-	for origin, moves := range gs.allMoves[gs.currColor] {
-		for destination := range moves {
-			for moveType := range moves[destination] {
-				gs.executeMove(origin, destination, moveType)
-				score := -gs.alphaBeta(depth, math.Inf(-1), math.Inf(1)) * float64(gs.currColor)
-				gs.Undo()
-				if score > bestScore {
-					bestScore = score
-					bestOrigin = origin
-					bestDestination = destination
-					bestMoveType = moveType
-				}
+	var moves MoveList
+	gs.generateMoves(&moves)
+	gs.orderMoves(&moves)
+	moves.moveToFront(ttMove)
+
+	color := gs.currColor
+	alpha, beta := a, b
+	value := -infinity * int(color)
+	var best Move
+	legal := 0
+	for _, move := range moves.Moves() {
+		gs.executeMove(move.Origin, move.Destination, move.MoveType)
+		if gs.inCheck(color) {
+			gs.Undo()
+			continue
+		}
+		legal++
+		score := gs.alphaBeta(depth-1, ply+1, a, b)
+		gs.Undo()
+
+		if color == White {
+			if score > value {
+				value, best = score, move
+				gs.updatePV(ply, move)
 			}
+			if value >= b {
+				gs.updateHistory(move, depth)
+				break
+			}
+			a = max(a, value)
+		} else {
+			if score < value {
+				value, best = score, move
+				gs.updatePV(ply, move)
+			}
+			if value <= a {
+				gs.updateHistory(move, depth)
+				break
+			}
+			b = min(b, value)
 		}
 	}
-	gs.executeMove(bestOrigin, bestDestination, bestMoveType)
-}
 
-func (gs *GameState) alphaBeta(depth int8, a, b float64) float64 {
-	if depth == 0 {
-		return gs.score[White] - gs.score[Black]
+	if legal == 0 {
+		if gs.inCheck(color) {
+			return -(MateScore - ply) * int(color)
+		}
+		return 0
 	}
 
-	if gs.currColor == White {
-		value := math.Inf(-1)
-		for origin, moves := range gs.allMoves[gs.currColor] {
-			for destination := range moves {
-				for moveType := range moves[destination] {
-					gs.executeMove(origin, destination, moveType)
-					value = math.Max(value, gs.alphaBeta(depth-1, a, b))
-					gs.Undo()
-					if value > b {
-						return value
-					}
-					a = math.Max(a, value)
-				}
-			}
+	// a search cut short is not kept
+	if !gs.outOfBudget() {
+		bound := exactBound
+		switch {
+		case value >= beta:
+			bound = lowerBound
+		case value <= alpha:
+			bound = upperBound
 		}
-		return value
-	} else {
-		value := math.Inf(1)
-		for origin, moves := range gs.allMoves[gs.currColor] {
-			for destination := range moves {
-				for moveType := range moves[destination] {
-					gs.executeMove(origin, destination, moveType)
-					value = math.Min(value, gs.alphaBeta(depth-1, a, b))
-					gs.Undo()
-					if value < a {
-						return value
-					}
-					b = math.Min(b, value)
-				}
-			}
-		}
-		return value
+		gs.storeTT(depth, ply, value, bound, best)
 	}
+	return value
+}
+
+// outOfBudget returns true once the search has spent its node budget, run out of time or been stopped. These are
+// only checked every 1024 nodes
+func (gs *GameState) outOfBudget() bool {
+	if gs.maxNodes > 0 && gs.nodes >= gs.maxNodes {
+		return true
+	}
+	if gs.nodes&1023 == 0 && (signaled(gs.stopSignal) || !gs.deadline.IsZero() && time.Now().After(gs.deadline)) {
+		gs.stopped = true
+	}
+	return gs.stopped
+}
+
+// signaled returns true if the channel is closed, and false if it is nil
+func signaled(c <-chan struct{}) bool {
+	select {
+	case <-c:
+		return true
+	default:
+		return false
+	}
+}
+
+// updatePV makes the move followed by the principal variation of the next ply the principal variation of the ply
+func (gs *GameState) updatePV(ply int, move Move) {
+	gs.pvTable[ply][0] = move
+	copy(gs.pvTable[ply][1:], gs.pvTable[ply+1][:gs.pvLength[ply+1]])
+	gs.pvLength[ply] = gs.pvLength[ply+1] + 1
 }
@@ -0,0 +1,48 @@
+package game
+
+import "testing"
+
+func TestPieceAtHasMoved(t *testing.T) {
+	tests := []struct {
+		name   string
+		fen    string
+		moves  []string
+		square string
+		want   bool
+	}{
+		{"starting position", StartFEN, nil, "e2", false},
+		{"pushed pawn", StartFEN, []string{"e2e4"}, "e4", true},
+		{"knight moved back", StartFEN, []string{"g1f3", "g8f6", "f3g1"}, "g1", true},
+		{"untouched king", StartFEN, []string{"e2e4", "e7e5"}, "e1", false},
+		{"castled king", "r3k2r/8/8/8/8/8/8/R3K2R w KQkq - 0 1", []string{"e1g1"}, "g1", true},
+		{"castled rook", "r3k2r/8/8/8/8/8/8/R3K2R w KQkq - 0 1", []string{"e1g1"}, "f1", true},
+		{"rook on the other side", "r3k2r/8/8/8/8/8/8/R3K2R w KQkq - 0 1", []string{"e1g1"}, "a1", false},
+		{"rook without castling rights", "r3k2r/8/8/8/8/8/8/R3K2R w Kkq - 0 1", nil, "a1", true},
+		{"king without castling rights", "r3k2r/8/8/8/8/8/8/R3K2R w kq - 0 1", nil, "e1", true},
+		{"king with one right", "r3k2r/8/8/8/8/8/8/R3K2R w Qkq - 0 1", nil, "e1", false},
+		{"rights lost during the game", "r3k2r/8/8/8/8/8/8/R3K2R w KQkq - 0 1", []string{"a1a2", "e8e7"}, "e1", false},
+		{"set up away from home", "4k3/8/8/8/4P3/8/8/4K3 w - - 0 1", nil, "e4", true},
+		{"black pawn at home", "4k3/4p3/8/8/8/8/8/4K3 b - - 0 1", nil, "e7", false},
+		{"promoted piece", "4k3/P7/8/8/8/8/8/4K3 w - - 0 1", []string{"a7a8q"}, "a8", true},
+		{"capture after en passant", StartFEN, []string{"e2e4", "a7a6", "e4e5", "d7d5", "e5d6"}, "d6", true},
+	}
+
+	for _, tt := range tests {
+		gs := mustFEN(t, tt.fen)
+		for _, s := range tt.moves {
+			move, err := gs.ParseMove(s)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := gs.Play(move); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		sq, _ := parseSquare(tt.square)
+		piece, ok := gs.PieceAt(sq)
+		if !ok || piece.HasMoved != tt.want {
+			t.Errorf("%s: PieceAt(%s) = %+v, %v, want HasMoved %v", tt.name, tt.square, piece, ok, tt.want)
+		}
+	}
+}
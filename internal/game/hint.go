@@ -0,0 +1,24 @@
+package game
+
+import "math"
+
+// HintDepth is the depth of the short search ranking the moves of a hint
+const HintDepth = 2
+
+// Hint is a legal move ranked by a short search, with its move in SAN and how far it falls short of the best one
+type Hint struct {
+	Line
+	SAN  string
+	Loss int // centipawns below the score of the best move, 0 for the best moves
+}
+
+// Hints scores every legal move of the current player with a search of the given depth, and returns them from
+// best to worst. Scores are from the perspective of the current player
+func (gs *GameState) Hints(depth int8) []Hint {
+	lines := gs.MultiPV(depth, math.MaxInt)
+	hints := make([]Hint, len(lines))
+	for i, line := range lines {
+		hints[i] = Hint{Line: line, SAN: gs.SAN(line.Move), Loss: lines[0].Score - line.Score}
+	}
+	return hints
+}
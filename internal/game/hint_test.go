@@ -0,0 +1,45 @@
+package game
+
+import "testing"
+
+func TestHints(t *testing.T) {
+	tests := []struct {
+		name string
+		fen  string
+		best string // SAN of the best move
+	}{
+		{"mate in one", "6k1/5ppp/8/8/8/8/8/R5K1 w - - 0 1", "Ra8#"},
+		{"free queen", "4k3/8/8/4q3/8/8/8/4RK2 w - - 0 1", "Rxe5+"},
+		{"black trades rooks", "4k3/8/8/8/8/8/8/r2RK3 b - - 0 1", "Rxd1+"},
+		{"only move", "7k/8/8/8/8/8/6q1/7K w - - 0 1", "Kxg2"},
+		{"checkmated", "rnb1kbnr/pppp1ppp/8/4p3/6Pq/5P2/PPPPP2P/RNBQKBNR w KQkq - 1 3", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gs := mustFEN(t, tt.fen)
+			hints := gs.Hints(HintDepth)
+			if want := len(gs.LegalMoves()); len(hints) != want {
+				t.Fatalf("%d hints, want one for each of the %d legal moves", len(hints), want)
+			}
+			if len(hints) == 0 {
+				return
+			}
+
+			if hints[0].SAN != tt.best {
+				t.Errorf("best hint %s, want %s", hints[0].SAN, tt.best)
+			}
+			for i, hint := range hints {
+				if hint.Loss != hints[0].Score-hint.Score || hint.Loss < 0 {
+					t.Errorf("%s: loss %d with score %d below the best %d", hint.SAN, hint.Loss, hint.Score, hints[0].Score)
+				}
+				if i > 0 && hint.Score > hints[i-1].Score {
+					t.Errorf("%s ranked after %s with a better score", hint.SAN, hints[i-1].SAN)
+				}
+				if hint.SAN != gs.SAN(hint.Move) || hint.PV[0] != hint.Move {
+					t.Errorf("hint %s does not match its move %v and line %v", hint.SAN, hint.Move, hint.PV)
+				}
+			}
+		})
+	}
+}
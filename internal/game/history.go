@@ -3,19 +3,28 @@ package game
 type Action struct {
 	From          int8
 	To            int8
-	hasMoved      bool
 	capture       *Piece
 	promotionPawn *Piece
 	enPassantPawn *Piece
 }
 
 type HistoryEntry struct {
-	Actions         []Action
+	Actions         [2]Action
+	actionCount     int
 	enPassantSquare int8
-	whiteKingSquare int8
-	blackKingSquare int8
-	blackScore      float64
-	whiteScore      float64
+	castling        uint8
+	halfMoveClock   int
+	hash            uint64
+	moveType        MoveType
+}
+
+// Moves returns the moves played in the game so far
+func (gs *GameState) Moves() []Move {
+	moves := make([]Move, len(gs.history))
+	for i, entry := range gs.history {
+		moves[i] = Move{Origin: entry.Actions[0].From, Destination: entry.Actions[0].To, MoveType: entry.moveType}
+	}
+	return moves
 }
 
 // Undo the latest move
@@ -26,32 +35,34 @@ func (gs *GameState) Undo() bool {
 	}
 
 	// pop the last entry from the history
-	var entry *HistoryEntry
+	var entry HistoryEntry
 	entry, gs.history = gs.history[len(gs.history)-1], gs.history[:len(gs.history)-1]
 
 	// undo the actions
-	for _, change := range entry.Actions {
+	for _, change := range entry.Actions[:entry.actionCount] {
 		if change.promotionPawn != nil {
-			gs.board[change.To] = change.promotionPawn
+			gs.removePiece(change.To)
+			gs.putPiece(change.To, change.promotionPawn)
 		} else if change.enPassantPawn != nil {
-			gs.board[change.From] = change.enPassantPawn
+			gs.putPiece(change.From, change.enPassantPawn)
 			continue
 		}
-		gs.board[change.From] = gs.board[change.To]
-		gs.board[change.To] = change.capture
-		gs.board[change.From].HasMoved = change.hasMoved
+		gs.movePiece(change.To, change.From)
+		if change.capture != nil {
+			gs.putPiece(change.To, change.capture)
+		}
 	}
 
 	// update the state variables
 	gs.enPassantSquare = entry.enPassantSquare
-	gs.kingSquares[White] = entry.whiteKingSquare
-	gs.kingSquares[Black] = entry.blackKingSquare
-	gs.score[White] = entry.whiteScore
-	gs.score[Black] = entry.blackScore
+	gs.castling = entry.castling
+	gs.hash = entry.hash
+	gs.halfMoveClock = entry.halfMoveClock
+	if gs.currColor == White {
+		gs.fullMoveNumber--
+	}
 
 	gs.currColor *= -1
 
-	gs.update()
-
 	return true
 }
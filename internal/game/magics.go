@@ -0,0 +1,43 @@
+package game
+
+// Magic multipliers of the sliders by square, a1 first. Each maps every occupancy of the relevant squares of its
+// slider to an index of the attack table without destructive collisions. They were found once by a random search
+// over sparse numbers, so that initialization only fills in the attack tables
+
+var rookMagicNumbers = [64]uint64{
+	0x1080004008801020, 0x0840092002c03000, 0x1900200010400900, 0x0880100008000480,
+	0x4200100420080200, 0x8100020100080400, 0x0200040110886200, 0x0200008040220411,
+	0x0404800084400220, 0x0000401000402000, 0x0086001081220440, 0x0408800800100280,
+	0x000a001201040820, 0x8848800200840080, 0x4001000100040200, 0x0442000102105084,
+	0x9080010020804100, 0x0040404000201009, 0x0000808010002009, 0x2200090021d00100,
+	0x0008008008040080, 0x0004004002010040, 0x0011040008015042, 0x00000a0001768104,
+	0x0000800080204009, 0x2010004140002001, 0x9800200280100080, 0x1000100080080080,
+	0x0050500500080100, 0x0000020080040080, 0x0c10010400420810, 0x1040008200005104,
+	0x01808240088004a0, 0x0882804004802000, 0x0880402001001100, 0x2000210409001000,
+	0x2000480131001500, 0x0000800400800200, 0x000002380c001003, 0x4600084882000431,
+	0x0080002000504000, 0x0300500020004002, 0x0040408200220011, 0x0010040008004040,
+	0x0000080004008080, 0x0010040002008080, 0x2012004881020004, 0x8300842444820011,
+	0x0088403882010200, 0x0820400080210100, 0x0110910040a00300, 0x0801100280080480,
+	0x0242009008200600, 0x1002000489500200, 0x0040800200010080, 0x0091800041000080,
+	0x0000209300488001, 0x04c1002414824001, 0x020020000b001041, 0x7000100004200901,
+	0x8002002004100802, 0x30010002084c0007, 0x0888221800813004, 0x4000002840840112,
+}
+
+var bishopMagicNumbers = [64]uint64{
+	0x20c0090901061081, 0x0024040094030104, 0x8210810200290200, 0x0011040484620000,
+	0x0081104002221000, 0x0009012011001350, 0x0081010802400380, 0x0000420210010408,
+	0x0008105002280050, 0x0001028484040044, 0x2a00880810408804, 0x7020022282000100,
+	0x0084040420100a50, 0x000401010840e000, 0x2020020210420888, 0x0008084202012010,
+	0x2010400810018800, 0x0445122008020840, 0x0804100808002008, 0x0008002104110100,
+	0x0061005820080800, 0x2001000200820100, 0x480c210084010800, 0x3004442500480420,
+	0x1010102240048100, 0x00182009084220a3, 0x8803090a10004205, 0x0208080040202020,
+	0x000c044084010040, 0x00a1010002004106, 0x6008210020640202, 0x1600902112860801,
+	0x00042008c1220200, 0x010c042002440140, 0x5022080200040820, 0x0402004042940100,
+	0x0860108400008020, 0x000c080022021000, 0x0264080652822100, 0x4005031221010401,
+	0x0004502410008400, 0x000500b010a20400, 0x0415094050080800, 0x080000201800a104,
+	0x4022a80304000110, 0x4012140802028020, 0x40200104010100a0, 0x12810806008b0c41,
+	0x0020441008080000, 0x2002120084045420, 0x0704020062080002, 0x0000001084040001,
+	0x0322200891240200, 0xf040200210024800, 0x0140824832008042, 0x000210020a004602,
+	0x0083042805141020, 0x002c12009a011000, 0x0041a00044140400, 0x00004004020a0202,
+	0x0000140010020210, 0x2864160811012200, 0x2060080841082a17, 0xa010041108003100,
+}
@@ -0,0 +1,103 @@
+package game
+
+import "sort"
+
+// FindMate searches for a forced mate in at most n moves for the current player.
+// If one exists it returns the shortest mating line, where the defender always plays the longest resistance.
+// Otherwise it returns false, proving that no mate in n exists
+func (gs *GameState) FindMate(n int) ([]Move, bool) {
+	return gs.shortestMate(n)
+}
+
+// shortestMate returns the shortest forced mate within n moves for the current player
+func (gs *GameState) shortestMate(n int) ([]Move, bool) {
+	for i := 1; i <= n; i++ {
+		if line, ok := gs.mateIn(i); ok {
+			return line, true
+		}
+	}
+	return nil, false
+}
+
+// mateIn returns a line proving a forced mate within n moves for the current player
+func (gs *GameState) mateIn(n int) ([]Move, bool) {
+	var moves MoveList
+	gs.legalMoves(&moves)
+
+	for _, move := range gs.orderChecksFirst(moves.Moves()) {
+		// on the last move only checks can mate
+		if n == 1 && !move.check {
+			break
+		}
+
+		gs.executeMove(move.Origin, move.Destination, move.MoveType)
+		line, ok := gs.refuteAll(n)
+		gs.Undo()
+
+		if ok {
+			return append([]Move{move.Move}, line...), true
+		}
+	}
+
+	return nil, false
+}
+
+// refuteAll returns true if every defence of the player to move loses to a mate within the remaining n-1 moves.
+// The returned line follows the defence that survives the longest
+func (gs *GameState) refuteAll(n int) ([]Move, bool) {
+	var defences MoveList
+	gs.legalMoves(&defences)
+
+	// no defences left: checkmate, or stalemate if the defender is not in check
+	if defences.Len() == 0 {
+		return nil, gs.inCheck(gs.currColor)
+	}
+
+	if n == 1 {
+		return nil, false
+	}
+
+	var longest []Move
+	for _, defence := range defences.Moves() {
+		gs.executeMove(defence.Origin, defence.Destination, defence.MoveType)
+		line, ok := gs.shortestMate(n - 1)
+		gs.Undo()
+
+		if !ok {
+			return nil, false
+		}
+
+		// the defender prefers the defence that delays mate the longest
+		if longest == nil || len(line)+1 > len(longest) {
+			longest = append([]Move{defence}, line...)
+		}
+	}
+
+	return longest, true
+}
+
+type orderedMove struct {
+	Move
+	check   bool
+	capture bool
+}
+
+// orderChecksFirst orders the moves so that checks are tried first, then captures, then quiet moves
+func (gs *GameState) orderChecksFirst(moves []Move) []orderedMove {
+	ordered := make([]orderedMove, len(moves))
+	for i, move := range moves {
+		ordered[i] = orderedMove{Move: move, capture: gs.board[move.Destination] != nil || move.MoveType == EnPassantAttack}
+		gs.executeMove(move.Origin, move.Destination, move.MoveType)
+		ordered[i].check = gs.inCheck(gs.currColor)
+		gs.Undo()
+	}
+
+	sort.SliceStable(ordered, func(i, j int) bool {
+		if ordered[i].check != ordered[j].check {
+			return ordered[i].check
+		}
+		return ordered[i].capture && !ordered[j].capture
+	})
+
+	return ordered
+}
@@ -0,0 +1,68 @@
+package game
+
+import "testing"
+
+func TestFindMate(t *testing.T) {
+	tests := []struct {
+		name  string
+		fen   string
+		moves int    // length of the shortest mate, in moves of the winner
+		first string // first move of the mate, when it is the only one
+	}{
+		{"back rank", "6k1/5ppp/8/8/8/8/8/R5K1 w - - 0 1", 1, "a1a8"},
+		{"scholar's mate", "r1bqkb1r/pppp1ppp/2n2n2/4p2Q/2B1P3/8/PPPP1PPP/RNB1K1NR w KQkq - 4 4", 1, "h5f7"},
+		{"black mates", "r5k1/8/8/8/8/8/5PPP/6K1 b - - 0 1", 1, "a8a1"},
+		{"rook roller", "7k/8/8/8/8/8/R7/1R4K1 w - - 0 1", 2, ""},
+		{"knight check then bishop", "r2qkb1r/pp2nppp/3p4/2pNN1B1/2BnP3/3P4/PPP2PPP/R2bK2R w KQkq - 1 1", 2, "d5f6"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gs := mustFEN(t, tt.fen)
+			if tt.moves > 1 {
+				if _, ok := gs.FindMate(tt.moves - 1); ok {
+					t.Errorf("FindMate(%d) found a mate shorter than %d moves", tt.moves-1, tt.moves)
+				}
+			}
+
+			line, ok := gs.FindMate(tt.moves)
+			if !ok {
+				t.Fatalf("FindMate(%d) found no mate", tt.moves)
+			}
+			if len(line) != 2*tt.moves-1 {
+				t.Errorf("FindMate(%d) = %v, want %d plies", tt.moves, line, 2*tt.moves-1)
+			}
+			if tt.first != "" && line[0].String() != tt.first {
+				t.Errorf("FindMate(%d) starts with %v, want %s", tt.moves, line[0], tt.first)
+			}
+
+			// the line must be legal and end in checkmate
+			for _, move := range line {
+				if err := gs.Play(move); err != nil {
+					t.Fatalf("%v: %v", line, err)
+				}
+			}
+			if _, reason := gs.Result(); reason != "checkmate" {
+				t.Errorf("%v ends in %q, want checkmate", line, reason)
+			}
+		})
+	}
+}
+
+func TestFindMateNone(t *testing.T) {
+	tests := []struct {
+		name  string
+		fen   string
+		moves int
+	}{
+		{"start position", StartFEN, 2},
+		{"bare kings", "8/8/8/4k3/8/8/8/4K3 w - - 0 1", 3},
+		{"stalemate", "7k/5Q2/6K1/8/8/8/8/8 b - - 0 1", 1},
+	}
+
+	for _, tt := range tests {
+		if line, ok := mustFEN(t, tt.fen).FindMate(tt.moves); ok {
+			t.Errorf("%s: FindMate(%d) = %v, want no mate", tt.name, tt.moves, line)
+		}
+	}
+}
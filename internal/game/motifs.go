@@ -0,0 +1,260 @@
+package game
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Motif is a kind of tactical pattern found in a position
+type Motif int
+
+const (
+	// Hanging is a piece attacked by the opponent that none of its own pieces defends
+	Hanging Motif = iota
+
+	// AbsolutePin is a piece that cannot leave the line between an enemy slider and its own king
+	AbsolutePin
+
+	// RelativePin is a piece that would expose a more valuable piece of its own behind it to an enemy slider
+	RelativePin
+
+	// Fork is a piece attacking two or more enemy pieces worth attacking: the king, pieces more valuable than the
+	// attacker, or undefended ones
+	Fork
+
+	// Skewer is a slider attacking a piece that has to move away and uncover a piece worth attacking behind it
+	Skewer
+
+	// DiscoveredAttack is a piece standing between a slider of its own and an enemy piece worth attacking, which
+	// the slider attacks as soon as it moves away
+	DiscoveredAttack
+
+	// Overloaded is a piece that alone defends two or more attacked pieces of its own, and cannot keep them all
+	Overloaded
+)
+
+var motifNames = [...]string{"hanging piece", "absolute pin", "relative pin", "fork", "skewer", "discovered attack",
+	"overloaded defender"}
+
+func (m Motif) String() string {
+	return motifNames[m]
+}
+
+// Finding is a tactical motif in the position. Squares are those of the board, like the squares of moves
+type Finding struct {
+	Motif Motif
+
+	// Color is the player who can make use of the motif, the one attacking
+	Color Color
+
+	// Square holds the piece the motif is about: the hanging piece, the pinning, forking or skewering piece, the
+	// slider of a discovered attack, or the overloaded defender
+	Square int8
+
+	// Targets hold the pieces it bears on: the attackers of a hanging piece, the pieces attacked by a fork, the two
+	// pieces on the line of a pin, skewer or discovered attack nearest first, or the pieces only the overloaded
+	// defender defends
+	Targets []int8
+}
+
+func (f Finding) String() string {
+	targets := make([]string, len(f.Targets))
+	for i, sq := range f.Targets {
+		targets[i] = SquareName(sq)
+	}
+	return fmt.Sprintf("%v for %v: %s on %s", f.Motif, f.Color, SquareName(f.Square), strings.Join(targets, " "))
+}
+
+// lineFinding returns the finding of a motif along the line of a slider, on squares of the bitboards
+func lineFinding(motif Motif, color Color, slider, front, behind int8) Finding {
+	return Finding{Motif: motif, Color: color, Square: to120[slider], Targets: []int8{to120[front], to120[behind]}}
+}
+
+// Motifs returns the tactical motifs of the position for both players, white's first
+func (gs *GameState) Motifs() []Finding {
+	var findings []Finding
+	for _, color := range []Color{White, Black} {
+		findings = append(findings, gs.HangingPieces(color)...)
+		findings = append(findings, gs.Pins(color)...)
+		findings = append(findings, gs.Forks(color)...)
+		findings = append(findings, gs.Skewers(color)...)
+		findings = append(findings, gs.DiscoveredAttacks(color)...)
+		findings = append(findings, gs.OverloadedPieces(color)...)
+	}
+	return findings
+}
+
+// HangingPieces returns the pieces of the opponent of the color that the color attacks and nothing defends
+func (gs *GameState) HangingPieces(color Color) []Finding {
+	var findings []Finding
+	occupied := gs.colors[0] | gs.colors[1]
+	for b := gs.colors[(-color).index()] &^ gs.pieces[(-color).index()][King]; b != 0; {
+		sq := b.popLsb()
+		attackers := gs.attackersTo(sq, color, occupied)
+		if attackers != 0 && gs.attackersTo(sq, -color, occupied) == 0 {
+			findings = append(findings, Finding{Motif: Hanging, Color: color, Square: to120[sq], Targets: squares(attackers)})
+		}
+	}
+	return findings
+}
+
+// Pins returns the pieces of the opponent of the color pinned by its sliders, absolutely to their king or
+// relatively to a more valuable piece
+func (gs *GameState) Pins(color Color) []Finding {
+	var findings []Finding
+	gs.eachLine(color, func(slider, front, behind int8) {
+		if gs.colorAt(front) != -color || gs.colorAt(behind) != -color || gs.typeAt(front) == King {
+			return
+		}
+		switch {
+		case gs.typeAt(behind) == King:
+			findings = append(findings, lineFinding(AbsolutePin, color, slider, front, behind))
+		case seeValues[gs.typeAt(behind)] > seeValues[gs.typeAt(front)]:
+			findings = append(findings, lineFinding(RelativePin, color, slider, front, behind))
+		}
+	})
+	return findings
+}
+
+// Forks returns the pieces of the color attacking two or more pieces of the opponent worth attacking
+func (gs *GameState) Forks(color Color) []Finding {
+	var findings []Finding
+	occupied := gs.colors[0] | gs.colors[1]
+	for pieceType := King; pieceType <= Pawn; pieceType++ {
+		for b := gs.pieces[color.index()][pieceType]; b != 0; {
+			sq := b.popLsb()
+			var attacks Bitboard
+			if pieceType == Pawn {
+				attacks = pawnAttacks[color.index()][sq]
+			} else {
+				attacks = attacksFrom(pieceType, sq, occupied)
+			}
+
+			var targets []int8
+			for t := attacks & gs.colors[(-color).index()]; t != 0; {
+				target := t.popLsb()
+				if gs.worthAttacking(target, sq) {
+					targets = append(targets, to120[target])
+				}
+			}
+			if len(targets) >= 2 {
+				findings = append(findings, Finding{Motif: Fork, Color: color, Square: to120[sq], Targets: targets})
+			}
+		}
+	}
+	return findings
+}
+
+// Skewers returns the sliders of the color attacking a piece of the opponent worth more than the one behind it,
+// itself worth attacking
+func (gs *GameState) Skewers(color Color) []Finding {
+	var findings []Finding
+	gs.eachLine(color, func(slider, front, behind int8) {
+		if gs.colorAt(front) != -color || gs.colorAt(behind) != -color {
+			return
+		}
+		if seeValues[gs.typeAt(front)] > seeValues[gs.typeAt(behind)] && gs.worthAttacking(behind, slider) {
+			findings = append(findings, lineFinding(Skewer, color, slider, front, behind))
+		}
+	})
+	return findings
+}
+
+// DiscoveredAttacks returns the sliders of the color that a piece of its own keeps from attacking a piece of the
+// opponent worth attacking
+func (gs *GameState) DiscoveredAttacks(color Color) []Finding {
+	var findings []Finding
+	gs.eachLine(color, func(slider, front, behind int8) {
+		if gs.colorAt(front) == color && gs.colorAt(behind) == -color && gs.worthAttacking(behind, slider) {
+			findings = append(findings, lineFinding(DiscoveredAttack, color, slider, front, behind))
+		}
+	})
+	return findings
+}
+
+// OverloadedPieces returns the pieces of the opponent of the color that are the only defender of two or more
+// pieces the color attacks
+func (gs *GameState) OverloadedPieces(color Color) []Finding {
+	var findings []Finding
+	occupied := gs.colors[0] | gs.colors[1]
+	duties := map[int8][]int8{}
+	for b := gs.colors[(-color).index()] &^ gs.pieces[(-color).index()][King]; b != 0; {
+		sq := b.popLsb()
+		if gs.attackersTo(sq, color, occupied) == 0 {
+			continue
+		}
+		if defenders := gs.attackersTo(sq, -color, occupied); defenders.count() == 1 {
+			duties[defenders.lsb()] = append(duties[defenders.lsb()], to120[sq])
+		}
+	}
+	for b := gs.colors[(-color).index()]; b != 0; {
+		if defender := b.popLsb(); len(duties[defender]) >= 2 {
+			findings = append(findings, Finding{Motif: Overloaded, Color: color, Square: to120[defender], Targets: duties[defender]})
+		}
+	}
+	return findings
+}
+
+// eachLine calls f for every slider of the color with the first two pieces it meets along each of its lines, on
+// squares of the bitboards
+func (gs *GameState) eachLine(color Color, f func(slider, front, behind int8)) {
+	occupied := gs.colors[0] | gs.colors[1]
+	for _, pieceType := range []Type{Queen, Rook, Bishop} {
+		var directions [][2]int8
+		if pieceType != Bishop {
+			directions = append(directions, rookDirections...)
+		}
+		if pieceType != Rook {
+			directions = append(directions, bishopDirections...)
+		}
+
+		for b := gs.pieces[color.index()][pieceType]; b != 0; {
+			slider := b.popLsb()
+			for _, direction := range directions {
+				var met []int8
+				file, rank := slider%8, slider/8
+				for len(met) < 2 {
+					file, rank = file+direction[0], rank+direction[1]
+					if file < 0 || file > 7 || rank < 0 || rank > 7 {
+						break
+					}
+					if sq := rank*8 + file; bit(sq)&occupied != 0 {
+						met = append(met, sq)
+					}
+				}
+				if len(met) == 2 {
+					f(slider, met[0], met[1])
+				}
+			}
+		}
+	}
+}
+
+// worthAttacking returns true if attacking the piece on the target square from the attacker's square threatens to
+// win something: the target is the king, is worth more than the attacker, or is not defended
+func (gs *GameState) worthAttacking(target, attacker int8) bool {
+	targetType := gs.typeAt(target)
+	if targetType == King || seeValues[targetType] > seeValues[gs.typeAt(attacker)] {
+		return true
+	}
+	return gs.attackersTo(target, gs.colorAt(target), gs.colors[0]|gs.colors[1]) == 0
+}
+
+// typeAt returns the type of the piece on the square of the bitboards
+func (gs *GameState) typeAt(sq int8) Type {
+	return gs.board[to120[sq]].Type
+}
+
+// colorAt returns the color of the piece on the square of the bitboards
+func (gs *GameState) colorAt(sq int8) Color {
+	return gs.board[to120[sq]].Color
+}
+
+// squares returns the squares of the set as squares of the board
+func squares(b Bitboard) []int8 {
+	var list []int8
+	for b != 0 {
+		list = append(list, to120[b.popLsb()])
+	}
+	return list
+}
@@ -0,0 +1,73 @@
+package game
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMotifs(t *testing.T) {
+	tests := []struct {
+		name     string
+		fen      string
+		findings []string
+	}{
+		{"hanging piece", "4k3/8/8/3n4/8/8/8/3RK3 w - - 0 1", []string{
+			"hanging piece for White: d5 on d1",
+		}},
+		{"absolute pin", "4k3/4n3/8/8/8/8/8/4RK2 w - - 0 1", []string{
+			"absolute pin for White: e1 on e7 e8",
+		}},
+		{"relative pin", "3qk3/8/8/3n4/8/8/8/3RK3 w - - 0 1", []string{
+			"relative pin for White: d1 on d5 d8",
+		}},
+		{"knight fork of king and rook", "r3k3/2N5/8/8/8/8/8/4K3 b - - 0 1", []string{
+			"hanging piece for White: a8 on c7",
+			"fork for White: c7 on a8 e8",
+		}},
+		// the king in front of its queen is skewered, and uncovers the queen's attack on the rook when it moves
+		{"skewer", "q7/8/8/k7/8/8/8/R6K w - - 0 1", []string{
+			"skewer for White: a1 on a5 a8",
+			"discovered attack for Black: a8 on a5 a1",
+		}},
+		{"discovered check", "4k3/8/8/8/8/8/4N3/4R1K1 w - - 0 1", []string{
+			"discovered attack for White: e1 on e2 e8",
+		}},
+		{"overloaded queen", "3qk3/8/8/n7/7b/8/8/R3K2R w - - 0 1", []string{
+			"overloaded defender for White: d8 on h4 a5",
+		}},
+		{"start position", StartFEN, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var findings []string
+			for _, f := range mustFEN(t, tt.fen).Motifs() {
+				findings = append(findings, f.String())
+			}
+			if !reflect.DeepEqual(findings, tt.findings) {
+				t.Errorf("Motifs() = %q, want %q", findings, tt.findings)
+			}
+		})
+	}
+}
+
+func TestMotifsByColor(t *testing.T) {
+	// white's rook pins the knight to the king while black's bishop forks the rook and the queen
+	gs := mustFEN(t, "4k3/4n3/8/8/8/2b5/8/Q3R1K1 w - - 0 1")
+
+	tests := []struct {
+		name     string
+		findings []Finding
+		want     []Finding
+	}{
+		{"white pins", gs.Pins(White), []Finding{{AbsolutePin, White, 102, []int8{30, 18}}}},
+		{"black pins", gs.Pins(Black), nil},
+		{"black forks", gs.Forks(Black), []Finding{{Fork, Black, 76, []int8{98, 102}}}},
+		{"white forks", gs.Forks(White), nil},
+	}
+	for _, tt := range tests {
+		if !reflect.DeepEqual(tt.findings, tt.want) {
+			t.Errorf("%s: %v, want %v", tt.name, tt.findings, tt.want)
+		}
+	}
+}
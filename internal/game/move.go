@@ -0,0 +1,91 @@
+package game
+
+import "fmt"
+
+// Move is a single move identified by its origin square, destination square and move type
+type Move struct {
+	Origin      int8
+	Destination int8
+	MoveType    MoveType
+}
+
+// String returns the move in coordinate notation, e.g. e2e4 or a7a8q
+func (m Move) String() string {
+	s := SquareName(m.Origin) + SquareName(m.Destination)
+	switch m.MoveType {
+	case QueenPromotion:
+		s += "q"
+	case RookPromotion:
+		s += "r"
+	case BishopPromotion:
+		s += "b"
+	case KnightPromotion:
+		s += "n"
+	}
+	return s
+}
+
+// ParseMove returns the legal move of the current player given in coordinate notation, e.g. e2e4 or a7a8q
+func (gs *GameState) ParseMove(s string) (Move, error) {
+	for _, move := range gs.LegalMoves() {
+		if move.String() == s {
+			return move, nil
+		}
+	}
+	return Move{}, fmt.Errorf("move %q: not a legal move", s)
+}
+
+// Play makes the move if it is legal for the current player
+func (gs *GameState) Play(move Move) error {
+	for _, legal := range gs.LegalMoves() {
+		if legal == move {
+			gs.executeMove(move.Origin, move.Destination, move.MoveType)
+			return nil
+		}
+	}
+	return fmt.Errorf("move %v: not a legal move", move)
+}
+
+// newMove returns the move between two squares given on the 64 square bitboard layout
+func newMove(origin, destination int8, moveType MoveType) Move {
+	return Move{Origin: to120[origin], Destination: to120[destination], MoveType: moveType}
+}
+
+// maxMoves bounds the number of moves generated in any position
+const maxMoves = 256
+
+// MoveList is a fixed capacity list of moves that is filled without allocating
+type MoveList struct {
+	moves [maxMoves]Move
+	count int
+}
+
+// Len returns the number of moves in the list
+func (l *MoveList) Len() int {
+	return l.count
+}
+
+// Moves returns the moves in the list, backed by the list itself
+func (l *MoveList) Moves() []Move {
+	return l.moves[:l.count]
+}
+
+func (l *MoveList) add(move Move) {
+	l.moves[l.count] = move
+	l.count++
+}
+
+// addPromotions adds the four promotions of the pawn move between two squares on the 64 square layout
+func (l *MoveList) addPromotions(origin, destination int8) {
+	for _, moveType := range promotions {
+		l.add(newMove(origin, destination, moveType))
+	}
+}
+
+// SquareName returns the algebraic name of a square on the 120 square board, e.g. 102 is e1, or "-" off the board
+func SquareName(square int8) string {
+	if _, ok := validSquares[square]; !ok {
+		return "-"
+	}
+	return fmt.Sprintf("%c%d", 'a'+square%12-2, 9-square/12)
+}
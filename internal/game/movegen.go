@@ -0,0 +1,130 @@
+package game
+
+var promotions = [...]MoveType{QueenPromotion, RookPromotion, BishopPromotion, KnightPromotion}
+
+// generateMoves fills the list with the moves of the current player, without checking for check
+func (gs *GameState) generateMoves(list *MoveList) {
+	list.count = 0
+
+	us := gs.currColor
+	own := gs.colors[us.index()]
+	enemy := gs.colors[(-us).index()]
+	occupied := own | enemy
+
+	// 1. the pieces move to any square they attack that is not occupied by a friendly piece. The king moves come last
+	// of all, so that among moves ordered alike the search tries them last
+	pieceMoves := func(pieceType Type) {
+		for pieces := gs.pieces[us.index()][pieceType]; pieces != 0; {
+			origin := pieces.popLsb()
+			for targets := attacksFrom(pieceType, origin, occupied) &^ own; targets != 0; {
+				list.add(newMove(origin, targets.popLsb(), Neutral))
+			}
+		}
+	}
+	for pieceType := Queen; pieceType < Pawn; pieceType++ {
+		pieceMoves(pieceType)
+	}
+
+	// 2. the pawns push forward onto empty squares and capture diagonally
+	forward, startRank, promotionRank := int8(8), rank2, rank8
+	if us == Black {
+		forward, startRank, promotionRank = -8, rank7, rank1
+	}
+	enPassant := Bitboard(0)
+	if gs.enPassantSquare != 0 {
+		enPassant = bit(to64[gs.enPassantSquare])
+	}
+
+	for pawns := gs.pieces[us.index()][Pawn]; pawns != 0; {
+		origin := pawns.popLsb()
+
+		// a. single and double pushes
+		if push := origin + forward; bit(push)&occupied == 0 {
+			if bit(push)&promotionRank != 0 {
+				list.addPromotions(origin, push)
+			} else {
+				list.add(newMove(origin, push, Neutral))
+			}
+			if bit(origin)&startRank != 0 && bit(push+forward)&occupied == 0 {
+				list.add(newMove(origin, push+forward, EnPassantPrimer))
+			}
+		}
+
+		// b. captures, including promotions by capture
+		for targets := pawnAttacks[us.index()][origin] & enemy; targets != 0; {
+			target := targets.popLsb()
+			if bit(target)&promotionRank != 0 {
+				list.addPromotions(origin, target)
+			} else {
+				list.add(newMove(origin, target, Neutral))
+			}
+		}
+
+		// c. en passant captures
+		if pawnAttacks[us.index()][origin]&enPassant != 0 {
+			list.add(newMove(origin, enPassant.lsb(), EnPassantAttack))
+		}
+	}
+
+	// 3. castling
+	if us == White {
+		if gs.whiteKingSide() {
+			list.add(Move{Origin: 102, Destination: 104, MoveType: WhiteKingSideCastle})
+		}
+		if gs.whiteQueenSide() {
+			list.add(Move{Origin: 102, Destination: 100, MoveType: WhiteQueenSideCastle})
+		}
+	} else {
+		if gs.blackKingSide() {
+			list.add(Move{Origin: 18, Destination: 20, MoveType: BlackKingSideCastle})
+		}
+		if gs.blackQueenSide() {
+			list.add(Move{Origin: 18, Destination: 16, MoveType: BlackQueenSideCastle})
+		}
+	}
+
+	// 4. the king
+	pieceMoves(King)
+}
+
+// legalMoves fills the list with the moves of the current player that do not leave their own king in check
+func (gs *GameState) legalMoves(list *MoveList) {
+	var pseudo MoveList
+	gs.generateMoves(&pseudo)
+
+	list.count = 0
+	for _, move := range pseudo.Moves() {
+		gs.executeMove(move.Origin, move.Destination, move.MoveType)
+		if !gs.inCheck(-gs.currColor) {
+			list.add(move)
+		}
+		gs.Undo()
+	}
+}
+
+// attacksFrom returns the squares attacked by a piece of the given type, other than a pawn, on the square
+func attacksFrom(pieceType Type, square int8, occupied Bitboard) Bitboard {
+	switch pieceType {
+	case King:
+		return kingAttacks[square]
+	case Queen:
+		return rookAttacks(square, occupied) | bishopAttacks(square, occupied)
+	case Rook:
+		return rookAttacks(square, occupied)
+	case Bishop:
+		return bishopAttacks(square, occupied)
+	case Knight:
+		return knightAttacks[square]
+	}
+	return 0
+}
+
+// attackersTo returns the pieces of the given color that attack the square
+func (gs *GameState) attackersTo(square int8, attacker Color, occupied Bitboard) Bitboard {
+	pieces := &gs.pieces[attacker.index()]
+	return pawnAttacks[(-attacker).index()][square]&pieces[Pawn] |
+		knightAttacks[square]&pieces[Knight] |
+		kingAttacks[square]&pieces[King] |
+		bishopAttacks(square, occupied)&(pieces[Bishop]|pieces[Queen]) |
+		rookAttacks(square, occupied)&(pieces[Rook]|pieces[Queen])
+}
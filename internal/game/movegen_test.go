@@ -0,0 +1,48 @@
+package game
+
+import "testing"
+
+func TestLegalMoves(t *testing.T) {
+	tests := []struct {
+		name     string
+		fen      string
+		count    int
+		includes []string
+	}{
+		{"start position", StartFEN, 20, []string{"e2e4", "g1f3"}},
+		{"kiwipete", "r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1", 48, []string{"e1g1", "e1c1"}},
+		{"rook endgame", "8/2p5/3p4/KP5r/1R3p1k/8/4P1P1/8 w - - 0 1", 14, nil},
+		{"in check", "r3k2r/Pppp1ppp/1b3nbN/nP6/BBP1P3/q4N2/Pp1P2PP/R2Q1RK1 w kq - 0 1", 6, []string{"c4c5", "f1f2"}},
+		{"promotions", "rnbq1k1r/pp1Pbppp/2p5/8/2B5/8/PPP1NnPP/RNBQK2R w KQ - 1 8", 44, []string{"d7c8q", "d7c8n"}},
+		{"en passant", "rnbqkbnr/ppp1p1pp/8/3pPp2/8/8/PPPP1PPP/RNBQKBNR w KQkq f6 0 3", 31, []string{"e5f6", "e5e6"}},
+		{"most moves", "R6R/3Q4/1Q4Q1/4Q3/2Q4Q/Q4Q2/pp1Q4/kBNN1KB1 w - - 0 1", 218, nil},
+		{"checkmate", "rnb1kbnr/pppp1ppp/8/4p3/6Pq/5P2/PPPPP2P/RNBQKBNR w KQkq - 1 3", 0, nil},
+		{"stalemate", "7k/5Q2/6K1/8/8/8/8/8 b - - 0 1", 0, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			moves := mustFEN(t, tt.fen).LegalMoves()
+			if len(moves) != tt.count {
+				t.Errorf("%d legal moves, want %d", len(moves), tt.count)
+			}
+			found := map[string]bool{}
+			for _, move := range moves {
+				found[move.String()] = true
+			}
+			for _, want := range tt.includes {
+				if !found[want] {
+					t.Errorf("%s is not among the legal moves", want)
+				}
+			}
+		})
+	}
+}
+
+func TestLegalMovesDoNotAllocate(t *testing.T) {
+	gs := mustFEN(t, "r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1")
+	var moves MoveList
+	if allocs := testing.AllocsPerRun(100, func() { gs.legalMoves(&moves) }); allocs != 0 {
+		t.Errorf("legalMoves allocates %v times, want none", allocs)
+	}
+}
@@ -0,0 +1,101 @@
+package game
+
+import "strings"
+
+// PawnClass is the set of features of a pawn, which can have several at once
+type PawnClass uint8
+
+const (
+	// Passed is a pawn with no enemy pawn in front of it on its file or the files beside it
+	Passed PawnClass = 1 << iota
+
+	// Isolated is a pawn with no pawn of its side on the files beside it
+	Isolated
+
+	// Doubled is a pawn sharing its file with another pawn of its side
+	Doubled
+
+	// Backward is a pawn left behind by the pawns of its side on the files beside it, which cannot advance safely as
+	// an enemy pawn guards the square in front of it
+	Backward
+
+	// Connected is a pawn with a pawn of its side beside it, or diagonally in front or behind, that defends it or can
+	// advance to
+	Connected
+
+	// Candidate is a pawn that is not passed but can become so: no enemy pawn is in front of it on its file, and the
+	// pawns of its side able to support its advance are at least as many as the enemy pawns guarding its path
+	Candidate
+)
+
+var pawnClassNames = [...]string{"passed", "isolated", "doubled", "backward", "connected", "candidate"}
+
+func (c PawnClass) String() string {
+	var names []string
+	for i, name := range pawnClassNames {
+		if c&(1<<i) != 0 {
+			names = append(names, name)
+		}
+	}
+	return strings.Join(names, ", ")
+}
+
+// PawnInfo is a pawn on the board and its features
+type PawnInfo struct {
+	Square int8 // square of the board, like the squares of moves
+	Class  PawnClass
+}
+
+// ClassifyPawns returns the pawns of the color with their features, from the a-file to the h-file and on a file
+// from the color's side of the board forward
+func (gs *GameState) ClassifyPawns(color Color) []PawnInfo {
+	var pawns []PawnInfo
+	for file := int8(0); file < 8; file++ {
+		var onFile []int8
+		for b := gs.pieces[color.index()][Pawn] & fileMask(file); b != 0; {
+			onFile = append(onFile, b.popLsb())
+		}
+		for i := range onFile {
+			sq := onFile[i]
+			if color == Black {
+				sq = onFile[len(onFile)-1-i]
+			}
+			pawns = append(pawns, PawnInfo{Square: to120[sq], Class: gs.pawnClass(color, sq)})
+		}
+	}
+	return pawns
+}
+
+// pawnClass returns the features of the pawn of the color on the square of the bitboards
+func (gs *GameState) pawnClass(color Color, sq int8) PawnClass {
+	own, enemy := gs.pieces[color.index()][Pawn], gs.pieces[(-color).index()][Pawn]
+	file, rank := sq%8, sq/8
+	ahead := ranksAhead(color, rank)
+	beside := adjacentFiles(file)
+	var class PawnClass
+
+	if enemy&(fileMask(file)|beside)&ahead == 0 {
+		class |= Passed
+	}
+	if own&beside == 0 {
+		class |= Isolated
+	}
+	if (own & fileMask(file)).count() > 1 {
+		class |= Doubled
+	}
+
+	// the pawns beside it that are level with it or behind can still come to support it
+	helpers := own & beside &^ ahead
+	sentries := enemy & beside & ahead
+	stop := sq + 8*int8(color)
+	if own&beside != 0 && helpers == 0 && stop >= 0 && stop < 64 && pawnAttacks[color.index()][stop]&enemy != 0 {
+		class |= Backward
+	}
+	if own&beside&(rankMask(rank-1)|rankMask(rank)|rankMask(rank+1)) != 0 {
+		class |= Connected
+	}
+	if enemy&fileMask(file)&ahead == 0 && sentries != 0 && helpers.count() >= sentries.count() {
+		class |= Candidate
+	}
+	return class
+}
@@ -0,0 +1,82 @@
+package game
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestClassifyPawns(t *testing.T) {
+	tests := []struct {
+		name  string
+		fen   string
+		color Color
+		want  map[string]PawnClass
+	}{
+		{"start position", StartFEN, White, map[string]PawnClass{
+			"a2": Connected, "b2": Connected, "c2": Connected, "d2": Connected,
+			"e2": Connected, "f2": Connected, "g2": Connected, "h2": Connected,
+		}},
+		{"passed and isolated", "4k3/8/8/3pP3/8/8/8/4K3 w - - 0 1", White, map[string]PawnClass{
+			"e5": Passed | Isolated,
+		}},
+		{"doubled, one connected", "8/p1p3pp/1p1k4/3P4/2P2pP1/8/PP4PP/4K3 w - - 0 1", White, map[string]PawnClass{
+			"a2": Connected, "b2": Connected, "c4": Connected, "d5": Connected | Candidate,
+			"g2": Doubled | Connected, "g4": Doubled, "h2": Connected,
+		}},
+		{"backward and candidate", "8/p1p3pp/1p1k4/3P4/2P2pP1/8/PP4PP/4K3 w - - 0 1", Black, map[string]PawnClass{
+			"a7": Connected, "b6": Connected, "c7": Backward | Connected, "f4": Candidate,
+			"g7": Connected, "h7": Connected,
+		}},
+		{"protected passed pawn", "4k3/8/8/3PP3/8/8/8/4K3 w - - 0 1", White, map[string]PawnClass{
+			"d5": Passed | Connected, "e5": Passed | Connected,
+		}},
+		{"tripled", "4k3/8/8/8/4P3/4P3/4P3/4K3 w - - 0 1", White, map[string]PawnClass{
+			"e2": Passed | Isolated | Doubled, "e3": Passed | Isolated | Doubled, "e4": Passed | Isolated | Doubled,
+		}},
+		{"no pawns", "4k3/8/8/8/8/8/8/4K3 w - - 0 1", Black, map[string]PawnClass{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := map[string]PawnClass{}
+			var order []string
+			for _, pawn := range mustFEN(t, tt.fen).ClassifyPawns(tt.color) {
+				got[SquareName(pawn.Square)] = pawn.Class
+				order = append(order, SquareName(pawn.Square))
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ClassifyPawns(%v) = %v, want %v", tt.color, got, tt.want)
+			}
+
+			// pawns come by file, then from the player's side of the board forward
+			for i := 1; i < len(order); i++ {
+				a, b := order[i-1], order[i]
+				forward := a[1] < b[1]
+				if tt.color == Black {
+					forward = a[1] > b[1]
+				}
+				if a[0] > b[0] || a[0] == b[0] && !forward {
+					t.Errorf("pawns in the order %v", order)
+					break
+				}
+			}
+		})
+	}
+}
+
+func TestPawnClassString(t *testing.T) {
+	tests := []struct {
+		class PawnClass
+		want  string
+	}{
+		{0, ""},
+		{Passed, "passed"},
+		{Doubled | Isolated, "isolated, doubled"},
+		{Connected | Candidate | Backward, "backward, connected, candidate"},
+	}
+	for _, tt := range tests {
+		if got := tt.class.String(); got != tt.want {
+			t.Errorf("PawnClass(%d).String() = %q, want %q", tt.class, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package game
+
+import "fmt"
+
+// PerftPosition is a reference position with its known perft node counts
+type PerftPosition struct {
+	Name  string
+	FEN   string
+	Nodes []uint64 // Nodes[i] is the node count at depth i+1
+}
+
+// PerftSuite holds the standard reference positions used to verify the move generator
+var PerftSuite = []PerftPosition{
+	{
+		Name:  "Start",
+		FEN:   StartFEN,
+		Nodes: []uint64{20, 400, 8902, 197281, 4865609},
+	},
+	{
+		Name:  "Kiwipete",
+		FEN:   "r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1",
+		Nodes: []uint64{48, 2039, 97862, 4085603},
+	},
+	{
+		Name:  "En passant pins",
+		FEN:   "8/2p5/3p4/KP5r/1R3p1k/8/4P1P1/8 w - - 0 1",
+		Nodes: []uint64{14, 191, 2812, 43238, 674624},
+	},
+	{
+		Name:  "Promotions and castling",
+		FEN:   "r3k2r/Pppp1ppp/1b3nbN/nP6/BBP1P3/q4N2/Pp1P2PP/R2Q1RK1 w kq - 0 1",
+		Nodes: []uint64{6, 264, 9467, 422333},
+	},
+	{
+		Name:  "Promotion into check",
+		FEN:   "rnbq1k1r/pp1Pbppp/2p5/8/2B5/8/PPP1NnPP/RNBQK2R w KQ - 1 8",
+		Nodes: []uint64{44, 1486, 62379, 2103487},
+	},
+	{
+		Name:  "Middlegame",
+		FEN:   "r4rk1/1pp1qppp/p1np1n2/2b1p1B1/2B1P1b1/P1NP1N2/1PP1QPPP/R4RK1 w - - 0 10",
+		Nodes: []uint64{46, 2079, 89890, 3894594},
+	},
+}
+
+// Perft counts the leaf nodes of the legal move tree to the given depth
+func (gs *GameState) Perft(depth int) uint64 {
+	if depth == 0 {
+		return 1
+	}
+
+	var moves MoveList
+	gs.legalMoves(&moves)
+	if depth == 1 {
+		return uint64(moves.Len())
+	}
+
+	var nodes uint64
+	for _, move := range moves.Moves() {
+		gs.executeMove(move.Origin, move.Destination, move.MoveType)
+		nodes += gs.Perft(depth - 1)
+		gs.Undo()
+	}
+	return nodes
+}
+
+// Divide returns the perft count to the given depth below each legal move of the current player
+func (gs *GameState) Divide(depth int) map[Move]uint64 {
+	divide := map[Move]uint64{}
+	if depth == 0 {
+		return divide
+	}
+
+	var moves MoveList
+	gs.legalMoves(&moves)
+	for _, move := range moves.Moves() {
+		gs.executeMove(move.Origin, move.Destination, move.MoveType)
+		divide[move] = gs.Perft(depth - 1)
+		gs.Undo()
+	}
+	return divide
+}
+
+// Verify checks the perft counts of the position up to the given depth, returning an error on the first mismatch
+func (p PerftPosition) Verify(maxDepth int) error {
+	gs, err := NewGameFromFEN(p.FEN)
+	if err != nil {
+		return err
+	}
+
+	for depth := 1; depth <= maxDepth && depth <= len(p.Nodes); depth++ {
+		if nodes := gs.Perft(depth); nodes != p.Nodes[depth-1] {
+			return fmt.Errorf("%s: perft(%d) = %d, expected %d", p.Name, depth, nodes, p.Nodes[depth-1])
+		}
+	}
+	return nil
+}
@@ -0,0 +1,437 @@
+package game
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// PGNGame is a game read from a PGN file: its tag pairs and the moves of its main line, each with its annotations
+type PGNGame struct {
+	Tags   map[string]string
+	Moves  []Move
+	Result GameResult
+
+	// Annotations of the moves, indexed like them. It may be shorter than Moves, or nil when there are none
+	Annotations []PGNAnnotation
+}
+
+// PGNAnnotation is what a PGN file says about a move besides the move itself
+type PGNAnnotation struct {
+	NAGs    []int  // numeric annotation glyphs, e.g. 2 for a mistake
+	Comment string // the text of the comments following the move
+	// alternatives to the move, each played from the position before it. Variations nested deeper are left out
+	Variations [][]Move
+}
+
+// NAGs written as move suffixes
+const (
+	NAGGood        = 1 // !
+	NAGMistake     = 2 // ?
+	NAGBrilliant   = 3 // !!
+	NAGBlunder     = 4 // ??
+	NAGInteresting = 5 // !?
+	NAGDubious     = 6 // ?!
+)
+
+// pgnSuffixes are the move suffixes standing for the first six NAGs
+var pgnSuffixes = map[string]int{
+	"!": NAGGood, "?": NAGMistake, "!!": NAGBrilliant, "??": NAGBlunder, "!?": NAGInteresting, "?!": NAGDubious,
+}
+
+// annotation returns the annotation of the ply, empty if it has none
+func (g *PGNGame) annotation(ply int) PGNAnnotation {
+	if ply < len(g.Annotations) {
+		return g.Annotations[ply]
+	}
+	return PGNAnnotation{}
+}
+
+// pgnResults are the game termination markers of PGN
+var pgnResults = map[string]GameResult{"1-0": WhiteWon, "0-1": BlackWon, "1/2-1/2": Drawn, "*": Unfinished}
+
+// Start returns the position the game starts from, given by its FEN tag or the standard starting position
+func (g *PGNGame) Start() (*GameState, error) {
+	if fen, ok := g.Tags["FEN"]; ok {
+		return NewGameFromFEN(fen)
+	}
+	return NewGame(), nil
+}
+
+// PGNError is a game of a PGN file that could not be read. The reader carries on with the next game
+type PGNError struct {
+	Game int
+	Err  error
+}
+
+func (e *PGNError) Error() string {
+	return fmt.Sprintf("game %d: %v", e.Game, e.Err)
+}
+
+func (e *PGNError) Unwrap() error {
+	return e.Err
+}
+
+// PGNReader reads the games of a PGN file one at a time
+type PGNReader struct {
+	r      *bufio.Reader
+	number int
+}
+
+// NewPGNReader returns a reader of the games in r
+func NewPGNReader(r io.Reader) *PGNReader {
+	return &PGNReader{r: bufio.NewReader(r)}
+}
+
+// LoadPGN reads all the games of a PGN file
+func LoadPGN(path string) ([]*PGNGame, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var games []*PGNGame
+	pr := NewPGNReader(file)
+	for {
+		g, err := pr.Next()
+		if err == io.EOF {
+			return games, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		games = append(games, g)
+	}
+}
+
+// pgnMove is a move of the main line as written, with its annotations and the moves of its variations
+type pgnMove struct {
+	san        string
+	annotation PGNAnnotation
+	variations [][]string
+}
+
+// Next returns the next game, or io.EOF once there are none left. Variations that cannot be replayed are left out
+func (pr *PGNReader) Next() (*PGNGame, error) {
+	g := &PGNGame{Tags: map[string]string{}}
+	var moves []pgnMove
+	var variation []string
+	depth := 0
+	started := false
+
+	// last returns the move the annotations being read belong to, nil before the first move
+	last := func() *pgnMove {
+		if len(moves) == 0 || depth > 0 {
+			return nil
+		}
+		return &moves[len(moves)-1]
+	}
+
+	// 1. the tags and the tokens of the movetext, up to the result
+	for done := false; !done; {
+		c, _, err := pr.r.ReadRune()
+		if err == io.EOF {
+			if !started {
+				return nil, io.EOF
+			}
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if unicode.IsSpace(c) {
+			continue
+		}
+		if !started {
+			pr.number++
+			started = true
+		}
+
+		switch c {
+		case '[':
+			// a tag after the moves starts the next game, whose result was left out
+			if len(moves) > 0 {
+				pr.r.UnreadRune()
+				done = true
+				break
+			}
+			line, err := pr.r.ReadString(']')
+			if err != nil && err != io.EOF {
+				return nil, err
+			}
+			name, value, _ := strings.Cut(strings.TrimSuffix(line, "]"), " ")
+			value = strings.TrimSpace(value)
+			value = strings.TrimSuffix(strings.TrimPrefix(value, "\""), "\"")
+			g.Tags[name] = strings.NewReplacer(`\"`, `"`, `\\`, `\`).Replace(value)
+		case '{':
+			comment, err := pr.r.ReadString('}')
+			if err != nil && err != io.EOF {
+				return nil, err
+			}
+			if move := last(); move != nil {
+				comment = strings.Join(strings.Fields(strings.TrimSuffix(comment, "}")), " ")
+				move.annotation.Comment = strings.TrimSpace(move.annotation.Comment + " " + comment)
+			}
+		case ';', '%':
+			if _, err := pr.r.ReadString('\n'); err != nil && err != io.EOF {
+				return nil, err
+			}
+		case '(':
+			depth++
+			if depth == 1 {
+				variation = nil
+			}
+		case ')':
+			depth--
+			if depth == 0 && len(moves) > 0 {
+				moves[len(moves)-1].variations = append(moves[len(moves)-1].variations, variation)
+			}
+		case ']', '}':
+			// stray closing brackets are skipped
+		default:
+			pr.r.UnreadRune()
+			token := pr.token()
+			if result, ok := pgnResults[token]; ok && depth == 0 {
+				g.Result = result
+				done = true
+				break
+			}
+			if strings.HasPrefix(token, "$") || pgnSuffixes[token] != 0 {
+				nag, ok := pgnSuffixes[token]
+				if !ok {
+					nag, _ = strconv.Atoi(token[1:])
+				}
+				if move := last(); move != nil && nag > 0 {
+					move.annotation.NAGs = append(move.annotation.NAGs, nag)
+				}
+				continue
+			}
+			if depth > 1 {
+				continue
+			}
+			// move numbers may be written apart from or against the move, and castling with zeros
+			if i := strings.IndexFunc(token, func(r rune) bool { return r < '0' || r > '9' }); i > 0 && token[i] == '.' {
+				token = token[i:]
+			}
+			token = strings.TrimLeft(token, ".")
+			if token == "" {
+				continue
+			}
+			san := strings.TrimRight(token, "!?")
+			if depth == 1 {
+				variation = append(variation, san)
+				continue
+			}
+			move := pgnMove{san: san}
+			if nag := pgnSuffixes[token[len(san):]]; nag != 0 {
+				move.annotation.NAGs = append(move.annotation.NAGs, nag)
+			}
+			moves = append(moves, move)
+		}
+	}
+
+	// 2. the moves, replayed from the starting position
+	gs, err := g.Start()
+	if err != nil {
+		return nil, &PGNError{Game: pr.number, Err: err}
+	}
+	annotated := false
+	for i, m := range moves {
+		move, err := gs.ParseSAN(m.san)
+		if err != nil {
+			return nil, &PGNError{Game: pr.number, Err: fmt.Errorf("ply %d: %w", i+1, err)}
+		}
+		for _, sans := range m.variations {
+			if line, ok := gs.replaySAN(sans); ok {
+				m.annotation.Variations = append(m.annotation.Variations, line)
+			}
+		}
+		gs.executeMove(move.Origin, move.Destination, move.MoveType)
+		g.Moves = append(g.Moves, move)
+		g.Annotations = append(g.Annotations, m.annotation)
+		annotated = annotated || len(m.annotation.NAGs) > 0 || m.annotation.Comment != "" || len(m.annotation.Variations) > 0
+	}
+	if !annotated {
+		g.Annotations = nil
+	}
+	if g.Result == Unfinished {
+		g.Result = pgnResults[g.Tags["Result"]]
+	}
+
+	return g, nil
+}
+
+// replaySAN returns the moves written in SAN played one after the other from the position, which is left as it was.
+// It returns false if one of them cannot be played
+func (gs *GameState) replaySAN(sans []string) ([]Move, bool) {
+	var line []Move
+	defer func() {
+		for range line {
+			gs.Undo()
+		}
+	}()
+	for _, san := range sans {
+		move, err := gs.ParseSAN(san)
+		if err != nil {
+			return nil, false
+		}
+		gs.executeMove(move.Origin, move.Destination, move.MoveType)
+		line = append(line, move)
+	}
+	return append([]Move(nil), line...), len(line) > 0
+}
+
+// token reads a word of the movetext, up to a space or the start of a comment, variation or tag
+func (pr *PGNReader) token() string {
+	var sb strings.Builder
+	for {
+		c, _, err := pr.r.ReadRune()
+		if err != nil {
+			break
+		}
+		if unicode.IsSpace(c) || strings.ContainsRune("[]{}();", c) {
+			pr.r.UnreadRune()
+			break
+		}
+		sb.WriteRune(c)
+	}
+	return sb.String()
+}
+
+// pgnRoster are the tags every PGN game has, in the order they are written, with the values of unknown ones
+var pgnRoster = []struct{ name, unknown string }{
+	{"Event", "?"}, {"Site", "?"}, {"Date", "????.??.??"}, {"Round", "?"}, {"White", "?"}, {"Black", "?"},
+	{"Result", "*"},
+}
+
+// pgnLineWidth is the width movetext lines are wrapped at
+const pgnLineWidth = 79
+
+// Write writes the game in PGN: the seven tag roster, the other tags by name, then the movetext with the NAGs,
+// comments and variations of each move
+func (g *PGNGame) Write(w io.Writer) error {
+	gs, err := g.Start()
+	if err != nil {
+		return err
+	}
+	bw := bufio.NewWriter(w)
+
+	// 1. the tags
+	tag := func(name, value string) {
+		fmt.Fprintf(bw, "[%s \"%s\"]\n", name, strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(value))
+	}
+	roster := map[string]bool{}
+	for _, t := range pgnRoster {
+		roster[t.name] = true
+		value, ok := g.Tags[t.name]
+		switch {
+		case t.name == "Result":
+			value = g.Result.String()
+		case !ok:
+			value = t.unknown
+		}
+		tag(t.name, value)
+	}
+	var names []string
+	for name := range g.Tags {
+		if !roster[name] {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		tag(name, g.Tags[name])
+	}
+	fmt.Fprintln(bw)
+
+	// 2. the movetext, wrapped
+	var tokens []string
+	for ply, move := range g.Moves {
+		annotation := g.annotation(ply)
+		numbered := ply == 0 || gs.currColor == White ||
+			len(g.annotation(ply-1).Variations) > 0 || g.annotation(ply-1).Comment != ""
+		tokens = append(tokens, gs.pgnMove(move, numbered))
+		for _, nag := range annotation.NAGs {
+			tokens = append(tokens, fmt.Sprintf("$%d", nag))
+		}
+		if annotation.Comment != "" {
+			tokens = append(tokens, "{"+strings.ReplaceAll(annotation.Comment, "}", ")")+"}")
+		}
+		for _, variation := range annotation.Variations {
+			tokens = append(tokens, "("+gs.pgnLine(variation)+")")
+		}
+		gs.executeMove(move.Origin, move.Destination, move.MoveType)
+	}
+	tokens = append(tokens, g.Result.String())
+
+	// a move number stays on the line of its move
+	var words []string
+	for _, word := range strings.Fields(strings.Join(tokens, " ")) {
+		if n := len(words); n > 0 && strings.HasSuffix(words[n-1], ".") && unicode.IsDigit(rune(words[n-1][0])) {
+			words[n-1] += " " + word
+			continue
+		}
+		words = append(words, word)
+	}
+	width := 0
+	for _, word := range words {
+		if width > 0 && width+1+len(word) > pgnLineWidth {
+			fmt.Fprintln(bw)
+			width = 0
+		} else if width > 0 {
+			fmt.Fprint(bw, " ")
+			width++
+		}
+		fmt.Fprint(bw, word)
+		width += len(word)
+	}
+	fmt.Fprint(bw, "\n\n")
+
+	return bw.Flush()
+}
+
+// pgnMove returns the move in SAN, after its move number when numbered or played by white
+func (gs *GameState) pgnMove(move Move, numbered bool) string {
+	switch {
+	case gs.currColor == White:
+		return fmt.Sprintf("%d. %s", gs.fullMoveNumber, gs.SAN(move))
+	case numbered:
+		return fmt.Sprintf("%d... %s", gs.fullMoveNumber, gs.SAN(move))
+	}
+	return gs.SAN(move)
+}
+
+// pgnLine returns the moves played from the position as PGN movetext, which leaves the position as it was
+func (gs *GameState) pgnLine(line []Move) string {
+	words := make([]string, len(line))
+	for i, move := range line {
+		words[i] = gs.pgnMove(move, i == 0)
+		gs.executeMove(move.Origin, move.Destination, move.MoveType)
+	}
+	for range line {
+		gs.Undo()
+	}
+	return strings.Join(words, " ")
+}
+
+// PGN returns the game played so far, from its first position
+func (gs *GameState) PGN() *PGNGame {
+	g := &PGNGame{Tags: map[string]string{}, Moves: gs.Moves()}
+	for range g.Moves {
+		gs.Undo()
+	}
+	if start := gs.FEN(); start != NewGame().FEN() {
+		g.Tags["SetUp"], g.Tags["FEN"] = "1", start
+	}
+	for _, move := range g.Moves {
+		gs.executeMove(move.Origin, move.Destination, move.MoveType)
+	}
+	g.Result, _ = gs.Result()
+	return g
+}
@@ -0,0 +1,180 @@
+package game
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const annotatedPGN = `[Event "Casual game"]
+[White "Anderssen"]
+[Black "Kieseritzky"]
+[Result "1-0"]
+
+1. e4 {The king's pawn.} e5 2. f4! $13 exf4 3.Bc4 (3. Nf3 g5 (3... d6) 4. h4) Qh4+ 4. Kf1 b5?! ; a comment to the end of the line
+5. Bxb5 1-0
+`
+
+func TestPGNRead(t *testing.T) {
+	g, err := NewPGNReader(strings.NewReader(annotatedPGN)).Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if g.Tags["White"] != "Anderssen" || g.Tags["Event"] != "Casual game" || g.Result != WhiteWon {
+		t.Errorf("tags %v, result %v", g.Tags, g.Result)
+	}
+	gs := NewGame()
+	var sans []string
+	for _, move := range g.Moves {
+		sans = append(sans, gs.SAN(move))
+		gs.executeMove(move.Origin, move.Destination, move.MoveType)
+	}
+	if got, want := strings.Join(sans, " "), "e4 e5 f4 exf4 Bc4 Qh4+ Kf1 b5 Bxb5"; got != want {
+		t.Errorf("moves %s, want %s", got, want)
+	}
+
+	tests := []struct {
+		ply        int
+		nags       []int
+		comment    string
+		variations []string // in coordinate notation, moves separated by spaces
+	}{
+		{0, nil, "The king's pawn.", nil},
+		{2, []int{NAGGood, 13}, "", nil},
+		{4, nil, "", []string{"g1f3 g7g5 h2h4"}},
+		{7, []int{NAGDubious}, "", nil},
+		{8, nil, "", nil},
+	}
+	for _, tt := range tests {
+		a := g.annotation(tt.ply)
+		var variations []string
+		for _, line := range a.Variations {
+			var moves []string
+			for _, move := range line {
+				moves = append(moves, move.String())
+			}
+			variations = append(variations, strings.Join(moves, " "))
+		}
+		if !reflect.DeepEqual(a.NAGs, tt.nags) || a.Comment != tt.comment || !reflect.DeepEqual(variations, tt.variations) {
+			t.Errorf("ply %d: %v %q %v, want %v %q %v", tt.ply+1, a.NAGs, a.Comment, variations, tt.nags, tt.comment,
+				tt.variations)
+		}
+	}
+}
+
+func TestPGNWriteRead(t *testing.T) {
+	g, err := NewPGNReader(strings.NewReader(annotatedPGN)).Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := g.Write(&buf); err != nil {
+		t.Fatal(err)
+	}
+	written := buf.String()
+	words := strings.Join(strings.Fields(written), " ")
+	for _, want := range []string{
+		`[Date "????.??.??"]`, `[Result "1-0"]`,
+		`1. e4 {The king's pawn.} 1... e5 2. f4 $1 $13`, `3. Bc4 (3. Nf3 g5 4. h4) 3... Qh4+`, "5. Bxb5 1-0",
+	} {
+		if !strings.Contains(words, want) {
+			t.Errorf("written game lacks %q:\n%s", want, written)
+		}
+	}
+	for _, line := range strings.Split(written, "\n") {
+		if len(line) > pgnLineWidth {
+			t.Errorf("line longer than %d characters: %s", pgnLineWidth, line)
+		}
+	}
+
+	read, err := NewPGNReader(&buf).Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(read.Moves, g.Moves) || !reflect.DeepEqual(read.Annotations, g.Annotations) ||
+		read.Result != g.Result || read.Tags["White"] != g.Tags["White"] {
+		t.Errorf("read back as %+v, want %+v", read, g)
+	}
+}
+
+func TestPGNReader(t *testing.T) {
+	const games = `[Event "from a position"]
+[SetUp "1"]
+[FEN "6k1/5ppp/8/8/8/8/8/R5K1 w - - 0 1"]
+
+1. Ra8# 1-0
+
+[Event "illegal move"]
+
+1. e4 e5 2. Ke3 *
+
+[Event "no result"]
+
+1. d4 d5
+
+[Event "last"]
+[Result "1/2-1/2"]
+
+1. c4 c5 1/2-1/2
+`
+	tests := []struct {
+		event  string
+		moves  int
+		result GameResult
+		err    bool
+	}{
+		{"from a position", 1, WhiteWon, false},
+		{"illegal move", 0, Unfinished, true},
+		{"no result", 2, Unfinished, false},
+		{"last", 2, Drawn, false},
+	}
+
+	pr := NewPGNReader(strings.NewReader(games))
+	for i, tt := range tests {
+		g, err := pr.Next()
+		var pgnErr *PGNError
+		if tt.err {
+			if !errors.As(err, &pgnErr) || pgnErr.Game != i+1 {
+				t.Errorf("%s: error %v, want a PGNError for game %d", tt.event, err, i+1)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%s: %v", tt.event, err)
+		}
+		if g.Tags["Event"] != tt.event || len(g.Moves) != tt.moves || g.Result != tt.result {
+			t.Errorf("game %d: %q with %d moves and result %v, want %q with %d and %v", i+1, g.Tags["Event"],
+				len(g.Moves), g.Result, tt.event, tt.moves, tt.result)
+		}
+	}
+	if _, err := pr.Next(); err != io.EOF {
+		t.Errorf("after the last game: %v, want io.EOF", err)
+	}
+}
+
+func TestGamePGN(t *testing.T) {
+	gs := mustFEN(t, "6k1/5ppp/8/8/8/8/8/R5K1 w - - 0 1")
+	move, err := gs.ParseSAN("Ra8#")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := gs.Play(move); err != nil {
+		t.Fatal(err)
+	}
+
+	g := gs.PGN()
+	if g.Tags["FEN"] != "6k1/5ppp/8/8/8/8/8/R5K1 w - - 0 1" || g.Tags["SetUp"] != "1" {
+		t.Errorf("tags %v, want the starting position", g.Tags)
+	}
+	if len(g.Moves) != 1 || g.Moves[0] != move || g.Result != WhiteWon {
+		t.Errorf("moves %v with result %v, want [%v] and 1-0", g.Moves, g.Result, move)
+	}
+	if got := gs.FEN(); got != "R5k1/5ppp/8/8/8/8/8/6K1 b - - 1 1" {
+		t.Errorf("PGN left the game at %s", got)
+	}
+}
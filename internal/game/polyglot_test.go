@@ -0,0 +1,198 @@
+package game
+
+import (
+	"encoding/binary"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// the keys given with the specification of the Polyglot book format
+func TestPolyglotKey(t *testing.T) {
+	tests := []struct {
+		moves []string
+		key   uint64
+	}{
+		{nil, 0x463b96181691fc9c},
+		{[]string{"e2e4"}, 0x823c9b50fd114196},
+		{[]string{"e2e4", "d7d5"}, 0x0756b94461c50fb0},
+		{[]string{"e2e4", "d7d5", "e4e5"}, 0x662fafb965db29d4},
+		{[]string{"e2e4", "d7d5", "e4e5", "f7f5"}, 0x22a48b5a8e47ff78},
+		{[]string{"e2e4", "d7d5", "e4e5", "f7f5", "e1e2"}, 0x652a607ca3f242c1},
+		{[]string{"e2e4", "d7d5", "e4e5", "f7f5", "e1e2", "e8f7"}, 0x00fdd303c946bdd9},
+		{[]string{"a2a4", "b7b5", "h2h4", "b5b4", "c2c4"}, 0x3c8123ea7b067637},
+		{[]string{"a2a4", "b7b5", "h2h4", "b5b4", "c2c4", "b4c3", "a1a3"}, 0x5c3f9b829b279560},
+	}
+
+	for _, tt := range tests {
+		gs := NewGame()
+		for _, s := range tt.moves {
+			move, err := gs.ParseMove(s)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := gs.Play(move); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if got := gs.PolyglotKey(); got != tt.key {
+			t.Errorf("%v: key %016x, want %016x", tt.moves, got, tt.key)
+		}
+	}
+}
+
+// writeBook writes the entries to a Polyglot book file in the given order
+func writeBook(t *testing.T, entries []bookEntry) string {
+	t.Helper()
+	data := make([]byte, 0, len(entries)*polyglotEntrySize)
+	for _, e := range entries {
+		var entry [polyglotEntrySize]byte
+		binary.BigEndian.PutUint64(entry[0:8], e.key)
+		binary.BigEndian.PutUint16(entry[8:10], e.move)
+		binary.BigEndian.PutUint16(entry[10:12], e.weight)
+		data = append(data, entry[:]...)
+	}
+	path := filepath.Join(t.TempDir(), "book.bin")
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadBook(t *testing.T) {
+	castling := mustFEN(t, "r3k2r/8/8/8/8/8/8/R3K2R w KQkq - 0 1")
+	promotion := mustFEN(t, "4k3/P7/8/8/8/8/8/4K3 w - - 0 1")
+
+	// moves are encoded as the origin and destination with a1 as 0, and castling as the king taking its rook
+	start := NewGame().PolyglotKey()
+	path := writeBook(t, []bookEntry{
+		{castling.PolyglotKey(), 4<<6 | 7, 1},
+		{start, 12<<6 | 28, 3},
+		{promotion.PolyglotKey(), 4<<12 | 48<<6 | 56, 1},
+		{start, 11<<6 | 27, 1},
+		{start, 6<<6 | 21, 0},
+		{castling.PolyglotKey(), 4<<6 | 0, 1},
+	})
+	book, err := LoadBook(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if book.Len() != 6 {
+		t.Errorf("Len() = %d, want 6", book.Len())
+	}
+
+	tests := []struct {
+		name  string
+		gs    *GameState
+		moves []string
+	}{
+		{"start position", NewGame(), []string{"e2e4", "d2d4", "g1f3"}},
+		{"castling", castling, []string{"e1g1", "e1c1"}},
+		{"promotion", promotion, []string{"a7a8q"}},
+		{"not in the book", mustFEN(t, "4k3/8/8/8/8/8/8/4K3 w - - 0 1"), nil},
+	}
+	for _, tt := range tests {
+		moves := book.Moves(tt.gs)
+		found := map[string]bool{}
+		for _, move := range moves {
+			found[move.Move.String()] = true
+		}
+		if len(moves) != len(tt.moves) {
+			t.Errorf("%s: %d book moves, want %d", tt.name, len(moves), len(tt.moves))
+		}
+		for _, want := range tt.moves {
+			if !found[want] {
+				t.Errorf("%s: %s is not among the book moves %v", tt.name, want, moves)
+			}
+		}
+	}
+
+	// moves are picked in proportion to their weights, and never with a weight of 0
+	rng := rand.New(rand.NewSource(1))
+	picked := map[string]int{}
+	for i := 0; i < 4000; i++ {
+		move, ok := book.Pick(NewGame(), rng)
+		if !ok {
+			t.Fatal("Pick found no move")
+		}
+		picked[move.String()]++
+	}
+	if picked["g1f3"] != 0 || picked["e2e4"] < 2700 || picked["e2e4"] > 3300 {
+		t.Errorf("picked %v, want e2e4 three times as often as d2d4 and never g1f3", picked)
+	}
+
+	if _, err := LoadBook(filepath.Join(t.TempDir(), "missing.bin")); err == nil {
+		t.Error("LoadBook of a missing file succeeded")
+	}
+	bad := filepath.Join(t.TempDir(), "bad.bin")
+	if err := os.WriteFile(bad, make([]byte, polyglotEntrySize+1), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadBook(bad); err == nil {
+		t.Error("LoadBook of a truncated file succeeded")
+	}
+}
+
+func TestSearchBookMove(t *testing.T) {
+	start := NewGame().PolyglotKey()
+	book, err := LoadBook(writeBook(t, []bookEntry{{start, 12<<6 | 28, 1}, {start, 11<<6 | 27, 1}, {start, 6<<6 | 21, 1}}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the same seed draws the same book move, and the seeds between them draw every move
+	played := map[string]bool{}
+	for seed := int64(1); seed <= 20; seed++ {
+		a, okA := NewGame().Search(Limits{Depth: 1, Book: book, Rand: rand.New(rand.NewSource(seed))})
+		b, okB := NewGame().Search(Limits{Depth: 1, Book: book, Rand: rand.New(rand.NewSource(seed))})
+		if !okA || !okB || a.Move != b.Move {
+			t.Errorf("seed %d: searched %v and %v", seed, a.Move, b.Move)
+		}
+		played[a.Move.String()] = true
+
+		gs := NewGame()
+		gs.ExecuteBestMoveWith(Limits{Depth: 1, Book: book, Rand: rand.New(rand.NewSource(seed))})
+		if moves := gs.Moves(); len(moves) != 1 || moves[0] != a.Move {
+			t.Errorf("seed %d: ExecuteBestMoveWith played %v, want %v", seed, moves, a.Move)
+		}
+	}
+	if len(played) != 3 {
+		t.Errorf("seeds 1 to 20 played %v, want all 3 book moves", played)
+	}
+
+	// the book belongs to the limits it is given with: searches without it, or past its depth, search instead. Its
+	// only move is one the search does not play
+	book, err = LoadBook(writeBook(t, []bookEntry{{start, 6<<6 | 23, 1}}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name   string
+		limits Limits
+		moves  []string
+		book   bool
+	}{
+		{"without a book", Limits{Depth: 1}, nil, false},
+		{"within the depth", Limits{Depth: 1, Book: book, BookDepth: 1}, nil, true},
+		{"past the depth", Limits{Depth: 1, Book: book, BookDepth: 1}, []string{"b1c3", "b8c6", "c3b1", "c6b8"}, false},
+	}
+	for _, tt := range tests {
+		gs := NewGame()
+		for _, s := range tt.moves {
+			move, err := gs.ParseMove(s)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := gs.Play(move); err != nil {
+				t.Fatal(err)
+			}
+		}
+		line, ok := gs.Search(tt.limits)
+		if !ok {
+			t.Fatalf("%s: no move", tt.name)
+		}
+		if fromBook := line.Move.String() == "g1h3"; fromBook != tt.book {
+			t.Errorf("%s: searched %v, from the book %v, want %v", tt.name, line.PV, fromBook, tt.book)
+		}
+	}
+}
@@ -0,0 +1,302 @@
+package game
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Puzzle is a position where the player to move has a single winning line: at each of their moves exactly one move
+// wins material or mates
+type Puzzle struct {
+	FEN string
+
+	// the player's moves and the opponent's replies in turn, starting and ending with the player's
+	Solution []Move
+
+	// what the puzzle is about, named like the Lichess puzzle themes, e.g. mateIn2, fork or sacrifice
+	Themes []string
+
+	// where the puzzle was found: the number of its game in the file and the ply of the opponent's mistake
+	Game int
+	Ply  int
+}
+
+// PuzzleOptions set how deep the engine looks for puzzles and how clear their solution has to be
+type PuzzleOptions struct {
+	Depth int8
+
+	// centipawns the opponent's mistake and every move of the solution must win over any other move
+	Margin int
+
+	// the longest solution, in moves of the player
+	MaxMoves int
+}
+
+// DefaultPuzzleOptions search to depth 2 for solutions of up to 3 moves, each winning 2 pawns more than the others
+var DefaultPuzzleOptions = PuzzleOptions{Depth: 2, Margin: 200, MaxMoves: 3}
+
+// PuzzleReport counts the games puzzles were extracted from
+type PuzzleReport struct {
+	Games   int // games scanned
+	Skipped int // games with moves that could not be read
+}
+
+// ExtractPuzzles scans the games of a PGN file for the positions following a mistake whose punishment is a puzzle.
+// Progress is called after each game with the number of games scanned and puzzles found so far
+func ExtractPuzzles(r io.Reader, options PuzzleOptions, progress func(games, puzzles int)) ([]Puzzle, PuzzleReport, error) {
+	var puzzles []Puzzle
+	var report PuzzleReport
+	analysisOptions := AnnotateOptions{Depth: options.Depth, Blunder: options.Margin}
+
+	pr := NewPGNReader(r)
+	for number := 1; ; number++ {
+		g, err := pr.Next()
+		if err == io.EOF {
+			break
+		}
+		var pgnErr *PGNError
+		if errors.As(err, &pgnErr) {
+			report.Skipped++
+			continue
+		}
+		if err != nil {
+			return nil, report, err
+		}
+		report.Games++
+
+		// 1. the mistakes of the game, found the same way as when annotating it
+		analysis, err := g.Analyze(analysisOptions, nil)
+		if err != nil {
+			return nil, report, &PGNError{Game: number, Err: err}
+		}
+
+		// 2. the positions they lead to with a single winning line, once for each solution in a game where the
+		// same chance is missed again and again
+		gs, _ := g.Start()
+		solutions := map[string]bool{}
+		for ply, move := range g.Moves {
+			gs.executeMove(move.Origin, move.Destination, move.MoveType)
+			if analysis[ply].Judgement != Blunder {
+				continue
+			}
+			puzzle, ok := gs.puzzle(options)
+			if !ok || solutions[fmt.Sprint(puzzle.Solution)] {
+				continue
+			}
+			solutions[fmt.Sprint(puzzle.Solution)] = true
+			puzzle.Game, puzzle.Ply = number, ply+1
+			puzzles = append(puzzles, puzzle)
+		}
+
+		if progress != nil {
+			progress(report.Games, len(puzzles))
+		}
+	}
+
+	return puzzles, report, nil
+}
+
+// puzzle returns the puzzle of the position if the current player has a single winning line, which leaves the
+// position as it was
+func (gs *GameState) puzzle(options PuzzleOptions) (Puzzle, bool) {
+	p := Puzzle{FEN: gs.FEN()}
+	defer func() {
+		for range p.Solution {
+			gs.Undo()
+		}
+	}()
+
+	// 1. the player's move must win, and no other move come close
+	lines := gs.MultiPV(options.Depth, 2)
+	if len(lines) < 2 || lines[0].Score < options.Margin || !uniqueWin(lines, options.Margin) {
+		return Puzzle{}, false
+	}
+	score := lines[0].Score
+	_, mate := MateMoves(score)
+
+	// 2. the solution carries on while the opponent's best reply leaves a single winning move again
+	for moves := 1; ; moves++ {
+		move := lines[0].Move
+		p.Solution = append(p.Solution, move)
+		gs.executeMove(move.Origin, move.Destination, move.MoveType)
+		if moves == options.MaxMoves {
+			break
+		}
+
+		replies := gs.MultiPV(options.Depth, 1)
+		if len(replies) == 0 {
+			break
+		}
+		reply := replies[0].Move
+		gs.executeMove(reply.Origin, reply.Destination, reply.MoveType)
+		lines = gs.MultiPV(options.Depth, 2)
+		if len(lines) == 0 || !uniqueWin(lines, options.Margin) {
+			gs.Undo()
+			break
+		}
+		p.Solution = append(p.Solution, reply)
+	}
+
+	// 3. a mate must be seen through to the end
+	if result, _ := gs.Result(); mate && (result == Unfinished || result == Drawn) {
+		return Puzzle{}, false
+	}
+
+	p.Themes = gs.puzzleThemes(p, score, mate)
+	return p, true
+}
+
+// uniqueWin returns true if the best of the lines, ranked best first, wins by the margin over any other move, or
+// mates where no other move does. A single legal move is forced and so unique
+func uniqueWin(lines []Line, margin int) bool {
+	if len(lines) == 1 {
+		return true
+	}
+	if lines[0].Score >= mateBound {
+		return lines[1].Score < mateBound
+	}
+	return lines[0].Score-lines[1].Score >= margin
+}
+
+// puzzleThemes returns the themes of the puzzle, whose solution has been played on the position
+func (gs *GameState) puzzleThemes(p Puzzle, score int, mate bool) []string {
+	var themes []string
+	moves := (len(p.Solution) + 1) / 2
+
+	// 1. the outcome
+	switch {
+	case mate:
+		themes = append(themes, "mate", fmt.Sprintf("mateIn%d", moves))
+	case score >= 500:
+		themes = append(themes, "crushing")
+	default:
+		themes = append(themes, "advantage")
+	}
+
+	// 2. the length of the solution
+	themes = append(themes, [...]string{"oneMove", "short", "long"}[min(moves, 3)-1])
+
+	// 3. the special moves of the player and the motifs they make use of, replayed from the start
+	for range p.Solution {
+		gs.Undo()
+	}
+	special := map[string]bool{}
+	player := gs.currColor
+	for i, move := range p.Solution {
+		if i%2 == 1 {
+			gs.executeMove(move.Origin, move.Destination, move.MoveType)
+			continue
+		}
+
+		switch {
+		case move.MoveType.promotion() != Pawn:
+			special["promotion"] = true
+		case move.MoveType == EnPassantAttack:
+			special["enPassant"] = true
+		case move.MoveType >= WhiteKingSideCastle && move.MoveType <= BlackQueenSideCastle:
+			special["castling"] = true
+		}
+		if gs.isCapture(move) && gs.SEE(move) < 0 {
+			special["sacrifice"] = true
+		}
+		for _, f := range gs.HangingPieces(player) {
+			special["hangingPiece"] = special["hangingPiece"] || f.Square == move.Destination
+		}
+		for _, f := range gs.DiscoveredAttacks(player) {
+			special["discoveredAttack"] = special["discoveredAttack"] || f.Targets[0] == move.Origin
+		}
+
+		gs.executeMove(move.Origin, move.Destination, move.MoveType)
+		for theme, findings := range map[string][]Finding{
+			"fork": gs.Forks(player), "pin": gs.Pins(player), "skewer": gs.Skewers(player),
+		} {
+			for _, f := range findings {
+				special[theme] = special[theme] || f.Square == move.Destination
+			}
+		}
+	}
+	for _, theme := range []string{"fork", "pin", "skewer", "discoveredAttack", "hangingPiece", "promotion", "enPassant",
+		"castling", "sacrifice"} {
+		if special[theme] {
+			themes = append(themes, theme)
+		}
+	}
+	return themes
+}
+
+// puzzleHeader names the columns of a puzzle file
+var puzzleHeader = []string{"FEN", "Moves", "Themes", "Game", "Ply"}
+
+// SavePuzzles writes the puzzles as CSV with a header line: the FEN, the solution in UCI notation and the themes,
+// both separated by spaces, then where the puzzle was found
+func SavePuzzles(path string, puzzles []Puzzle) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	w := csv.NewWriter(file)
+	w.Write(puzzleHeader)
+	for _, p := range puzzles {
+		moves := make([]string, len(p.Solution))
+		for i, move := range p.Solution {
+			moves[i] = move.String()
+		}
+		w.Write([]string{p.FEN, strings.Join(moves, " "), strings.Join(p.Themes, " "), strconv.Itoa(p.Game),
+			strconv.Itoa(p.Ply)})
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return err
+	}
+	return file.Close()
+}
+
+// LoadPuzzles reads puzzles written by SavePuzzles
+func LoadPuzzles(path string) ([]Puzzle, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	r := csv.NewReader(file)
+	r.FieldsPerRecord = len(puzzleHeader)
+	records, err := r.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	var puzzles []Puzzle
+	for i, record := range records {
+		if i == 0 && record[0] == puzzleHeader[0] {
+			continue
+		}
+		p := Puzzle{FEN: record[0], Themes: strings.Fields(record[2])}
+		gs, err := NewGameFromFEN(p.FEN)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, i+1, err)
+		}
+		for _, s := range strings.Fields(record[1]) {
+			move, err := gs.ParseMove(s)
+			if err != nil {
+				return nil, fmt.Errorf("%s:%d: %w", path, i+1, err)
+			}
+			gs.executeMove(move.Origin, move.Destination, move.MoveType)
+			p.Solution = append(p.Solution, move)
+		}
+		if len(p.Solution) == 0 {
+			return nil, fmt.Errorf("%s:%d: no solution", path, i+1)
+		}
+		p.Game, _ = strconv.Atoi(record[3])
+		p.Ply, _ = strconv.Atoi(record[4])
+		puzzles = append(puzzles, p)
+	}
+	return puzzles, nil
+}
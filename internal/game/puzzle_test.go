@@ -0,0 +1,160 @@
+package game
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestPuzzle(t *testing.T) {
+	tests := []struct {
+		name     string
+		fen      string
+		solution string   // in coordinate notation, empty if the position is no puzzle
+		themes   []string // themes the puzzle must have
+	}{
+		{"mate in one", "6k1/5ppp/8/8/8/8/8/R5K1 w - - 0 1", "a1a8", []string{"mate", "mateIn1", "oneMove"}},
+		{"knight fork", "r3k3/8/8/3N4/8/8/8/4K3 w - - 0 1", "d5c7 e8d7 c7a8", []string{"advantage", "short", "fork"}},
+		{"free queen", "4k3/8/8/4q3/8/8/8/4RK2 w - - 0 1", "e1e5", []string{"crushing", "oneMove", "hangingPiece"}},
+		{"several mates", "7k/8/8/8/8/8/R7/1R4K1 w - - 0 1", "", nil},
+		{"nothing to win", StartFEN, "", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gs := mustFEN(t, tt.fen)
+			p, ok := gs.puzzle(DefaultPuzzleOptions)
+			if got := gs.FEN(); got != tt.fen {
+				t.Errorf("puzzle left the position at %s", got)
+			}
+			if !ok {
+				if tt.solution != "" {
+					t.Errorf("no puzzle, want %s", tt.solution)
+				}
+				return
+			}
+			if tt.solution == "" {
+				t.Fatalf("puzzle %v, want none", p.Solution)
+			}
+
+			moves := make([]string, len(p.Solution))
+			for i, move := range p.Solution {
+				moves[i] = move.String()
+			}
+			// the opponent's replies may be any that lose the same way, so only the player's moves are checked
+			want := strings.Fields(tt.solution)
+			if len(moves) != len(want) {
+				t.Fatalf("solution %v, want %v", moves, want)
+			}
+			for i := 0; i < len(moves); i += 2 {
+				if moves[i] != want[i] {
+					t.Errorf("solution %v, want %v", moves, want)
+				}
+			}
+
+			themes := map[string]bool{}
+			for _, theme := range p.Themes {
+				themes[theme] = true
+			}
+			for _, theme := range tt.themes {
+				if !themes[theme] {
+					t.Errorf("themes %v lack %s", p.Themes, theme)
+				}
+			}
+		})
+	}
+}
+
+func TestExtractPuzzles(t *testing.T) {
+	const games = `[Event "Scholar's mate"]
+[Result "1-0"]
+
+1. e4 e5 2. Qh5 Nc6 3. Bc4 Nf6 4. Qxf7# 1-0
+
+[Event "illegal"]
+[Result "*"]
+
+1. e4 e4 *
+
+[Event "quiet"]
+[Result "1/2-1/2"]
+
+1. Nf3 Nf6 2. Ng1 Ng8 1/2-1/2
+`
+	var calls int
+	puzzles, report, err := ExtractPuzzles(strings.NewReader(games), DefaultPuzzleOptions, func(games, found int) {
+		calls++
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Games != 2 || report.Skipped != 1 || calls != 2 {
+		t.Errorf("report %+v after %d calls, want 2 games, 1 skipped and 2 calls", report, calls)
+	}
+	if len(puzzles) != 1 {
+		t.Fatalf("%d puzzles, want 1", len(puzzles))
+	}
+
+	p := puzzles[0]
+	want := Puzzle{
+		FEN:    "r1bqkb1r/pppp1ppp/2n2n2/4p2Q/2B1P3/8/PPPP1PPP/RNB1K1NR w KQkq - 4 4",
+		Themes: []string{"mate", "mateIn1", "oneMove"},
+		Game:   1,
+		Ply:    6,
+	}
+	if p.FEN != want.FEN || p.Game != want.Game || p.Ply != want.Ply || len(p.Solution) != 1 ||
+		p.Solution[0].String() != "h5f7" {
+		t.Errorf("puzzle %+v, want Qxf7# after ply 6 of game 1", p)
+	}
+	for i, theme := range want.Themes {
+		if i >= len(p.Themes) || p.Themes[i] != theme {
+			t.Errorf("themes %v, want them to start with %v", p.Themes, want.Themes)
+			break
+		}
+	}
+}
+
+func TestSaveLoadPuzzles(t *testing.T) {
+	var puzzles []Puzzle
+	for i, fen := range []string{"6k1/5ppp/8/8/8/8/8/R5K1 w - - 0 1", "r3k3/8/8/3N4/8/8/8/4K3 w - - 0 1"} {
+		p, ok := mustFEN(t, fen).puzzle(DefaultPuzzleOptions)
+		if !ok {
+			t.Fatalf("%s: no puzzle", fen)
+		}
+		p.Game, p.Ply = i+1, 10*i
+		puzzles = append(puzzles, p)
+	}
+
+	path := filepath.Join(t.TempDir(), "puzzles.csv")
+	if err := SavePuzzles(path, puzzles); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadPuzzles(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(loaded, puzzles) {
+		t.Errorf("LoadPuzzles read back %+v, want %+v", loaded, puzzles)
+	}
+
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{"invalid FEN", "FEN,Moves,Themes,Game,Ply\nnot a fen,a1a8,mate,1,1\n"},
+		{"illegal move", "FEN,Moves,Themes,Game,Ply\n6k1/5ppp/8/8/8/8/8/R5K1 w - - 0 1,a1h8,mate,1,1\n"},
+		{"no solution", "FEN,Moves,Themes,Game,Ply\n6k1/5ppp/8/8/8/8/8/R5K1 w - - 0 1,,mate,1,1\n"},
+		{"missing column", "FEN,Moves,Themes,Game,Ply\n6k1/5ppp/8/8/8/8/8/R5K1 w - - 0 1,a1a8\n"},
+	}
+	for _, tt := range tests {
+		path := filepath.Join(t.TempDir(), "puzzles.csv")
+		if err := os.WriteFile(path, []byte(tt.content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := LoadPuzzles(path); err == nil {
+			t.Errorf("%s: LoadPuzzles succeeded", tt.name)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package game
+
+import "math/rand"
+
+// MoveWeight gives the relative chance of a legal move being picked at random in the position. Moves weighted 0
+// or less are never picked unless every move is
+type MoveWeight func(gs *GameState, move Move) float64
+
+// PreferCaptures makes captures and promotions the given number of times as likely as quiet moves
+func PreferCaptures(factor float64) MoveWeight {
+	return func(gs *GameState, move Move) float64 {
+		if gs.isCapture(move) {
+			return factor
+		}
+		return 1
+	}
+}
+
+// RandomMove picks a legal move for the current player drawn from rng, each move as likely as its weight, or all
+// of them alike when weight is nil. Moves are generated in a fixed order, so the same seed and position yield the
+// same move. A nil rng uses the shared source of math/rand
+func (gs *GameState) RandomMove(rng *rand.Rand, weight MoveWeight) (Move, bool) {
+	var moves MoveList
+	gs.legalMoves(&moves)
+	if moves.Len() == 0 {
+		return Move{}, false
+	}
+	if weight == nil {
+		return moves.moves[randIntn(rng, moves.Len())], true
+	}
+
+	var total float64
+	var weights [maxMoves]float64
+	for i, move := range moves.Moves() {
+		weights[i] = max(weight(gs, move), 0)
+		total += weights[i]
+	}
+	if total == 0 {
+		return moves.moves[randIntn(rng, moves.Len())], true
+	}
+
+	pick := randFloat64(rng) * total
+	for i, move := range moves.Moves() {
+		pick -= weights[i]
+		if pick < 0 {
+			return move, true
+		}
+	}
+	return moves.moves[moves.Len()-1], true
+}
+
+// ExecuteRandomMove plays a legal move for the current player picked by RandomMove
+func (gs *GameState) ExecuteRandomMove(rng *rand.Rand, weight MoveWeight) bool {
+	move, ok := gs.RandomMove(rng, weight)
+	if !ok {
+		return false
+	}
+	gs.executeMove(move.Origin, move.Destination, move.MoveType)
+	return true
+}
+
+// randIntn returns a number in [0, n) drawn from rng, or from the shared source of math/rand when rng is nil
+func randIntn(rng *rand.Rand, n int) int {
+	if rng == nil {
+		return rand.Intn(n)
+	}
+	return rng.Intn(n)
+}
+
+// randFloat64 returns a number in [0, 1) drawn from rng, or from the shared source of math/rand when rng is nil
+func randFloat64(rng *rand.Rand) float64 {
+	if rng == nil {
+		return rand.Float64()
+	}
+	return rng.Float64()
+}
@@ -0,0 +1,113 @@
+package game
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// randomGame plays random moves from the start with the seed and returns them
+func randomGame(seed int64, weight MoveWeight) []Move {
+	rng := rand.New(rand.NewSource(seed))
+	gs := NewGame()
+	for ply := 0; ply < 60 && gs.ExecuteRandomMove(rng, weight); ply++ {
+	}
+	return gs.Moves()
+}
+
+func TestRandomMoveSeeded(t *testing.T) {
+	for _, weight := range []MoveWeight{nil, PreferCaptures(5)} {
+		a, b := randomGame(7, weight), randomGame(7, weight)
+		if len(a) != len(b) {
+			t.Fatalf("the same seed played %d and %d moves", len(a), len(b))
+		}
+		for i := range a {
+			if a[i] != b[i] {
+				t.Fatalf("the same seed played %v and %v", a, b)
+			}
+		}
+
+		c := randomGame(8, weight)
+		same := len(a) == len(c)
+		for i := 0; same && i < len(a); i++ {
+			same = a[i] == c[i]
+		}
+		if same {
+			t.Errorf("seeds 7 and 8 played the same game %v", a)
+		}
+	}
+}
+
+func TestRandomMoveWeights(t *testing.T) {
+	// the rook can take the knight or make one of 7 quiet moves, the king has 4 moves
+	const fen = "4k3/8/8/8/8/8/8/Rn2K3 w - - 0 1"
+	capture := "a1b1"
+
+	tests := []struct {
+		name   string
+		weight MoveWeight
+		share  float64 // expected share of the capture
+	}{
+		{"uniform", nil, 1.0 / 12},
+		{"captures five times as likely", PreferCaptures(5), 5.0 / 16},
+		{"captures only", func(gs *GameState, move Move) float64 {
+			if gs.isCapture(move) {
+				return 1
+			}
+			return 0
+		}, 1},
+		{"nothing weighted", func(*GameState, Move) float64 { return -1 }, 1.0 / 12},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gs := mustFEN(t, fen)
+			if n := len(gs.LegalMoves()); n != 12 {
+				t.Fatalf("%d legal moves, want 12", n)
+			}
+			rng := rand.New(rand.NewSource(1))
+			const draws = 20000
+			captures := 0
+			for i := 0; i < draws; i++ {
+				move, ok := gs.RandomMove(rng, tt.weight)
+				if !ok {
+					t.Fatal("RandomMove found no move")
+				}
+				if move.String() == capture {
+					captures++
+				}
+			}
+			if share := float64(captures) / draws; share < tt.share-0.02 || share > tt.share+0.02 {
+				t.Errorf("capture played %.3f of the time, want %.3f", share, tt.share)
+			}
+		})
+	}
+}
+
+func TestRandomMoveNone(t *testing.T) {
+	gs := mustFEN(t, "7k/5Q2/6K1/8/8/8/8/8 b - - 0 1")
+	if move, ok := gs.RandomMove(rand.New(rand.NewSource(1)), nil); ok {
+		t.Errorf("RandomMove = %v in stalemate", move)
+	}
+	if gs.ExecuteRandomMove(rand.New(rand.NewSource(1)), nil) {
+		t.Error("ExecuteRandomMove played in stalemate")
+	}
+}
+
+func TestRandomMoveSharedSource(t *testing.T) {
+	// without a source of its own, the move is drawn from the shared source of math/rand
+	for _, weight := range []MoveWeight{nil, PreferCaptures(5)} {
+		gs := NewGame()
+		for ply := 0; ply < 20; ply++ {
+			move, ok := gs.RandomMove(nil, weight)
+			if !ok {
+				t.Fatalf("no move after %v", gs.Moves())
+			}
+			if err := gs.Play(move); err != nil {
+				t.Fatalf("after %v: %v", gs.Moves(), err)
+			}
+		}
+		if !gs.ExecuteRandomMove(nil, weight) {
+			t.Errorf("ExecuteRandomMove(nil) played no move after %v", gs.Moves())
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package game
+
+// GameResult is the outcome of a game
+type GameResult int
+
+const (
+	Unfinished GameResult = iota
+	WhiteWon
+	BlackWon
+	Drawn
+)
+
+// String returns the result as written in PGN
+func (r GameResult) String() string {
+	return [...]string{"*", "1-0", "0-1", "1/2-1/2"}[r]
+}
+
+// LegalMoves returns the legal moves of the current player
+func (gs *GameState) LegalMoves() []Move {
+	var moves MoveList
+	gs.legalMoves(&moves)
+	return append([]Move(nil), moves.Moves()...)
+}
+
+// InCheck returns true if the current player is in check
+func (gs *GameState) InCheck() bool {
+	return gs.inCheck(gs.currColor)
+}
+
+// Result returns the result of the game by the rules, and the reason it ended
+func (gs *GameState) Result() (GameResult, string) {
+	var moves MoveList
+	gs.legalMoves(&moves)
+	switch {
+	case moves.Len() == 0 && gs.inCheck(gs.currColor):
+		if gs.currColor == White {
+			return BlackWon, "checkmate"
+		}
+		return WhiteWon, "checkmate"
+	case moves.Len() == 0:
+		return Drawn, "stalemate"
+	case gs.halfMoveClock >= 100:
+		return Drawn, "fifty move rule"
+	case gs.Repetitions() >= 3:
+		return Drawn, "threefold repetition"
+	case gs.insufficientMaterial():
+		return Drawn, "insufficient material"
+	}
+	return Unfinished, ""
+}
+
+// Repetitions returns how many times the current position has occurred in the game, counting itself. Only the
+// positions since the last capture or pawn move can repeat it
+func (gs *GameState) Repetitions() int {
+	count := 1
+	for i := len(gs.history) - 2; i >= 0 && i >= len(gs.history)-gs.halfMoveClock; i -= 2 {
+		if gs.history[i].hash == gs.hash {
+			count++
+		}
+	}
+	return count
+}
+
+// insufficientMaterial returns true if neither player can ever mate: bare kings, a single minor piece, or bishops
+// all on squares of the same color
+func (gs *GameState) insufficientMaterial() bool {
+	for c := range gs.pieces {
+		if gs.pieces[c][Queen]|gs.pieces[c][Rook]|gs.pieces[c][Pawn] != 0 {
+			return false
+		}
+	}
+
+	knights := gs.pieces[0][Knight] | gs.pieces[1][Knight]
+	bishops := gs.pieces[0][Bishop] | gs.pieces[1][Bishop]
+	switch {
+	case (knights | bishops).count() <= 1:
+		return true
+	case knights == 0:
+		const lightSquares Bitboard = 0x55aa55aa55aa55aa
+		return bishops&lightSquares == 0 || bishops&^lightSquares == 0
+	}
+	return false
+}
@@ -0,0 +1,147 @@
+package game
+
+import (
+	"fmt"
+	"strings"
+)
+
+// sanPieces are the letters of the piece types in standard algebraic notation, pawns having none
+var sanPieces = [6]string{King: "K", Queen: "Q", Rook: "R", Bishop: "B", Knight: "N", Pawn: ""}
+
+// SAN returns the legal move of the current player in standard algebraic notation, e.g. Nf3, exd5, O-O or e8=Q+
+func (gs *GameState) SAN(move Move) string {
+	var s string
+	switch move.MoveType {
+	case WhiteKingSideCastle, BlackKingSideCastle:
+		s = "O-O"
+	case WhiteQueenSideCastle, BlackQueenSideCastle:
+		s = "O-O-O"
+	default:
+		piece := gs.board[move.Origin]
+		capture := gs.board[move.Destination] != nil || move.MoveType == EnPassantAttack
+
+		// 1. the piece, told apart from the others of its type that can go to the same square
+		if piece.Type == Pawn {
+			if capture {
+				s = SquareName(move.Origin)[:1]
+			}
+		} else {
+			s = sanPieces[piece.Type]
+			sameFile, sameRank, ambiguous := false, false, false
+			for _, other := range gs.LegalMoves() {
+				if other.Destination != move.Destination || other.Origin == move.Origin ||
+					gs.board[other.Origin].Type != piece.Type {
+					continue
+				}
+				ambiguous = true
+				sameFile = sameFile || other.Origin%12 == move.Origin%12
+				sameRank = sameRank || other.Origin/12 == move.Origin/12
+			}
+			switch {
+			case ambiguous && !sameFile:
+				s += SquareName(move.Origin)[:1]
+			case ambiguous && !sameRank:
+				s += SquareName(move.Origin)[1:]
+			case ambiguous:
+				s += SquareName(move.Origin)
+			}
+		}
+
+		// 2. the capture, the destination and the promotion
+		if capture {
+			s += "x"
+		}
+		s += SquareName(move.Destination)
+		if promotion := move.MoveType.promotion(); promotion != Pawn {
+			s += "=" + sanPieces[promotion]
+		}
+	}
+
+	// 3. check and mate
+	gs.executeMove(move.Origin, move.Destination, move.MoveType)
+	if gs.inCheck(gs.currColor) {
+		var replies MoveList
+		gs.legalMoves(&replies)
+		if replies.Len() == 0 {
+			s += "#"
+		} else {
+			s += "+"
+		}
+	}
+	gs.Undo()
+
+	return s
+}
+
+// ParseSAN returns the legal move of the current player given in standard algebraic notation. Check and annotation
+// marks, the capture sign and the = of promotions are optional, and castling may be written with zeros
+func (gs *GameState) ParseSAN(s string) (Move, error) {
+	san := strings.TrimRight(s, "+#!?")
+	san = strings.ReplaceAll(san, "0", "O")
+
+	// 1. castling
+	if san == "O-O" || san == "O-O-O" {
+		for _, move := range gs.LegalMoves() {
+			switch move.MoveType {
+			case WhiteKingSideCastle, BlackKingSideCastle:
+				if san == "O-O" {
+					return move, nil
+				}
+			case WhiteQueenSideCastle, BlackQueenSideCastle:
+				if san == "O-O-O" {
+					return move, nil
+				}
+			}
+		}
+		return Move{}, fmt.Errorf("move %q: castling is not legal", s)
+	}
+
+	// 2. the piece, the promotion and the destination, leaving the squares the piece may come from
+	pieceType := Pawn
+	if len(san) > 0 {
+		if i := strings.IndexByte("KQRBN", san[0]); i >= 0 {
+			pieceType = Type(i)
+			san = san[1:]
+		}
+	}
+	promotion := Pawn
+	if n := len(san); n > 0 {
+		if i := strings.IndexByte("QRBN", strings.ToUpper(san[n-1:])[0]); i >= 0 {
+			promotion = Queen + Type(i)
+			san = strings.TrimSuffix(san[:n-1], "=")
+		}
+	}
+	san = strings.NewReplacer("x", "", "-", "", ":", "").Replace(san)
+	if len(san) < 2 {
+		return Move{}, fmt.Errorf("move %q: not in algebraic notation", s)
+	}
+	destination, ok := parseSquare(san[len(san)-2:])
+	if !ok {
+		return Move{}, fmt.Errorf("move %q: not in algebraic notation", s)
+	}
+	from := san[:len(san)-2]
+
+	// 3. the one legal move matching them, the file or rank given narrowing down where it comes from
+	var found []Move
+	for _, move := range gs.LegalMoves() {
+		if move.Destination != destination || gs.board[move.Origin].Type != pieceType ||
+			move.MoveType.promotion() != promotion {
+			continue
+		}
+		name := SquareName(move.Origin)
+		matches := true
+		for _, c := range from {
+			matches = matches && strings.ContainsRune(name, c)
+		}
+		if matches {
+			found = append(found, move)
+		}
+	}
+	switch len(found) {
+	case 0:
+		return Move{}, fmt.Errorf("move %q: not a legal move", s)
+	case 1:
+		return found[0], nil
+	}
+	return Move{}, fmt.Errorf("move %q: ambiguous", s)
+}
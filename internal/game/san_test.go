@@ -0,0 +1,93 @@
+package game
+
+import "testing"
+
+func TestSAN(t *testing.T) {
+	tests := []struct {
+		name string
+		fen  string
+		move string
+		san  string
+	}{
+		{"pawn", StartFEN, "e2e4", "e4"},
+		{"knight", StartFEN, "g1f3", "Nf3"},
+		{"pawn capture", "rnbqkbnr/ppp1pppp/8/3p4/4P3/8/PPPP1PPP/RNBQKBNR w KQkq d6 0 2", "e4d5", "exd5"},
+		{"en passant", "rnbqkbnr/ppp1p1pp/8/3pPp2/8/8/PPPP1PPP/RNBQKBNR w KQkq f6 0 3", "e5f6", "exf6"},
+		{"file told apart", "4k3/8/8/8/8/8/8/R5RK w - - 0 1", "a1d1", "Rad1"},
+		{"rank told apart", "4k3/8/8/R7/8/8/8/R6K w - - 0 1", "a1a3", "R1a3"},
+		{"square told apart", "4k3/8/8/8/8/Q7/8/Q1Q4K w - - 0 1", "a1b2", "Qa1b2"},
+		{"pinned piece needs no telling apart", "4k3/8/8/8/8/8/5N2/r1N1K3 w - - 0 1", "f2d3", "Nd3"},
+		{"king side castling", "r3k2r/8/8/8/8/8/8/R3K2R w KQkq - 0 1", "e1g1", "O-O"},
+		{"queen side castling", "r3k2r/8/8/8/8/8/8/R3K2R b KQkq - 0 1", "e8c8", "O-O-O"},
+		{"promotion with check", "k7/4P3/8/8/8/8/8/4K3 w - - 0 1", "e7e8q", "e8=Q+"},
+		{"underpromotion", "k7/4P3/8/8/8/8/8/4K3 w - - 0 1", "e7e8n", "e8=N"},
+		{"capture with check", "4k3/8/8/4q3/8/8/8/4RK2 w - - 0 1", "e1e5", "Rxe5+"},
+		{"mate", "6k1/5ppp/8/8/8/8/8/R5K1 w - - 0 1", "a1a8", "Ra8#"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gs := mustFEN(t, tt.fen)
+			move, err := gs.ParseMove(tt.move)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := gs.SAN(move); got != tt.san {
+				t.Errorf("SAN(%s) = %s, want %s", tt.move, got, tt.san)
+			}
+			if got, err := gs.ParseSAN(tt.san); err != nil || got != move {
+				t.Errorf("ParseSAN(%s) = %v, %v, want %s", tt.san, got, err, tt.move)
+			}
+		})
+	}
+}
+
+func TestParseSAN(t *testing.T) {
+	tests := []struct {
+		name    string
+		fen     string
+		san     string
+		move    string
+		wantErr bool
+	}{
+		{"annotation marks", StartFEN, "Nf3!?", "g1f3", false},
+		{"castling with zeros", "r3k2r/8/8/8/8/8/8/R3K2R w KQkq - 0 1", "0-0-0", "e1c1", false},
+		{"promotion without =", "k7/4P3/8/8/8/8/8/4K3 w - - 0 1", "e8Q", "e7e8q", false},
+		{"capture without x", "rnbqkbnr/ppp1pppp/8/3p4/4P3/8/PPPP1PPP/RNBQKBNR w KQkq d6 0 2", "ed5", "e4d5", false},
+		{"more than needed to tell apart", StartFEN, "Ng1f3", "g1f3", false},
+		{"illegal", StartFEN, "Nf4", "", true},
+		{"ambiguous", "4k3/8/8/8/8/8/8/R5RK w - - 0 1", "Rd1", "", true},
+		{"castling through check", "4k3/8/8/8/8/8/5r2/4K2R w K - 0 1", "O-O", "", true},
+		{"not a move", StartFEN, "hello", "", true},
+		{"empty", StartFEN, "", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			move, err := mustFEN(t, tt.fen).ParseSAN(tt.san)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseSAN(%q) = %v, error %v, want error %v", tt.san, move, err, tt.wantErr)
+			}
+			if err == nil && move.String() != tt.move {
+				t.Errorf("ParseSAN(%q) = %v, want %s", tt.san, move, tt.move)
+			}
+		})
+	}
+}
+
+// every legal move reads back from its SAN
+func TestSANRoundTrip(t *testing.T) {
+	fens := []string{StartFEN, "4k3/8/8/8/8/Q7/8/Q1Q4K w - - 0 1"}
+	for _, p := range PerftSuite {
+		fens = append(fens, p.FEN)
+	}
+	for _, fen := range fens {
+		gs := mustFEN(t, fen)
+		for _, move := range gs.LegalMoves() {
+			san := gs.SAN(move)
+			if got, err := gs.ParseSAN(san); err != nil || got != move {
+				t.Errorf("%s: ParseSAN(SAN(%v)) = ParseSAN(%s) = %v, %v", fen, move, san, got, err)
+			}
+		}
+	}
+}
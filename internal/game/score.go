@@ -0,0 +1,76 @@
+package game
+
+import "fmt"
+
+// Scores are integers in centipawns. Beyond any evaluation lies a range reserved for the outcomes the search can
+// prove: mates, scored by how far they are from the root, and below them the tablebase wins whose distance to mate
+// is not known, scored the same way so that the quickest one is preferred
+const (
+	// MateScore is the score of giving mate at the root. A mate n plies from the root scores MateScore-n
+	MateScore = 32000
+
+	// scores at least mateBound from 0 are mates
+	mateBound = MateScore - 1000
+
+	// tablebaseWin is the score of reaching a tablebase win at the root, n plies later scoring tablebaseWin-n
+	tablebaseWin = mateBound - 1
+
+	// scores at least tablebaseBound from 0 are mates or tablebase wins
+	tablebaseBound = tablebaseWin - 1000
+
+	// infinity is beyond any score, as the bounds of a search with a full window
+	infinity = MateScore + 1
+)
+
+// MateIn returns the score of giving mate in the given number of moves from the root, or of being mated in as many
+// moves when negative
+func MateIn(moves int) int {
+	if moves > 0 {
+		return MateScore - (2*moves - 1)
+	}
+	return -MateScore + 2*-moves
+}
+
+// MateMoves returns the number of moves to the mate the score stands for, negative when it is the player being
+// mated, and false if the score is not a mate
+func MateMoves(score int) (int, bool) {
+	switch {
+	case score >= mateBound:
+		return (MateScore - score + 1) / 2, true
+	case score <= -mateBound:
+		return -(MateScore + score) / 2, true
+	}
+	return 0, false
+}
+
+// FormatScore returns the score as people read it: in pawns with the sign of the side it favours, e.g. +1.25, or
+// as the moves to mate, e.g. #3 or #-2
+func FormatScore(score int) string {
+	if moves, ok := MateMoves(score); ok {
+		return fmt.Sprintf("#%d", moves)
+	}
+	return fmt.Sprintf("%+.2f", float64(score)/100)
+}
+
+// scoreToTT returns the score as stored in the transposition table, where mates and tablebase wins are counted from
+// the position itself rather than from the root, so that the entry holds wherever the position is reached again
+func scoreToTT(score, ply int) int {
+	switch {
+	case score >= tablebaseBound:
+		return score + ply
+	case score <= -tablebaseBound:
+		return score - ply
+	}
+	return score
+}
+
+// scoreFromTT returns a score read from the transposition table as seen from the root, ply plies above the position
+func scoreFromTT(score, ply int) int {
+	switch {
+	case score >= tablebaseBound:
+		return score - ply
+	case score <= -tablebaseBound:
+		return score + ply
+	}
+	return score
+}
@@ -0,0 +1,114 @@
+package game
+
+import "testing"
+
+func TestMateIn(t *testing.T) {
+	tests := []struct {
+		moves int
+		score int
+	}{
+		{1, MateScore - 1},
+		{2, MateScore - 3},
+		{10, MateScore - 19},
+		{-1, -MateScore + 2},
+		{-3, -MateScore + 6},
+	}
+	for _, tt := range tests {
+		if got := MateIn(tt.moves); got != tt.score {
+			t.Errorf("MateIn(%d) = %d, want %d", tt.moves, got, tt.score)
+		}
+	}
+
+	for moves := -100; moves <= 100; moves++ {
+		if moves == 0 {
+			continue
+		}
+		if got, ok := MateMoves(MateIn(moves)); !ok || got != moves {
+			t.Errorf("MateMoves(MateIn(%d)) = %d, %v", moves, got, ok)
+		}
+	}
+}
+
+func TestMateMoves(t *testing.T) {
+	tests := []struct {
+		score int
+		moves int
+		ok    bool
+	}{
+		{MateScore, 0, true},
+		{MateScore - 1, 1, true},
+		{MateScore - 2, 1, true}, // the mated side's reply does not count as a move
+		{-MateScore, 0, true},
+		{-MateScore + 1, 0, true},
+		{0, 0, false},
+		{2500, 0, false},
+		{-tablebaseWin, 0, false},
+	}
+	for _, tt := range tests {
+		if moves, ok := MateMoves(tt.score); moves != tt.moves || ok != tt.ok {
+			t.Errorf("MateMoves(%d) = %d, %v, want %d, %v", tt.score, moves, ok, tt.moves, tt.ok)
+		}
+	}
+}
+
+func TestFormatScore(t *testing.T) {
+	tests := []struct {
+		score int
+		want  string
+	}{
+		{0, "+0.00"},
+		{125, "+1.25"},
+		{-40, "-0.40"},
+		{MateIn(3), "#3"},
+		{MateIn(-2), "#-2"},
+		{tablebaseWin - 5, "+309.94"},
+	}
+	for _, tt := range tests {
+		if got := FormatScore(tt.score); got != tt.want {
+			t.Errorf("FormatScore(%d) = %q, want %q", tt.score, got, tt.want)
+		}
+	}
+}
+
+func TestScoreTT(t *testing.T) {
+	tests := []struct {
+		score, ply, stored int
+	}{
+		{150, 7, 150},
+		{-150, 7, -150},
+		{MateIn(3), 2, MateIn(3) + 2},
+		{MateIn(-3), 2, MateIn(-3) - 2},
+		{tablebaseWin - 10, 4, tablebaseWin - 6},
+	}
+	for _, tt := range tests {
+		stored := scoreToTT(tt.score, tt.ply)
+		if stored != tt.stored {
+			t.Errorf("scoreToTT(%d, %d) = %d, want %d", tt.score, tt.ply, stored, tt.stored)
+		}
+		if got := scoreFromTT(stored, tt.ply); got != tt.score {
+			t.Errorf("scoreFromTT(%d, %d) = %d, want %d", stored, tt.ply, got, tt.score)
+		}
+	}
+}
+
+// a mate found by the search is scored by its distance from the root, for either player to move
+func TestSearchMateScore(t *testing.T) {
+	tests := []struct {
+		fen   string
+		moves int
+	}{
+		{"6k1/5ppp/8/8/8/8/8/R5K1 w - - 0 1", 1},
+		{"r5k1/8/8/8/8/8/5PPP/6K1 b - - 0 1", 1},
+		{"7k/8/8/8/8/8/R7/1R4K1 w - - 0 1", 2},
+		{"1r4k1/8/8/8/8/8/r7/6K1 w - - 0 1", -1},
+	}
+	for _, tt := range tests {
+		line, ok := mustFEN(t, tt.fen).Search(Limits{Depth: 4})
+		if !ok {
+			t.Fatalf("%s: no move found", tt.fen)
+		}
+		if line.Score != MateIn(tt.moves) {
+			t.Errorf("%s: score %s, want %s", tt.fen, FormatScore(line.Score), FormatScore(MateIn(tt.moves)))
+		}
+	}
+}
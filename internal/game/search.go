@@ -0,0 +1,302 @@
+package game
+
+import (
+	"math/rand"
+	"sort"
+	"time"
+)
+
+// Line is a root move ranked by the search along with its score in centipawns and principal variation
+type Line struct {
+	Move  Move
+	Score int
+	PV    []Move
+}
+
+// MultiPV searches every root move to the given depth and returns the best k distinct moves ranked from best to worst,
+// all of them when k is 0 or less. Scores are from the perspective of the current player and each PV starts with its
+// root move. Only the best move is searched for when k is 1, which the window of the search narrows down to much
+// faster
+func (gs *GameState) MultiPV(depth int8, k int) []Line {
+	if k == 1 {
+		line, ok := gs.bestLine(depth, Move{})
+		if !ok {
+			return nil
+		}
+		return []Line{line}
+	}
+
+	var moves MoveList
+	gs.legalMoves(&moves)
+
+	lines := make([]Line, 0, moves.Len())
+	for _, move := range moves.Moves() {
+		gs.executeMove(move.Origin, move.Destination, move.MoveType)
+		score := gs.alphaBeta(depth, 1, -infinity, infinity)
+		gs.Undo()
+
+		pv := append([]Move{move}, gs.pvTable[1][:gs.pvLength[1]]...)
+		lines = append(lines, Line{Move: move, Score: score * int(gs.currColor), PV: pv})
+	}
+
+	// rank the lines from best to worst, keeping the generation order for ties
+	sort.SliceStable(lines, func(i, j int) bool {
+		return lines[i].Score > lines[j].Score
+	})
+
+	if k <= 0 || k > len(lines) {
+		k = len(lines)
+	}
+	return lines[:k]
+}
+
+// bestLine searches the root moves to the given depth, the given move first and then the others by the material
+// they win, and returns the best of them. Each move after the first only has to be proven no better than the best so
+// far, so the search window narrows as it goes and the other moves are left without an exact score
+func (gs *GameState) bestLine(depth int8, first Move) (Line, bool) {
+	var moves MoveList
+	gs.legalMoves(&moves)
+	gs.orderMoves(&moves)
+	moves.moveToFront(first)
+
+	color := gs.currColor
+	a, b := -infinity, infinity
+	var best Line
+	found := false
+	for _, move := range moves.Moves() {
+		gs.executeMove(move.Origin, move.Destination, move.MoveType)
+		score := gs.alphaBeta(depth, 1, a, b)
+		gs.Undo()
+
+		if found && score*int(color) <= best.Score {
+			continue
+		}
+		pv := append([]Move{move}, gs.pvTable[1][:gs.pvLength[1]]...)
+		best, found = Line{Move: move, Score: score * int(color), PV: pv}, true
+		if color == White {
+			a = score
+		} else {
+			b = score
+		}
+	}
+	return best, found
+}
+
+// Limits bound a search by its depth, as given to MultiPV, its node budget and its time. Zero values mean no limit,
+// and without any limit a single iteration of depth 0 is searched
+type Limits struct {
+	Depth int8
+	Nodes int
+	Time  time.Duration
+
+	// Infinite searches until Stop is closed
+	Infinite bool
+
+	// Stop ends the search as soon as possible once closed, e.g. on a GUI's stop command. A search given a closed
+	// channel still completes its first iteration
+	Stop <-chan struct{}
+
+	// Book picks the move for the first BookDepth full moves of a game, or for as long as it has moves if BookDepth
+	// is 0. Rand draws the move, the shared source of math/rand when nil
+	Book      *Book
+	BookDepth int
+	Rand      *rand.Rand
+}
+
+// Search deepens the search one ply at a time until a limit is reached, and returns the best line of the deepest
+// iteration completed. In the opening the book picks the move instead, and so do the tablebases with few enough
+// pieces left
+func (gs *GameState) Search(limits Limits) (Line, bool) {
+	defer func() {
+		gs.maxNodes, gs.deadline, gs.stopped, gs.stopSignal = 0, time.Time{}, false, nil
+	}()
+
+	if move, ok := gs.BookMove(limits); ok {
+		return Line{Move: move, PV: []Move{move}}, true
+	}
+	if move, wdl, plies, ok := gs.DTMMove(); ok {
+		return Line{Move: move, Score: (MateScore - plies) * sign(int(wdl)), PV: []Move{move}}, true
+	}
+	if move, wdl, ok := gs.ProbeRoot(); ok {
+		score := 0
+		switch wdl {
+		case Win:
+			score = tablebaseWin - 1
+		case Loss:
+			score = -tablebaseWin + 1
+		}
+		return Line{Move: move, Score: score, PV: []Move{move}}, true
+	}
+
+	gs.nodes, gs.maxNodes, gs.stopped, gs.stopSignal = 0, limits.Nodes, false, limits.Stop
+	gs.historyTable = [2][64][64]int{}
+	if limits.Time > 0 {
+		gs.deadline = time.Now().Add(limits.Time)
+	}
+
+	maxDepth := limits.Depth
+	if maxDepth == 0 && (limits.Nodes > 0 || limits.Time > 0 || limits.Infinite) {
+		maxDepth = maxPly - 2
+	}
+
+	// each iteration starts from the best move of the one before
+	var best Line
+	found := false
+	for depth := int8(0); depth <= maxDepth; depth++ {
+		line, ok := gs.bestLine(depth, best.Move)
+		if !ok {
+			break
+		}
+
+		// an iteration cut short is only trusted when there is nothing better
+		stopped := gs.stopped || (gs.maxNodes > 0 && gs.nodes >= gs.maxNodes)
+		if stopped && found {
+			break
+		}
+		best, found = line, true
+		if stopped {
+			break
+		}
+	}
+	return best, found
+}
+
+// quiesce searches only captures and promotions until the position is quiet, so that the evaluation is never taken
+// in the middle of an exchange. Either player may stand pat on the evaluation instead, and captures that lose
+// material according to SEE are pruned
+func (gs *GameState) quiesce(ply int, a, b int) int {
+	gs.nodes++
+	gs.pvLength[ply] = 0
+
+	value := gs.evaluate()
+	if ply == maxPly-1 || gs.outOfBudget() {
+		return value
+	}
+
+	// 1. stand pat
+	if gs.currColor == White {
+		if value >= b {
+			return value
+		}
+		a = max(a, value)
+	} else {
+		if value <= a {
+			return value
+		}
+		b = min(b, value)
+	}
+
+	// 2. the winning and even captures, best first
+	var moves MoveList
+	gs.generateMoves(&moves)
+	gs.keepCaptures(&moves)
+	scores := gs.orderMoves(&moves)
+
+	for i, move := range moves.Moves() {
+		if scores[i] < 0 {
+			break
+		}
+
+		gs.executeMove(move.Origin, move.Destination, move.MoveType)
+		if gs.inCheck(-gs.currColor) {
+			gs.Undo()
+			continue
+		}
+		score := gs.quiesce(ply+1, a, b)
+		gs.Undo()
+
+		if gs.currColor == White {
+			if score > value {
+				value = score
+				gs.updatePV(ply, move)
+			}
+			if value >= b {
+				return value
+			}
+			a = max(a, value)
+		} else {
+			if score < value {
+				value = score
+				gs.updatePV(ply, move)
+			}
+			if value <= a {
+				return value
+			}
+			b = min(b, value)
+		}
+	}
+
+	return value
+}
+
+// isCapture returns true if the move captures a piece or promotes a pawn
+func (gs *GameState) isCapture(move Move) bool {
+	return gs.board[move.Destination] != nil || move.MoveType == EnPassantAttack || move.MoveType.promotion() != Pawn
+}
+
+// keepCaptures removes every move from the list except captures and promotions
+func (gs *GameState) keepCaptures(list *MoveList) {
+	n := 0
+	for _, move := range list.Moves() {
+		if gs.isCapture(move) {
+			list.moves[n] = move
+			n++
+		}
+	}
+	list.count = n
+}
+
+// orderMoves sorts the list so that captures winning or trading material by SEE come first, the most winning
+// first, then quiet moves by their history and how much they improve the square of their piece, then captures that
+// lose material. It returns the SEE of each move in the sorted list, 0 for quiet moves
+func (gs *GameState) orderMoves(list *MoveList) [maxMoves]int {
+	var scores, keys [maxMoves]int
+	moves := list.Moves()
+	for i, move := range moves {
+		if !gs.isCapture(move) {
+			keys[i] = gs.quietOrder(move)
+			continue
+		}
+		scores[i] = gs.SEE(move)
+		if scores[i] >= 0 {
+			keys[i] = captureOrder + scores[i]
+		} else {
+			keys[i] = -captureOrder + scores[i]
+		}
+	}
+
+	// insertion sort keeps the generation order among equal moves and needs no allocation
+	for i := 1; i < len(moves); i++ {
+		move, score, key := moves[i], scores[i], keys[i]
+		j := i
+		for ; j > 0 && keys[j-1] < key; j-- {
+			moves[j], scores[j], keys[j] = moves[j-1], scores[j-1], keys[j-1]
+		}
+		moves[j], scores[j], keys[j] = move, score, key
+	}
+
+	return scores
+}
+
+// captureOrder sets the captures apart from the quiet moves when ordering moves, above or below them
+const captureOrder = 1 << 30
+
+// quietOrder returns the key a quiet move is ordered by: how deep the search has found it refuting other moves in
+// the same position, then how much better its piece stands on its destination by the piece-square tables
+func (gs *GameState) quietOrder(move Move) int {
+	piece := gs.board[move.Origin]
+	origin, destination := to64[move.Origin], to64[move.Destination]
+	pst := &gs.params.PST[piece.Type]
+	improvement := pst[pstIndex(piece.Color, destination)] - pst[pstIndex(piece.Color, origin)]
+	return min(gs.historyTable[piece.Color.index()][origin][destination], captureOrder/2) + improvement
+}
+
+// updateHistory credits the move for refuting the move before it at the given depth, the deeper the more. Captures
+// are ordered by SEE instead and not credited
+func (gs *GameState) updateHistory(move Move, depth int8) {
+	if gs.isCapture(move) {
+		return
+	}
+	piece := gs.board[move.Origin]
+	gs.historyTable[piece.Color.index()][to64[move.Origin]][to64[move.Destination]] += int(depth) * int(depth)
+}
@@ -0,0 +1,214 @@
+package game
+
+import "testing"
+
+func TestMultiPV(t *testing.T) {
+	tests := []struct {
+		name string
+		fen  string
+	}{
+		{"start", StartFEN},
+		{"back rank mate", "6k1/5ppp/8/8/8/8/8/R5K1 w - - 0 1"},
+		{"black to move", "4k3/8/8/3q4/8/8/8/3RK3 b - - 0 1"},
+		{"captures", "r1bqkb1r/pppp1ppp/2n2n2/4p3/2B1P3/5N2/PPPP1PPP/RNBQK2R w KQkq - 4 4"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gs := mustFEN(t, tt.fen)
+			fen := gs.FEN()
+			legal := len(gs.LegalMoves())
+			lines := gs.MultiPV(2, 0)
+			if len(lines) != legal {
+				t.Fatalf("MultiPV(2, 0) returned %d lines, want all %d legal moves", len(lines), legal)
+			}
+
+			seen := make(map[Move]bool)
+			for i, line := range lines {
+				if seen[line.Move] {
+					t.Errorf("%v is ranked twice", line.Move)
+				}
+				seen[line.Move] = true
+				if i > 0 && line.Score > lines[i-1].Score {
+					t.Errorf("%v (%d) is ranked below %v (%d)", line.Move, line.Score, lines[i-1].Move, lines[i-1].Score)
+				}
+				if len(line.PV) == 0 || line.PV[0] != line.Move {
+					t.Errorf("the PV %v of %v does not start with it", line.PV, line.Move)
+				}
+
+				// the PV is a legal line from the position
+				played := 0
+				for _, move := range line.PV {
+					if err := gs.Play(move); err != nil {
+						t.Errorf("PV %v of %v: %v", line.PV, line.Move, err)
+						break
+					}
+					played++
+				}
+				for ; played > 0; played-- {
+					gs.Undo()
+				}
+				if gs.FEN() != fen {
+					t.Fatalf("undoing the PV %v left %s", line.PV, gs.FEN())
+				}
+			}
+
+			// the best line alone scores the same as the best of all lines
+			if best := gs.MultiPV(2, 1); len(best) != 1 || best[0].Score != lines[0].Score {
+				t.Errorf("MultiPV(2, 1) = %v, want the score %d of %v", best, lines[0].Score, lines[0].Move)
+			}
+		})
+	}
+}
+
+func TestMultiPVCount(t *testing.T) {
+	gs := NewGame()
+	legal := len(gs.LegalMoves())
+	tests := []struct {
+		k, want int
+	}{
+		{-1, legal},
+		{0, legal},
+		{1, 1},
+		{3, 3},
+		{legal, legal},
+		{legal + 10, legal},
+	}
+
+	for _, tt := range tests {
+		if got := len(gs.MultiPV(1, tt.k)); got != tt.want {
+			t.Errorf("MultiPV(1, %d) returned %d lines, want %d", tt.k, got, tt.want)
+		}
+	}
+
+	// without legal moves there is no line
+	if lines := mustFEN(t, "7k/5Q2/6K1/8/8/8/8/8 b - - 0 1").MultiPV(1, 1); lines != nil {
+		t.Errorf("MultiPV in stalemate = %v, want none", lines)
+	}
+}
+
+func TestBestLine(t *testing.T) {
+	tests := []struct {
+		name  string
+		fen   string
+		first string // searched first, whether or not it is best
+		want  string
+		score int
+	}{
+		{"mate in one", "6k1/5ppp/8/8/8/8/8/R5K1 w - - 0 1", "", "a1a8", MateIn(1)},
+		{"mate in one after a worse first move", "6k1/5ppp/8/8/8/8/8/R5K1 w - - 0 1", "a1a2", "a1a8", MateIn(1)},
+		{"black mates", "r5k1/8/8/8/8/8/5PPP/6K1 b - - 0 1", "g8f8", "a8a1", MateIn(1)},
+		{"winning a queen", "4k3/8/8/3q4/8/8/8/3RK3 w - - 0 1", "e1e2", "d1d5", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gs := mustFEN(t, tt.fen)
+			var first Move
+			if tt.first != "" {
+				var err error
+				if first, err = gs.ParseMove(tt.first); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			line, ok := gs.bestLine(2, first)
+			if !ok {
+				t.Fatal("no best line")
+			}
+			if line.Move.String() != tt.want {
+				t.Errorf("best move %v, want %s", line.Move, tt.want)
+			}
+			if len(line.PV) == 0 || line.PV[0] != line.Move {
+				t.Errorf("the PV %v does not start with %v", line.PV, line.Move)
+			}
+			if tt.score != 0 && line.Score != tt.score {
+				t.Errorf("scored %d, want %d", line.Score, tt.score)
+			}
+			if all := gs.MultiPV(2, 0); line.Score != all[0].Score {
+				t.Errorf("scored %d, the best of all lines %d", line.Score, all[0].Score)
+			}
+		})
+	}
+}
+
+func TestRepeatedSearchPV(t *testing.T) {
+	// searching again finds the positions after the root moves in the transposition table, whose PVs are rebuilt
+	gs := mustFEN(t, StartFEN)
+	for i := 0; i < 3; i++ {
+		for _, k := range []int{1, 0} {
+			for _, line := range gs.MultiPV(3, k) {
+				if len(line.PV) != 4 {
+					t.Errorf("search %d: MultiPV(3, %d) PV %v of %v, want 4 moves", i, k, line.PV, line.Move)
+				}
+				played := 0
+				for _, move := range line.PV {
+					if err := gs.Play(move); err != nil {
+						t.Errorf("search %d: PV %v: %v", i, line.PV, err)
+						break
+					}
+					played++
+				}
+				for ; played > 0; played-- {
+					gs.Undo()
+				}
+			}
+		}
+	}
+}
+
+func TestOrderMoves(t *testing.T) {
+	// the knight can take an undefended pawn on a4, and the knight or bishop a defended one on d5
+	gs := mustFEN(t, "4k3/8/2p5/3p4/p7/2N5/8/1R2K2B w - - 0 1")
+	var moves MoveList
+	gs.legalMoves(&moves)
+	scores := gs.orderMoves(&moves)
+
+	// good captures by SEE, then quiet moves, then losing captures
+	stage := func(i int, move Move) int {
+		switch {
+		case !gs.isCapture(move):
+			return 1
+		case scores[i] >= 0:
+			return 0
+		}
+		return 2
+	}
+	list := moves.Moves()
+	for i, move := range list {
+		if gs.isCapture(move) && scores[i] != gs.SEE(move) {
+			t.Errorf("%v scored %d, want its SEE %d", move, scores[i], gs.SEE(move))
+		}
+		if i == 0 {
+			continue
+		}
+		previous := list[i-1]
+		if stage(i, move) < stage(i-1, previous) ||
+			stage(i, move) != 1 && stage(i, move) == stage(i-1, previous) && scores[i] > scores[i-1] {
+			t.Errorf("%v (%d) is ordered after %v (%d)", move, scores[i], previous, scores[i-1])
+		}
+	}
+	if last := list[len(list)-1]; list[0].String() != "c3a4" || SquareName(last.Destination) != "d5" {
+		t.Errorf("ordered %v, want c3a4 first and a capture on d5 last", list)
+	}
+}
+
+// BenchmarkSearch searches the perft suite positions to depth 2, each from a fresh game. On the mailbox board the
+// bitboards replaced this searched about 14,600 nodes per second, and 1,370,000 once moves were generated from
+// bitboards, on the same machine
+func BenchmarkSearch(b *testing.B) {
+	nodes := 0
+	for i := 0; i < b.N; i++ {
+		for _, p := range PerftSuite {
+			b.StopTimer()
+			gs, err := NewGameFromFEN(p.FEN)
+			if err != nil {
+				b.Fatal(err)
+			}
+			b.StartTimer()
+			gs.MultiPV(2, 1)
+			nodes += gs.nodes
+		}
+	}
+	b.ReportMetric(float64(nodes)/b.Elapsed().Seconds(), "nodes/s")
+}
@@ -0,0 +1,84 @@
+package game
+
+// SEE returns the static exchange evaluation of the move: the material the current player wins on the destination
+// square once both players have traded off their cheapest attackers there, each free to stop capturing when it no
+// longer pays. Sliders hidden behind other attackers join the exchange as the pieces in front of them capture
+func (gs *GameState) SEE(move Move) int {
+	if move.MoveType >= WhiteKingSideCastle && move.MoveType <= BlackQueenSideCastle {
+		return 0
+	}
+
+	to := to64[move.Destination]
+	occupied := gs.colors[0] | gs.colors[1]
+	var gain [32]int
+
+	// 1. the move itself, including the pawn taken en passant and the piece promoted to
+	piece := gs.board[move.Origin]
+	value := seeValues[piece.Type]
+	if captured := gs.board[move.Destination]; captured != nil {
+		gain[0] = seeValues[captured.Type]
+	}
+	if move.MoveType == EnPassantAttack {
+		gain[0] = seeValues[Pawn]
+		occupied &^= bit(to64[move.Destination+12*int8(piece.Color)])
+	}
+	if promoted := move.MoveType.promotion(); promoted != Pawn {
+		value = seeValues[promoted]
+		gain[0] += value - seeValues[Pawn]
+	}
+
+	// 2. play out the exchange with the least valuable attacker of each side in turn
+	attackers := gs.attackersTo(to, White, occupied) | gs.attackersTo(to, Black, occupied)
+	from := bit(to64[move.Origin])
+	side := piece.Color
+	d := 0
+	for {
+		d++
+		gain[d] = value - gain[d-1]
+
+		if d == len(gain)-1 {
+			break
+		}
+
+		// remove the capturing piece and uncover any slider behind it
+		occupied &^= from
+		attackers &^= from
+		attackers |= (bishopAttacks(to, occupied)&gs.sliders(Bishop) | rookAttacks(to, occupied)&gs.sliders(Rook)) & occupied
+
+		side = -side
+		from, value = gs.leastValuableAttacker(attackers&gs.colors[side.index()], side)
+		if from == 0 {
+			break
+		}
+	}
+
+	// 3. each side picks the better of stopping or carrying on, from the end of the exchange back to the start
+	for d--; d > 0; d-- {
+		gain[d-1] = -max(-gain[d-1], gain[d])
+	}
+
+	return gain[0]
+}
+
+// sliders returns the pieces of both colors that slide like the given type, queens included
+func (gs *GameState) sliders(pieceType Type) Bitboard {
+	return gs.pieces[0][pieceType] | gs.pieces[1][pieceType] | gs.pieces[0][Queen] | gs.pieces[1][Queen]
+}
+
+// seeValues are the piece values of the exchanges, where the king counts for more than all the other pieces
+// together so that it only takes a defended piece last
+var seeValues = [6]int{King: 10000, Queen: pieceValues[Queen], Rook: pieceValues[Rook], Bishop: pieceValues[Bishop],
+	Knight: pieceValues[Knight], Pawn: pieceValues[Pawn]}
+
+// seeOrder lists the piece types from the least to the most valuable
+var seeOrder = [...]Type{Pawn, Knight, Bishop, Rook, Queen, King}
+
+// leastValuableAttacker returns the square and value of the cheapest of the given attackers of the color
+func (gs *GameState) leastValuableAttacker(attackers Bitboard, color Color) (Bitboard, int) {
+	for _, pieceType := range seeOrder {
+		if subset := attackers & gs.pieces[color.index()][pieceType]; subset != 0 {
+			return subset & -subset, seeValues[pieceType]
+		}
+	}
+	return 0, 0
+}
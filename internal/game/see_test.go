@@ -0,0 +1,63 @@
+package game
+
+import "testing"
+
+func TestSEE(t *testing.T) {
+	tests := []struct {
+		name string
+		fen  string
+		move string
+		want int
+	}{
+		{"undefended pawn", "1k1r4/1pp4p/p7/4p3/8/P5P1/1PP4P/2K1R3 w - - 0 1", "e1e5", 100},
+		{"pawn defended by a pawn", "4k3/8/3p4/4p3/8/8/8/4RK2 w - - 0 1", "e1e5", -400},
+		{"pawn takes a defended knight", "4k3/8/4p3/3n4/4P3/8/8/4K3 w - - 0 1", "e4d5", 200},
+		{"rook behind a rook", "4k3/4r3/8/4p3/8/8/4R3/4RK2 w - - 0 1", "e2e5", 100},
+		{"rook into a pawn's attack", "4k3/8/3p4/8/8/8/8/4RK2 w - - 0 1", "e1e5", -500},
+		{"quiet safe move", StartFEN, "g1f3", 0},
+		{"en passant", "4k3/8/8/3pP3/8/8/8/4K3 w - d6 0 1", "e5d6", 100},
+		{"promotion", "4k3/P7/8/8/8/8/8/4K3 w - - 0 1", "a7a8q", 800},
+		{"castling", "4k3/8/8/8/8/8/8/4K2R w K - 0 1", "e1g1", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gs := mustFEN(t, tt.fen)
+			move, err := gs.ParseMove(tt.move)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := gs.SEE(move); got != tt.want {
+				t.Errorf("SEE(%s) = %d, want %d", tt.move, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestQuiescence(t *testing.T) {
+	tests := []struct {
+		name  string
+		fen   string
+		avoid string // the capture a search without quiescence would play
+		play  string
+	}{
+		{"poisoned pawn", "4k3/8/3p4/4p3/8/8/8/4QK2 w - - 0 1", "e1e5", ""},
+		{"knight guarded by a pawn", "4k3/8/3p4/4n3/8/8/8/4RK2 w - - 0 1", "e1e5", ""},
+		{"free queen", "4k3/8/8/4q3/8/8/8/4RK2 w - - 0 1", "", "e1e5"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			line, ok := mustFEN(t, tt.fen).Search(Limits{Depth: 1})
+			if !ok {
+				t.Fatal("no move found")
+			}
+			if tt.avoid != "" && line.Move.String() == tt.avoid {
+				t.Errorf("Search played %s, which loses material", tt.avoid)
+			}
+			if tt.play != "" && line.Move.String() != tt.play {
+				t.Errorf("Search played %v, want %s", line.Move, tt.play)
+			}
+		})
+	}
+}
@@ -0,0 +1,122 @@
+package game
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+)
+
+// Skill describes how strongly the engine plays
+type Skill struct {
+	Level    int
+	Elo      int  // approximate playing strength of the level
+	Depth    int8 // search depth handed to the search
+	MaxNodes int  // node budget of the search, 0 means unlimited
+	Margin   int  // how many centipawns below the best move a move may score and still be played
+}
+
+// SkillLevels lists the available skill levels from weakest to strongest
+var SkillLevels = []Skill{
+	{Level: 0, Elo: 400, Depth: 0, MaxNodes: 100, Margin: 300},
+	{Level: 1, Elo: 550, Depth: 0, MaxNodes: 400, Margin: 200},
+	{Level: 2, Elo: 700, Depth: 1, MaxNodes: 1500, Margin: 150},
+	{Level: 3, Elo: 850, Depth: 1, MaxNodes: 4000, Margin: 100},
+	{Level: 4, Elo: 1000, Depth: 2, MaxNodes: 15000, Margin: 75},
+	{Level: 5, Elo: 1150, Depth: 2, MaxNodes: 40000, Margin: 50},
+	{Level: 6, Elo: 1300, Depth: 3, MaxNodes: 120000, Margin: 25},
+	{Level: 7, Elo: 1450, Depth: 3, MaxNodes: 0, Margin: 0},
+	{Level: 8, Elo: 1600, Depth: 4, MaxNodes: 0, Margin: 0},
+}
+
+// SkillForElo returns the skill level whose approximate Elo is closest to the given Elo
+func SkillForElo(elo int) Skill {
+	best := SkillLevels[0]
+	for _, skill := range SkillLevels[1:] {
+		if math.Abs(float64(skill.Elo-elo)) < math.Abs(float64(best.Elo-elo)) {
+			best = skill
+		}
+	}
+	return best
+}
+
+// SkillMove picks a move for the current player at the given skill level.
+// Moves scoring within the skill's margin of the best move are candidates, and one of them is drawn from rng,
+// favouring the moves closest to the best. The same seed and position yield the same choice, and a nil rng uses the
+// shared source of math/rand
+func (gs *GameState) SkillMove(skill Skill, rng *rand.Rand) (Move, bool) {
+	// 1. rank the root moves
+	lines := gs.skillLines(skill)
+	if len(lines) == 0 {
+		return Move{}, false
+	}
+
+	// 2. collect the near-best moves
+	var candidates []Line
+	for _, line := range lines {
+		if lines[0].Score-line.Score <= skill.Margin {
+			candidates = append(candidates, line)
+		}
+	}
+
+	// 3. order the candidates independently of move generation so the draw only depends on rng
+	sort.Slice(candidates, func(i, j int) bool {
+		a, b := candidates[i].Move, candidates[j].Move
+		if a.Origin != b.Origin {
+			return a.Origin < b.Origin
+		}
+		if a.Destination != b.Destination {
+			return a.Destination < b.Destination
+		}
+		return a.MoveType < b.MoveType
+	})
+
+	// 4. draw a candidate, weighting each by how close it is to the best score
+	var total float64
+	weights := make([]float64, len(candidates))
+	for i, line := range candidates {
+		weights[i] = float64(skill.Margin-(lines[0].Score-line.Score)) + 10
+		total += weights[i]
+	}
+
+	pick := randFloat64(rng) * total
+	for i, line := range candidates {
+		pick -= weights[i]
+		if pick < 0 {
+			return line.Move, true
+		}
+	}
+
+	return candidates[len(candidates)-1].Move, true
+}
+
+// skillLines searches every root move one ply deeper at a time, and ranks them by the deepest iteration that completed
+// within the node budget of the skill. The first iteration, captures only, is kept even when the budget cuts it short
+// so that there is always a move to play, and the weakest levels go no deeper
+func (gs *GameState) skillLines(skill Skill) []Line {
+	var lines []Line
+	gs.nodes, gs.maxNodes = 0, skill.MaxNodes
+	defer func() { gs.maxNodes = 0 }()
+
+	for depth := int8(0); depth <= skill.Depth; depth++ {
+		iteration := gs.MultiPV(depth, 0)
+		spent := gs.maxNodes > 0 && gs.nodes >= gs.maxNodes
+		if spent && lines != nil {
+			break
+		}
+		lines = iteration
+		if spent {
+			break
+		}
+	}
+	return lines
+}
+
+// ExecuteSkillMove plays a move for the current player at the given skill level
+func (gs *GameState) ExecuteSkillMove(skill Skill, rng *rand.Rand) bool {
+	move, ok := gs.SkillMove(skill, rng)
+	if !ok {
+		return false
+	}
+	gs.executeMove(move.Origin, move.Destination, move.MoveType)
+	return true
+}
@@ -0,0 +1,128 @@
+package game
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// skillFENs are positions with a choice of reasonable moves, for the skill levels to pick from. The levels without a
+// node budget are left to TestSkillMoveWinsQueen, as searching these fully takes too long
+var skillFENs = []string{
+	StartFEN,
+	"r1bqkb1r/pppp1ppp/2n2n2/4p3/2B1P3/5N2/PPPP1PPP/RNBQK2R w KQkq - 4 4",
+	"r2q1rk1/pp2bppp/2n1pn2/3p4/3P4/2NBPN2/PP3PPP/R2Q1RK1 b - - 0 10",
+}
+
+func TestSkillMoveSeeded(t *testing.T) {
+	for _, skill := range SkillLevels {
+		if skill.MaxNodes == 0 {
+			continue
+		}
+		for _, fen := range skillFENs {
+			for seed := int64(1); seed <= 3; seed++ {
+				a, okA := mustFEN(t, fen).SkillMove(skill, rand.New(rand.NewSource(seed)))
+				b, okB := mustFEN(t, fen).SkillMove(skill, rand.New(rand.NewSource(seed)))
+				if !okA || !okB || a != b {
+					t.Errorf("level %d, seed %d, %s: played %v and %v", skill.Level, seed, fen, a, b)
+				}
+			}
+		}
+	}
+}
+
+func TestSkillMoveMargin(t *testing.T) {
+	for _, skill := range SkillLevels {
+		if skill.MaxNodes == 0 {
+			continue
+		}
+		for _, fen := range skillFENs {
+			lines := mustFEN(t, fen).skillLines(skill)
+			scores := make(map[Move]int, len(lines))
+			for _, line := range lines {
+				scores[line.Move] = line.Score
+			}
+
+			for seed := int64(1); seed <= 10; seed++ {
+				move, ok := mustFEN(t, fen).SkillMove(skill, rand.New(rand.NewSource(seed)))
+				score, ranked := scores[move]
+				if !ok || !ranked {
+					t.Fatalf("level %d, seed %d, %s: played %v, which is not a root move", skill.Level, seed, fen, move)
+				}
+				if lines[0].Score-score > skill.Margin {
+					t.Errorf("level %d, seed %d, %s: played %v scoring %d, more than %d below the best %d",
+						skill.Level, seed, fen, move, score, skill.Margin, lines[0].Score)
+				}
+			}
+		}
+	}
+}
+
+func TestSkillMoveWinsQueen(t *testing.T) {
+	// every level takes a hanging queen, which leaves every other move far outside its margin
+	for _, skill := range SkillLevels {
+		for seed := int64(1); seed <= 5; seed++ {
+			gs := mustFEN(t, "4k3/8/8/3q4/8/8/8/3RK3 w - - 0 1")
+			if move, ok := gs.SkillMove(skill, rand.New(rand.NewSource(seed))); !ok || move.String() != "d1d5" {
+				t.Errorf("level %d, seed %d: played %v, want d1d5", skill.Level, seed, move)
+			}
+		}
+	}
+}
+
+func TestSkillNodeBudget(t *testing.T) {
+	for _, skill := range SkillLevels {
+		if skill.MaxNodes == 0 {
+			continue
+		}
+		gs := mustFEN(t, "r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1")
+		gs.SkillMove(skill, rand.New(rand.NewSource(1)))
+
+		// once the budget is spent, the searches under way only evaluate their remaining moves, at a node each
+		if limit := skill.MaxNodes + len(gs.LegalMoves())*(int(skill.Depth)+2); gs.nodes > limit {
+			t.Errorf("level %d searched %d nodes, over its budget of %d", skill.Level, gs.nodes, skill.MaxNodes)
+		}
+		if gs.maxNodes != 0 {
+			t.Errorf("level %d left a node budget of %d behind", skill.Level, gs.maxNodes)
+		}
+	}
+}
+
+func TestSkillForElo(t *testing.T) {
+	if got := SkillForElo(-1000).Level; got != 0 {
+		t.Errorf("SkillForElo(-1000) = level %d, want 0", got)
+	}
+	if got, want := SkillForElo(10000).Level, SkillLevels[len(SkillLevels)-1].Level; got != want {
+		t.Errorf("SkillForElo(10000) = level %d, want %d", got, want)
+	}
+
+	previous := SkillForElo(0)
+	for elo := 0; elo <= 3000; elo += 10 {
+		skill := SkillForElo(elo)
+		if skill.Level < previous.Level {
+			t.Fatalf("SkillForElo(%d) = level %d, below level %d for less", elo, skill.Level, previous.Level)
+		}
+		previous = skill
+	}
+
+	for _, skill := range SkillLevels {
+		if got := SkillForElo(skill.Elo); got != skill {
+			t.Errorf("SkillForElo(%d) = level %d, want %d", skill.Elo, got.Level, skill.Level)
+		}
+	}
+}
+
+func TestSkillMoveSharedSource(t *testing.T) {
+	// without a source of its own, the move is drawn from the shared source of math/rand
+	skill := SkillLevels[1]
+	gs := mustFEN(t, skillFENs[1])
+	move, ok := gs.SkillMove(skill, nil)
+	if !ok {
+		t.Fatal("SkillMove(nil) found no move")
+	}
+	if err := gs.Play(move); err != nil {
+		t.Fatalf("SkillMove(nil) = %v: %v", move, err)
+	}
+	if !gs.ExecuteSkillMove(skill, nil) {
+		t.Error("ExecuteSkillMove(nil) played no move")
+	}
+}
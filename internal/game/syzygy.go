@@ -0,0 +1,1154 @@
+package game
+
+import (
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Syzygy tablebase probing. The tables are read from a local directory of .rtbw (win/draw/loss) and .rtbz
+// (distance to zeroing) files, and decoded following the layout written by the Syzygy generator
+
+// WDL is the tablebase outcome of a position for the player to move
+type WDL int
+
+const (
+	Loss        WDL = -2
+	BlessedLoss WDL = -1 // lost, but drawn by the fifty move rule
+	Draw        WDL = 0
+	CursedWin   WDL = 1 // won, but drawn by the fifty move rule
+	Win         WDL = 2
+)
+
+func (w WDL) String() string {
+	switch w {
+	case Loss:
+		return "Loss"
+	case BlessedLoss:
+		return "Blessed Loss"
+	case CursedWin:
+		return "Cursed Win"
+	case Win:
+		return "Win"
+	}
+	return "Draw"
+}
+
+const (
+	tbPieces   = 7
+	tbWDLMagic = 0x5d23e871
+	tbDTZMagic = 0xa50c66d7
+)
+
+// flags of the pairs data
+const (
+	tbFlagSTM         = 1
+	tbFlagMapped      = 2
+	tbFlagWinPlies    = 4
+	tbFlagLossPlies   = 8
+	tbFlagWide        = 16
+	tbFlagSingleValue = 128
+)
+
+// pairsData describes the compressed values of one side, and one leading pawn file, of a table
+type pairsData struct {
+	flags           byte
+	pieces          [tbPieces]byte
+	groupLen        [tbPieces + 1]int
+	groupIdx        [tbPieces + 1]uint64
+	sizeofBlock     uint64
+	span            uint64
+	sparseIndexSize uint64
+	blocksNum       uint64
+	blockLengthSize uint64
+	maxSymLen       int
+	minSymLen       int // the value itself when the table stores a single value
+	lowestSym       int
+	base64          []uint64
+	symlen          []byte
+	btree           int
+	sparseIndex     int
+	blockLength     int
+	data            int
+	mapIdx          [4]uint16
+}
+
+// tbTable is one tablebase file, loaded on first use
+type tbTable struct {
+	path string
+	dtz  bool
+
+	// the material of each side, white first, as named by the file
+	material [2]string
+
+	pieceCount      int
+	hasPawns        bool
+	hasUniquePieces bool
+	pawnCount       [2]int // the leading color first
+
+	once   sync.Once
+	ok     bool
+	file   []byte
+	items  [2][4]pairsData
+	dtzMap int
+}
+
+// tablebases holds the tables found in the configured directory
+var tablebases struct {
+	sync.RWMutex
+	wdl       map[string]*tbTable
+	dtz       map[string]*tbTable
+	maxPieces int
+}
+
+// SetSyzygyPath configures the directory to read Syzygy tablebases from and returns the number of tables found.
+// An empty path disables probing
+func SetSyzygyPath(dir string) (int, error) {
+	wdl, dtz := map[string]*tbTable{}, map[string]*tbTable{}
+	maxPieces := 0
+
+	if dir != "" {
+		paths, err := filepath.Glob(filepath.Join(dir, "*.rtbw"))
+		if err != nil {
+			return 0, err
+		}
+		if len(paths) == 0 {
+			if _, err := os.Stat(dir); err != nil {
+				return 0, err
+			}
+		}
+		for _, path := range paths {
+			name := strings.TrimSuffix(filepath.Base(path), ".rtbw")
+			table, ok := newTBTable(strings.TrimSuffix(path, ".rtbw"), name)
+			if !ok {
+				continue
+			}
+			wdl[name] = table
+			maxPieces = max(maxPieces, table.pieceCount)
+
+			if _, err := os.Stat(table.path + ".rtbz"); err == nil {
+				dtzTable, _ := newTBTable(table.path, name)
+				dtzTable.dtz = true
+				dtz[name] = dtzTable
+			}
+		}
+	}
+
+	tablebases.Lock()
+	tablebases.wdl, tablebases.dtz, tablebases.maxPieces = wdl, dtz, maxPieces
+	tablebases.Unlock()
+
+	return len(wdl), nil
+}
+
+// newTBTable returns the table stored under path for the material named like KRPvKR
+func newTBTable(path, name string) (*tbTable, bool) {
+	sides := strings.Split(name, "v")
+	if len(sides) != 2 || len(name)-1 > tbPieces {
+		return nil, false
+	}
+	for _, side := range sides {
+		if strings.Count(side, "K") != 1 || strings.Trim(side, "KQRBNP") != "" {
+			return nil, false
+		}
+	}
+
+	table := &tbTable{path: path, material: [2]string{sides[0], sides[1]}, pieceCount: len(name) - 1}
+
+	whitePawns, blackPawns := strings.Count(sides[0], "P"), strings.Count(sides[1], "P")
+	table.hasPawns = whitePawns+blackPawns > 0
+	for _, side := range sides {
+		for _, c := range "QRBNP" {
+			if strings.Count(side, string(c)) == 1 {
+				table.hasUniquePieces = true
+			}
+		}
+	}
+
+	// the leading color is the one with fewer pawns, if both have pawns
+	if blackPawns == 0 || whitePawns > 0 && blackPawns >= whitePawns {
+		table.pawnCount = [2]int{whitePawns, blackPawns}
+	} else {
+		table.pawnCount = [2]int{blackPawns, whitePawns}
+	}
+
+	return table, true
+}
+
+// symmetric returns true if both sides have the same material, in which case only white to move is stored
+func (t *tbTable) symmetric() bool {
+	return t.material[0] == t.material[1]
+}
+
+func (t *tbTable) get(stm, file int) *pairsData {
+	sides := 1
+	if !t.dtz && !t.symmetric() {
+		sides = 2
+	}
+	if !t.hasPawns {
+		file = 0
+	}
+	return &t.items[stm%sides][file]
+}
+
+// load reads and parses the table file, once
+func (t *tbTable) load() bool {
+	t.once.Do(func() {
+		extension, magic := ".rtbw", uint32(tbWDLMagic)
+		if t.dtz {
+			extension, magic = ".rtbz", tbDTZMagic
+		}
+
+		file, err := os.ReadFile(t.path + extension)
+		if err != nil || len(file) < 16 || binary.LittleEndian.Uint32(file) != magic {
+			return
+		}
+		t.file = file
+
+		// a corrupt table reads out of bounds, which disables it rather than crashing the engine
+		defer func() {
+			if recover() != nil {
+				t.ok = false
+			}
+		}()
+		t.parse()
+		t.ok = true
+	})
+	return t.ok
+}
+
+// parse sets up the pairs data of every side and file from the table header
+func (t *tbTable) parse() {
+	data := t.file
+	pos := 4
+
+	// 1. the header flags, then the piece order and groups of each side and file
+	pos++
+	sides := 1
+	if !t.dtz && !t.symmetric() {
+		sides = 2
+	}
+	maxFile := 0
+	if t.hasPawns {
+		maxFile = 3
+	}
+	pp := t.hasPawns && t.pawnCount[1] > 0
+
+	for f := 0; f <= maxFile; f++ {
+		for i := 0; i < sides; i++ {
+			*t.get(i, f) = pairsData{}
+		}
+
+		order := [2][2]int{{int(data[pos] & 0xf), 0xf}, {int(data[pos] >> 4), 0xf}}
+		if pp {
+			order[0][1], order[1][1] = int(data[pos+1]&0xf), int(data[pos+1]>>4)
+			pos++
+		}
+		pos++
+
+		for k := 0; k < t.pieceCount; k, pos = k+1, pos+1 {
+			for i := 0; i < sides; i++ {
+				if i == 0 {
+					t.get(i, f).pieces[k] = data[pos] & 0xf
+				} else {
+					t.get(i, f).pieces[k] = data[pos] >> 4
+				}
+			}
+		}
+
+		for i := 0; i < sides; i++ {
+			t.setGroups(t.get(i, f), order[i], f)
+		}
+	}
+
+	// 2. the block sizes and symbol tables
+	pos += pos & 1
+	for f := 0; f <= maxFile; f++ {
+		for i := 0; i < sides; i++ {
+			pos = t.setSizes(t.get(i, f), pos)
+		}
+	}
+
+	// 3. the value maps of distance to zeroing tables
+	if t.dtz {
+		t.dtzMap = pos
+		for f := 0; f <= maxFile; f++ {
+			d := t.get(0, f)
+			if d.flags&tbFlagMapped == 0 {
+				continue
+			}
+			if d.flags&tbFlagWide != 0 {
+				pos += pos & 1
+				for i := 0; i < 4; i++ {
+					d.mapIdx[i] = uint16((pos-t.dtzMap)/2 + 1)
+					pos += 2*int(binary.LittleEndian.Uint16(data[pos:])) + 2
+				}
+			} else {
+				for i := 0; i < 4; i++ {
+					d.mapIdx[i] = uint16(pos - t.dtzMap + 1)
+					pos += int(data[pos]) + 1
+				}
+			}
+		}
+		pos += pos & 1
+	}
+
+	// 4. the sparse indexes, block lengths and finally the 64 byte aligned compressed blocks
+	for f := 0; f <= maxFile; f++ {
+		for i := 0; i < sides; i++ {
+			d := t.get(i, f)
+			d.sparseIndex = pos
+			pos += int(d.sparseIndexSize) * 6
+		}
+	}
+	for f := 0; f <= maxFile; f++ {
+		for i := 0; i < sides; i++ {
+			d := t.get(i, f)
+			d.blockLength = pos
+			pos += int(d.blockLengthSize) * 2
+		}
+	}
+	for f := 0; f <= maxFile; f++ {
+		for i := 0; i < sides; i++ {
+			d := t.get(i, f)
+			pos = (pos + 0x3f) &^ 0x3f
+			d.data = pos
+			pos += int(d.blocksNum * d.sizeofBlock)
+		}
+	}
+	if pos > len(data) {
+		panic("syzygy: truncated table")
+	}
+}
+
+// setGroups splits the pieces into the groups they are encoded by and computes the index factor of each group
+func (t *tbTable) setGroups(d *pairsData, order [2]int, file int) {
+	n := 0
+	firstLen := 2
+	if t.hasPawns {
+		firstLen = 0
+	} else if t.hasUniquePieces {
+		firstLen = 3
+	}
+
+	d.groupLen[n] = 1
+	for i := 1; i < t.pieceCount; i++ {
+		firstLen--
+		if firstLen > 0 || d.pieces[i] == d.pieces[i-1] {
+			d.groupLen[n]++
+		} else {
+			n++
+			d.groupLen[n] = 1
+		}
+	}
+	n++
+	d.groupLen[n] = 0
+
+	pp := t.hasPawns && t.pawnCount[1] > 0
+	next := 1
+	if pp {
+		next = 2
+	}
+	freeSquares := 64 - d.groupLen[0]
+	if pp {
+		freeSquares -= d.groupLen[1]
+	}
+
+	idx := uint64(1)
+	for k := 0; next < n || k == order[0] || k == order[1]; k++ {
+		switch {
+		case k == order[0]:
+			d.groupIdx[0] = idx
+			switch {
+			case t.hasPawns:
+				idx *= tbLeadPawnsSize[d.groupLen[0]][file]
+			case t.hasUniquePieces:
+				idx *= 31332
+			default:
+				idx *= 462
+			}
+		case k == order[1]:
+			d.groupIdx[1] = idx
+			idx *= tbBinomial[d.groupLen[1]][48-d.groupLen[0]]
+		default:
+			d.groupIdx[next] = idx
+			idx *= tbBinomial[d.groupLen[next]][freeSquares]
+			freeSquares -= d.groupLen[next]
+			next++
+		}
+	}
+	d.groupIdx[n] = idx
+}
+
+// setSizes reads the block layout and the canonical Huffman code of the pairs data
+func (t *tbTable) setSizes(d *pairsData, pos int) int {
+	data := t.file
+
+	d.flags = data[pos]
+	pos++
+	if d.flags&tbFlagSingleValue != 0 {
+		d.minSymLen = int(data[pos])
+		return pos + 1
+	}
+
+	n := 0
+	for n < len(d.groupLen) && d.groupLen[n] != 0 {
+		n++
+	}
+	tbSize := d.groupIdx[n]
+
+	d.sizeofBlock = 1 << data[pos]
+	d.span = 1 << data[pos+1]
+	d.sparseIndexSize = (tbSize + d.span - 1) / d.span
+	padding := uint64(data[pos+2])
+	d.blocksNum = uint64(binary.LittleEndian.Uint32(data[pos+3:]))
+	d.blockLengthSize = d.blocksNum + padding
+	d.maxSymLen = int(data[pos+7])
+	d.minSymLen = int(data[pos+8])
+	pos += 9
+	d.lowestSym = pos
+
+	// canonical Huffman code: base64[i] is the lowest code of length i+minSymLen, left aligned to 64 bits
+	d.base64 = make([]uint64, d.maxSymLen-d.minSymLen+1)
+	for i := len(d.base64) - 2; i >= 0; i-- {
+		d.base64[i] = (d.base64[i+1] + uint64(t.lowestSym(d, i)) - uint64(t.lowestSym(d, i+1))) / 2
+	}
+	for i := range d.base64 {
+		d.base64[i] <<= uint(64 - i - d.minSymLen)
+	}
+	pos += len(d.base64) * 2
+
+	// recursive pairing: every symbol expands into a left and right symbol, down to the stored values
+	d.symlen = make([]byte, binary.LittleEndian.Uint16(data[pos:]))
+	pos += 2
+	d.btree = pos
+
+	visited := make([]bool, len(d.symlen))
+	for sym := range d.symlen {
+		if !visited[sym] {
+			d.symlen[sym] = t.setSymlen(d, sym, visited)
+		}
+	}
+
+	return pos + len(d.symlen)*3 + len(d.symlen)&1
+}
+
+func (t *tbTable) setSymlen(d *pairsData, sym int, visited []bool) byte {
+	visited[sym] = true
+	right := t.right(d, sym)
+	if right == 0xfff {
+		return 0
+	}
+	left := t.left(d, sym)
+	if !visited[left] {
+		d.symlen[left] = t.setSymlen(d, left, visited)
+	}
+	if !visited[right] {
+		d.symlen[right] = t.setSymlen(d, right, visited)
+	}
+	return d.symlen[left] + d.symlen[right] + 1
+}
+
+func (t *tbTable) lowestSym(d *pairsData, i int) uint16 {
+	return binary.LittleEndian.Uint16(t.file[d.lowestSym+2*i:])
+}
+
+func (t *tbTable) left(d *pairsData, sym int) int {
+	lr := t.file[d.btree+3*sym:]
+	return int(lr[1]&0xf)<<8 | int(lr[0])
+}
+
+func (t *tbTable) right(d *pairsData, sym int) int {
+	lr := t.file[d.btree+3*sym:]
+	return int(lr[2])<<4 | int(lr[1]>>4)
+}
+
+// decompressPairs returns the value stored at the index
+func (t *tbTable) decompressPairs(d *pairsData, idx uint64) int {
+	if d.flags&tbFlagSingleValue != 0 {
+		return d.minSymLen
+	}
+	data := t.file
+
+	// 1. find the block holding the index, starting from the nearest sparse index entry
+	k := idx / d.span
+	entry := data[d.sparseIndex+6*int(k):]
+	block := int(binary.LittleEndian.Uint32(entry))
+	offset := int(binary.LittleEndian.Uint16(entry[4:]))
+	offset += int(idx%d.span) - int(d.span/2)
+
+	blockLength := func(block int) int {
+		return int(binary.LittleEndian.Uint16(data[d.blockLength+2*block:]))
+	}
+	for offset < 0 {
+		block--
+		offset += blockLength(block) + 1
+	}
+	for offset > blockLength(block) {
+		offset -= blockLength(block) + 1
+		block++
+	}
+
+	// 2. walk the Huffman symbols of the block until the one covering the offset
+	ptr := d.data + block*int(d.sizeofBlock)
+	buf64 := binary.BigEndian.Uint64(data[ptr:])
+	ptr += 8
+	buf64Size := 64
+
+	var sym int
+	for {
+		length := 0
+		for buf64 < d.base64[length] {
+			length++
+		}
+		sym = int((buf64 - d.base64[length]) >> uint(64-length-d.minSymLen))
+		sym += int(t.lowestSym(d, length))
+
+		if offset < int(d.symlen[sym])+1 {
+			break
+		}
+		offset -= int(d.symlen[sym]) + 1
+
+		length += d.minSymLen
+		buf64 <<= uint(length)
+		buf64Size -= length
+		if buf64Size <= 32 {
+			buf64Size += 32
+			buf64 |= uint64(binary.BigEndian.Uint32(data[ptr:])) << uint(64-buf64Size)
+			ptr += 4
+		}
+	}
+
+	// 3. expand the symbol down to the single value at the offset
+	for d.symlen[sym] != 0 {
+		left := t.left(d, sym)
+		if offset < int(d.symlen[left])+1 {
+			sym = left
+		} else {
+			offset -= int(d.symlen[left]) + 1
+			sym = t.right(d, sym)
+		}
+	}
+	return t.left(d, sym)
+}
+
+// encoding tables shared by every table
+var (
+	tbBinomial      [6][64]uint64
+	tbMapPawns      [64]int
+	tbLeadPawnIdx   [6][64]uint64
+	tbLeadPawnsSize [6][4]uint64
+	tbMapB1H1H7     [64]int
+	tbMapA1D1D4     [64]int
+	tbMapKK         [10][64]int
+)
+
+// offA1H8 returns how far the square is above the a1-h8 diagonal, negative below it
+func offA1H8(sq int) int {
+	return sq>>3 - sq&7
+}
+
+func init() {
+	// squares below the a1-h8 diagonal
+	code := 0
+	for sq := 0; sq < 64; sq++ {
+		if offA1H8(sq) < 0 {
+			tbMapB1H1H7[sq] = code
+			code++
+		}
+	}
+
+	// the a1-d1-d4 triangle, with the diagonal squares last
+	var diagonal []int
+	code = 0
+	for sq := 0; sq <= 27; sq++ {
+		if offA1H8(sq) < 0 && sq&7 <= 3 {
+			tbMapA1D1D4[sq] = code
+			code++
+		} else if offA1H8(sq) == 0 && sq&7 <= 3 {
+			diagonal = append(diagonal, sq)
+		}
+	}
+	for _, sq := range diagonal {
+		tbMapA1D1D4[sq] = code
+		code++
+	}
+
+	// the 462 placements of two kings with the first in the a1-d1-d4 triangle
+	var bothOnDiagonal [][2]int
+	code = 0
+	for idx := 0; idx < 10; idx++ {
+		for s1 := 0; s1 <= 27; s1++ {
+			if tbMapA1D1D4[s1] != idx || (idx == 0 && s1 != 1) {
+				continue
+			}
+			for s2 := 0; s2 < 64; s2++ {
+				if (kingAttacks[s1]|bit(int8(s1)))&bit(int8(s2)) != 0 {
+					continue
+				}
+				if offA1H8(s1) == 0 && offA1H8(s2) > 0 {
+					continue
+				}
+				if offA1H8(s1) == 0 && offA1H8(s2) == 0 {
+					bothOnDiagonal = append(bothOnDiagonal, [2]int{idx, s2})
+					continue
+				}
+				tbMapKK[idx][s2] = code
+				code++
+			}
+		}
+	}
+	for _, p := range bothOnDiagonal {
+		tbMapKK[p[0]][p[1]] = code
+		code++
+	}
+
+	tbBinomial[0][0] = 1
+	for n := 1; n < 64; n++ {
+		for k := 0; k < 6 && k <= n; k++ {
+			if k > 0 {
+				tbBinomial[k][n] += tbBinomial[k-1][n-1]
+			}
+			if k < n {
+				tbBinomial[k][n] += tbBinomial[k][n-1]
+			}
+		}
+	}
+
+	// pawns: the leading pawn is the one nearest the edge and then the lowest rank
+	available := 47
+	for leadPawns := 1; leadPawns <= 5; leadPawns++ {
+		for f := 0; f < 4; f++ {
+			idx := uint64(0)
+			for r := 1; r <= 6; r++ {
+				sq := r*8 + f
+				if leadPawns == 1 {
+					tbMapPawns[sq] = available
+					available--
+					tbMapPawns[sq^7] = available
+					available--
+				}
+				tbLeadPawnIdx[leadPawns][sq] = idx
+				idx += tbBinomial[leadPawns-1][tbMapPawns[sq]]
+			}
+			tbLeadPawnsSize[leadPawns][f] = idx
+		}
+	}
+}
+
+// tbPieceCode returns the piece code used by the table files: pawn 1 to king 6, plus 8 for black
+func tbPieceCode(pieceType Type, color Color) byte {
+	code := [...]byte{King: 6, Queen: 5, Rook: 4, Bishop: 3, Knight: 2, Pawn: 1}[pieceType]
+	if color == Black {
+		code |= 8
+	}
+	return code
+}
+
+// materialName returns the material of the color as used in table names, e.g. KRP
+func (gs *GameState) materialName(color Color) string {
+	var sb strings.Builder
+	for _, pieceType := range []Type{King, Queen, Rook, Bishop, Knight, Pawn} {
+		for i := gs.pieces[color.index()][pieceType].count(); i > 0; i-- {
+			sb.WriteByte("KQRBNP"[pieceType])
+		}
+	}
+	return sb.String()
+}
+
+// errTablebase is reported when a position is missing from the tablebases
+var errTablebase = fmt.Errorf("position not in the tablebases")
+
+// probeTable returns the raw value of the position in the WDL or DTZ table. For DTZ tables changeSTM reports that
+// the table only stores the other player to move
+func (gs *GameState) probeTable(dtz bool, wdl WDL) (value int, changeSTM bool, err error) {
+	all := gs.colors[0] | gs.colors[1]
+	if all.count() == 2 {
+		return int(Draw), false, nil
+	}
+
+	white, black := gs.materialName(White), gs.materialName(Black)
+
+	tablebases.RLock()
+	tables := tablebases.wdl
+	if dtz {
+		tables = tablebases.dtz
+	}
+	table, blackStronger := tables[white+"v"+black], false
+	if table == nil {
+		table, blackStronger = tables[black+"v"+white], true
+	}
+	tablebases.RUnlock()
+
+	if table == nil || !table.load() {
+		return 0, false, errTablebase
+	}
+
+	d, file, idx, changeSTM := gs.tbIndex(table, blackStronger)
+	if changeSTM {
+		return 0, true, nil
+	}
+	value = table.decompressPairs(d, idx)
+	if !dtz {
+		return value - 2, false, nil
+	}
+	return table.mapDTZ(file, value, wdl), false, nil
+}
+
+// tbIndex returns the pairs data of the table holding the position, the file of its leading pawn and the index of
+// the position, with black as the stronger side if the table names it second. For DTZ tables the last result reports
+// that the table only stores the other player to move
+func (gs *GameState) tbIndex(table *tbTable, blackStronger bool) (*pairsData, int, uint64, bool) {
+	all := gs.colors[0] | gs.colors[1]
+
+	// the tables store white as the stronger side, and only white to move when the material is symmetric
+	stm := 0
+	if gs.currColor == Black {
+		stm = 1
+	}
+	symmetricBlackToMove := table.symmetric() && stm == 1
+	flip := symmetricBlackToMove || blackStronger
+	flipColor, flipSquares := byte(0), 0
+	if flip {
+		flipColor, flipSquares = 8, 56
+		stm ^= 1
+	}
+
+	var squares [tbPieces]int
+	var pieces [tbPieces]byte
+	size, leadPawnsCount := 0, 0
+	var leadPawns Bitboard
+	file := 0
+
+	// 1. the leading pawns, the one nearest the edge first, which selects the file of the table
+	if table.hasPawns {
+		leadColor := White
+		if table.get(0, 0).pieces[0]^flipColor >= 8 {
+			leadColor = Black
+		}
+		leadPawns = gs.pieces[leadColor.index()][Pawn]
+		for b := leadPawns; b != 0; {
+			squares[size] = int(b.popLsb()) ^ flipSquares
+			size++
+		}
+		leadPawnsCount = size
+		best := 0
+		for i := 1; i < leadPawnsCount; i++ {
+			if tbMapPawns[squares[i]] > tbMapPawns[squares[best]] {
+				best = i
+			}
+		}
+		squares[0], squares[best] = squares[best], squares[0]
+		file = min(squares[0]&7, 7-squares[0]&7)
+	}
+
+	if table.dtz {
+		flags := table.get(stm, file).flags
+		if int(flags&tbFlagSTM) != stm && !(table.symmetric() && !table.hasPawns) {
+			return nil, file, 0, true
+		}
+	}
+
+	// 2. the remaining pieces, with their colors flipped as needed
+	for b := all &^ leadPawns; b != 0; {
+		sq := b.popLsb()
+		piece := gs.board[to120[sq]]
+		squares[size] = int(sq) ^ flipSquares
+		pieces[size] = tbPieceCode(piece.Type, piece.Color) ^ flipColor
+		size++
+	}
+
+	d := table.get(stm, file)
+
+	// 3. order the pieces as the table encodes them
+	for i := leadPawnsCount; i < size-1; i++ {
+		for j := i + 1; j < size; j++ {
+			if d.pieces[i] == pieces[j] {
+				pieces[i], pieces[j] = pieces[j], pieces[i]
+				squares[i], squares[j] = squares[j], squares[i]
+				break
+			}
+		}
+	}
+
+	// 4. mirror the board so that the leading piece is on the queen side
+	if squares[0]&7 > 3 {
+		for i := 0; i < size; i++ {
+			squares[i] ^= 7
+		}
+	}
+
+	var idx uint64
+	if table.hasPawns {
+		idx = tbLeadPawnIdx[leadPawnsCount][squares[0]]
+		rest := squares[1:leadPawnsCount]
+		sort.SliceStable(rest, func(i, j int) bool { return tbMapPawns[rest[i]] < tbMapPawns[rest[j]] })
+		for i := 1; i < leadPawnsCount; i++ {
+			idx += tbBinomial[i][tbMapPawns[squares[i]]]
+		}
+	} else {
+		// without pawns, also mirror the leading piece below the 5th rank and below the a1-h8 diagonal
+		if squares[0]>>3 > 3 {
+			for i := 0; i < size; i++ {
+				squares[i] ^= 56
+			}
+		}
+		for i := 0; i < d.groupLen[0]; i++ {
+			if offA1H8(squares[i]) == 0 {
+				continue
+			}
+			if offA1H8(squares[i]) > 0 {
+				for j := i; j < size; j++ {
+					squares[j] = (squares[j]>>3 | squares[j]<<3) & 63
+				}
+			}
+			break
+		}
+		idx = tbLeadingIndex(table.hasUniquePieces, squares[:])
+	}
+
+	// 5. the remaining groups, each encoded as a combination of the squares left over by the earlier groups
+	idx *= d.groupIdx[0]
+	groupStart := d.groupLen[0]
+	remainingPawns := table.hasPawns && table.pawnCount[1] > 0
+	for next := 1; d.groupLen[next] != 0; next++ {
+		group := squares[groupStart : groupStart+d.groupLen[next]]
+		sort.Ints(group)
+		var n uint64
+		for i, sq := range group {
+			adjust := 0
+			for _, earlier := range squares[:groupStart] {
+				if sq > earlier {
+					adjust++
+				}
+			}
+			pawnOffset := 0
+			if remainingPawns {
+				pawnOffset = 8
+			}
+			n += tbBinomial[i+1][sq-adjust-pawnOffset]
+		}
+		remainingPawns = false
+		idx += n * d.groupIdx[next]
+		groupStart += d.groupLen[next]
+	}
+	return d, file, idx, false
+}
+
+// tbLeadingIndex returns the index of the leading group of a pawnless table
+func tbLeadingIndex(unique bool, squares []int) uint64 {
+	if !unique {
+		return uint64(tbMapKK[tbMapA1D1D4[squares[0]]][squares[1]])
+	}
+
+	b := func(c bool) int {
+		if c {
+			return 1
+		}
+		return 0
+	}
+	adjust1 := b(squares[1] > squares[0])
+	adjust2 := b(squares[2] > squares[0]) + b(squares[2] > squares[1])
+
+	switch {
+	case offA1H8(squares[0]) != 0:
+		return uint64((tbMapA1D1D4[squares[0]]*63+(squares[1]-adjust1))*62 + squares[2] - adjust2)
+	case offA1H8(squares[1]) != 0:
+		return uint64((6*63+(squares[0]>>3)*28+tbMapB1H1H7[squares[1]])*62 + squares[2] - adjust2)
+	case offA1H8(squares[2]) != 0:
+		return uint64(6*63*62 + 4*28*62 + (squares[0]>>3)*7*28 + ((squares[1]>>3)-adjust1)*28 + tbMapB1H1H7[squares[2]])
+	default:
+		return uint64(6*63*62 + 4*28*62 + 4*7*28 + (squares[0]>>3)*7*6 + ((squares[1]>>3)-adjust1)*6 + (squares[2] >> 3) - adjust2)
+	}
+}
+
+// mapDTZ converts a raw DTZ table value into plies, given the WDL of the position
+func (t *tbTable) mapDTZ(file, value int, wdl WDL) int {
+	d := t.get(0, file)
+	if d.flags&tbFlagMapped != 0 {
+		i := int(d.mapIdx[[...]int{1, 3, 0, 2, 0}[wdl+2]]) + value
+		if d.flags&tbFlagWide != 0 {
+			value = int(binary.LittleEndian.Uint16(t.file[t.dtzMap+2*i:]))
+		} else {
+			value = int(t.file[t.dtzMap+i])
+		}
+	}
+
+	if (wdl == Win && d.flags&tbFlagWinPlies == 0) || (wdl == Loss && d.flags&tbFlagLossPlies == 0) ||
+		wdl == CursedWin || wdl == BlessedLoss {
+		value *= 2
+	}
+	return value + 1
+}
+
+// ProbeWDL returns the tablebase outcome of the position for the current player
+func (gs *GameState) ProbeWDL() (WDL, bool) {
+	wdl, _, err := gs.probeWDL(false)
+	return wdl, err == nil
+}
+
+// probeWDL returns the outcome of the position, resolving captures the tables store as don't care values.
+// zeroingBest reports that the best move captures or moves a pawn, which DTZ tables cannot be probed for
+func (gs *GameState) probeWDL(pawnMoves bool) (wdl WDL, zeroingBest bool, err error) {
+	var moves MoveList
+	gs.legalMoves(&moves)
+
+	best := Loss
+	searched := 0
+	for _, move := range moves.Moves() {
+		if !gs.isCapture(move) && (!pawnMoves || gs.board[move.Origin].Type != Pawn) {
+			continue
+		}
+		searched++
+
+		gs.executeMove(move.Origin, move.Destination, move.MoveType)
+		value, _, err := gs.probeWDL(false)
+		gs.Undo()
+		if err != nil {
+			return Draw, false, err
+		}
+
+		if -value > best {
+			best = -value
+			if best >= Win {
+				return best, true, nil
+			}
+		}
+	}
+
+	// the table value is not trusted when every move was searched, e.g. after a double pawn push
+	noMoreMoves := searched > 0 && searched == moves.Len()
+	var value WDL
+	if noMoreMoves {
+		value = best
+	} else {
+		raw, _, err := gs.probeTable(false, Draw)
+		if err != nil {
+			return Draw, false, err
+		}
+		value = WDL(raw)
+	}
+
+	if best >= value {
+		return best, best > Draw || noMoreMoves, nil
+	}
+	return value, false, nil
+}
+
+// dtzBeforeZeroing returns the DTZ of the position just before a zeroing move with the given outcome
+func dtzBeforeZeroing(wdl WDL) int {
+	switch wdl {
+	case Win:
+		return 1
+	case CursedWin:
+		return 101
+	case BlessedLoss:
+		return -101
+	case Loss:
+		return -1
+	}
+	return 0
+}
+
+// ProbeDTZ returns the distance in plies to the next capture or pawn move in an optimal game, positive when the
+// current player wins and negative when they lose, and 0 for draws. Values beyond 100 are affected by the fifty
+// move rule
+func (gs *GameState) ProbeDTZ() (int, bool) {
+	dtz, err := gs.probeDTZ()
+	return dtz, err == nil
+}
+
+func (gs *GameState) probeDTZ() (int, error) {
+	wdl, zeroingBest, err := gs.probeWDL(true)
+	if err != nil || wdl == Draw {
+		return 0, err
+	}
+	if zeroingBest {
+		return dtzBeforeZeroing(wdl), nil
+	}
+
+	dtz, changeSTM, err := gs.probeTable(true, wdl)
+	if err != nil {
+		return 0, err
+	}
+	if !changeSTM {
+		if wdl == BlessedLoss || wdl == CursedWin {
+			dtz += 100
+		}
+		if wdl < 0 {
+			dtz = -dtz
+		}
+		return dtz, nil
+	}
+
+	// the table stores the other player to move, so search one ply for the move with the best DTZ
+	var moves MoveList
+	gs.legalMoves(&moves)
+	minDTZ := 0xffff
+	for _, move := range moves.Moves() {
+		zeroing := gs.isCapture(move) || gs.board[move.Origin].Type == Pawn
+
+		gs.executeMove(move.Origin, move.Destination, move.MoveType)
+		var value int
+		if zeroing {
+			reply, _, err := gs.probeWDL(false)
+			if err != nil {
+				gs.Undo()
+				return 0, err
+			}
+			value = -dtzBeforeZeroing(reply)
+		} else {
+			reply, err := gs.probeDTZ()
+			if err != nil {
+				gs.Undo()
+				return 0, err
+			}
+			value = -reply
+		}
+		if value == 1 && gs.isCheckmate() {
+			minDTZ = 1
+		}
+		gs.Undo()
+
+		if !zeroing {
+			value += sign(value)
+		}
+		if value < minDTZ && sign(value) == sign(int(wdl)) {
+			minDTZ = value
+		}
+	}
+
+	if minDTZ == 0xffff {
+		return -1, nil
+	}
+	return minDTZ, nil
+}
+
+// isCheckmate returns true if the current player is in check without a legal move
+func (gs *GameState) isCheckmate() bool {
+	if !gs.inCheck(gs.currColor) {
+		return false
+	}
+	var moves MoveList
+	gs.legalMoves(&moves)
+	return moves.Len() == 0
+}
+
+func sign(x int) int {
+	switch {
+	case x > 0:
+		return 1
+	case x < 0:
+		return -1
+	}
+	return 0
+}
+
+// tablebaseCardinality returns the most pieces any loaded table covers
+func tablebaseCardinality() int {
+	tablebases.RLock()
+	defer tablebases.RUnlock()
+	return tablebases.maxPieces
+}
+
+// probeSearch returns the tablebase score of the position from white's perspective, for use inside the search.
+// Tables are only probed right after a capture or pawn move, where the fifty move counter is reset
+func (gs *GameState) probeSearch(ply int) (int, bool) {
+	cardinality := tablebaseCardinality()
+	if cardinality == 0 || gs.halfMoveClock != 0 || gs.castling != 0 || (gs.colors[0]|gs.colors[1]).count() > cardinality {
+		return 0, false
+	}
+
+	wdl, _, err := gs.probeWDL(false)
+	if err != nil {
+		return 0, false
+	}
+
+	var score int
+	switch wdl {
+	case Win:
+		score = tablebaseWin - ply
+	case Loss:
+		score = -tablebaseWin + ply
+	}
+	return score * int(gs.currColor), true
+}
+
+// ProbeRoot returns the tablebase-perfect move of the current player: the win that reaches the next capture or
+// pawn move soonest, otherwise a drawing move, otherwise the loss that holds out the longest
+func (gs *GameState) ProbeRoot() (Move, WDL, bool) {
+	cardinality := tablebaseCardinality()
+	if cardinality == 0 || gs.castling != 0 || (gs.colors[0]|gs.colors[1]).count() > cardinality {
+		return Move{}, Draw, false
+	}
+
+	var moves MoveList
+	gs.legalMoves(&moves)
+
+	best, bestDTZ := Move{}, 0
+	found := false
+	for _, move := range moves.Moves() {
+		gs.executeMove(move.Origin, move.Destination, move.MoveType)
+		var dtz int
+		var err error
+		if gs.halfMoveClock == 0 {
+			var wdl WDL
+			wdl, _, err = gs.probeWDL(false)
+			dtz = dtzBeforeZeroing(-wdl)
+		} else {
+			dtz, err = gs.probeDTZ()
+			dtz = -dtz
+			dtz += sign(dtz)
+		}
+		if dtz == 2 && gs.isCheckmate() {
+			dtz = 1
+		}
+		gs.Undo()
+
+		if err != nil {
+			return Move{}, Draw, false
+		}
+		if !found || dtzBetter(dtz, bestDTZ) {
+			best, bestDTZ, found = move, dtz, true
+		}
+	}
+	if !found {
+		return Move{}, Draw, false
+	}
+
+	wdl := Draw
+	switch {
+	case bestDTZ > 100:
+		wdl = CursedWin
+	case bestDTZ > 0:
+		wdl = Win
+	case bestDTZ < -100:
+		wdl = BlessedLoss
+	case bestDTZ < 0:
+		wdl = Loss
+	}
+	return best, wdl, true
+}
+
+// dtzBetter returns true if a move with distance a is better than one with distance b, where positive distances win
+// and negative distances lose
+func dtzBetter(a, b int) bool {
+	rank := func(dtz int) int {
+		switch {
+		case dtz > 0:
+			return 2
+		case dtz == 0:
+			return 1
+		}
+		return 0
+	}
+	if rank(a) != rank(b) {
+		return rank(a) > rank(b)
+	}
+	// the quickest win, or the slowest loss
+	return a < b
+}
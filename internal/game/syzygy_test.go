@@ -0,0 +1,592 @@
+package game
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestSetSyzygyPath(t *testing.T) {
+	t.Cleanup(func() { SetSyzygyPath("") })
+
+	dir := t.TempDir()
+	files := []string{
+		"KQvK.rtbw", "KQvK.rtbz", "KRPvKR.rtbw",
+		"KXvK.rtbw",      // not a piece
+		"KQKvK.rtbw",     // two kings
+		"KQRBNPvKQ.rtbw", // beyond the supported pieces
+		"KRvK.txt",
+	}
+	for _, name := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("not a table"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name    string
+		dir     string
+		want    int
+		wantErr bool
+	}{
+		{"disabled", "", 0, false},
+		{"missing directory", filepath.Join(dir, "missing"), 0, true},
+		{"empty directory", t.TempDir(), 0, false},
+		{"valid names only", dir, 2, false},
+	}
+	for _, tt := range tests {
+		n, err := SetSyzygyPath(tt.dir)
+		if n != tt.want || (err != nil) != tt.wantErr {
+			t.Errorf("%s: SetSyzygyPath = %d, %v, want %d tables, error %v", tt.name, n, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestProbeWithoutTables(t *testing.T) {
+	t.Cleanup(func() { SetSyzygyPath("") })
+
+	// a corrupt table is skipped rather than trusted
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "KQvK.rtbw"), []byte("not a table"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, path := range []string{"", dir} {
+		if _, err := SetSyzygyPath(path); err != nil {
+			t.Fatal(err)
+		}
+		gs := mustFEN(t, "8/8/8/3k4/8/8/8/3QK3 w - - 0 1")
+		if wdl, ok := gs.ProbeWDL(); ok {
+			t.Errorf("path %q: ProbeWDL = %v, want no result", path, wdl)
+		}
+		if dtz, ok := gs.ProbeDTZ(); ok {
+			t.Errorf("path %q: ProbeDTZ = %d, want no result", path, dtz)
+		}
+		if move, wdl, ok := gs.ProbeRoot(); ok {
+			t.Errorf("path %q: ProbeRoot = %v %v, want no result", path, move, wdl)
+		}
+	}
+}
+
+func TestMaterialName(t *testing.T) {
+	tests := []struct {
+		fen          string
+		white, black string
+	}{
+		{StartFEN, "KQRRBBNNPPPPPPPP", "KQRRBBNNPPPPPPPP"},
+		{"8/8/8/3k4/8/8/8/3QK3 w - - 0 1", "KQ", "K"},
+		{"8/8/2r5/3k4/8/4P3/8/2BRK3 b - - 0 1", "KRBP", "KR"},
+	}
+	for _, tt := range tests {
+		gs := mustFEN(t, tt.fen)
+		if white, black := gs.materialName(White), gs.materialName(Black); white != tt.white || black != tt.black {
+			t.Errorf("%s: material %sv%s, want %sv%s", tt.fen, white, black, tt.white, tt.black)
+		}
+	}
+}
+
+func TestWDLString(t *testing.T) {
+	tests := []struct {
+		wdl  WDL
+		want string
+	}{
+		{Loss, "Loss"},
+		{BlessedLoss, "Blessed Loss"},
+		{Draw, "Draw"},
+		{CursedWin, "Cursed Win"},
+		{Win, "Win"},
+	}
+	for _, tt := range tests {
+		if got := tt.wdl.String(); got != tt.want {
+			t.Errorf("WDL(%d).String() = %q, want %q", int(tt.wdl), got, tt.want)
+		}
+	}
+}
+
+// tbTestSide is the content of one side of a table written by writeTBTable: a single value for every position, or
+// the value of each index
+type tbTestSide struct {
+	flags  byte
+	single int
+	values []int
+}
+
+// writeTBTable writes a pawnless table in the layout of the Syzygy files, so that the decoder can be tested without
+// checking in files of the Syzygy generator. The pieces are listed in the order they are indexed, the same for both
+// sides. Values are stored as fixed length codes, one symbol per value, in blocks of 64 bytes, and DTZ tables take
+// the value maps of their first side
+func writeTBTable(t *testing.T, path string, dtz bool, pieces []byte, sides []tbTestSide, maps [4][]byte) {
+	t.Helper()
+	const blockBits, spanBits = 6, 6
+	const blockSize, span = 1 << blockBits, 1 << spanBits
+
+	le16 := func(b []byte, v int) []byte { return binary.LittleEndian.AppendUint16(b, uint16(v)) }
+	le32 := func(b []byte, v int) []byte { return binary.LittleEndian.AppendUint32(b, uint32(v)) }
+
+	// 1. the header: magic, flags, piece order and pieces
+	magic := tbWDLMagic
+	if dtz {
+		magic = tbDTZMagic
+	}
+	file := le32(nil, magic)
+	file = append(file, 0, 0)
+	for _, piece := range pieces {
+		file = append(file, piece|piece<<4)
+	}
+	file = append(file, make([]byte, len(file)&1)...)
+
+	// 2. the symbols of each side: symbol i stands for the ith distinct value
+	type encoded struct {
+		symbols  []int
+		bits     int
+		perBlock int
+		blocks   int
+	}
+	encodings := make([]encoded, len(sides))
+	for i, side := range sides {
+		if side.flags&tbFlagSingleValue != 0 {
+			file = append(file, side.flags, byte(side.single))
+			continue
+		}
+		e := &encodings[i]
+		seen := map[int]bool{}
+		for _, value := range side.values {
+			if !seen[value] {
+				seen[value] = true
+				e.symbols = append(e.symbols, value)
+			}
+		}
+		sort.Ints(e.symbols)
+		e.bits = 1
+		for 1<<e.bits < len(e.symbols) {
+			e.bits++
+		}
+		e.perBlock = blockSize * 8 / e.bits
+		e.blocks = (len(side.values) + e.perBlock - 1) / e.perBlock
+
+		file = append(file, side.flags, blockBits, spanBits, 0)
+		file = le32(file, e.blocks)
+		file = append(file, byte(e.bits), byte(e.bits))
+		file = le16(file, 0) // the lowest symbol of the only code length
+		file = le16(file, len(e.symbols))
+		for _, value := range e.symbols {
+			file = append(file, byte(value), byte(value>>8)|0xf0, 0xff) // a leaf: no right symbol
+		}
+		file = append(file, make([]byte, len(e.symbols)&1)...)
+	}
+
+	// 3. the value maps
+	if dtz {
+		if sides[0].flags&tbFlagMapped != 0 {
+			for _, m := range maps {
+				file = append(file, byte(len(m)))
+				file = append(file, m...)
+			}
+		}
+		file = append(file, make([]byte, len(file)&1)...)
+	}
+
+	// 4. the sparse indexes, pointing at the middle of each span, then the block lengths and the blocks
+	for i, side := range sides {
+		e := encodings[i]
+		for k := 0; k*span < len(side.values); k++ {
+			middle := k*span + span/2
+			block := min(middle/e.perBlock, e.blocks-1)
+			file = le32(file, block)
+			file = le16(file, middle-block*e.perBlock)
+		}
+	}
+	for i, side := range sides {
+		e := encodings[i]
+		for block := 0; block < e.blocks; block++ {
+			file = le16(file, min(e.perBlock, len(side.values)-block*e.perBlock)-1)
+		}
+	}
+	for i, side := range sides {
+		e := encodings[i]
+		file = append(file, make([]byte, -len(file)&63)...)
+		for block := 0; block < e.blocks; block++ {
+			data := make([]byte, blockSize)
+			for j := 0; j < e.perBlock && block*e.perBlock+j < len(side.values); j++ {
+				sym := sort.SearchInts(e.symbols, side.values[block*e.perBlock+j])
+				for b := 0; b < e.bits; b++ {
+					if sym&(1<<(e.bits-1-b)) != 0 {
+						bitPos := j*e.bits + b
+						data[bitPos/8] |= 0x80 >> (bitPos % 8)
+					}
+				}
+			}
+			file = append(file, data...)
+		}
+	}
+
+	// the decoder reads a few bytes past the last block
+	file = append(file, make([]byte, 8)...)
+	if err := os.WriteFile(path, file, 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// dtmFEN returns the FEN of the position of a DTM table
+func dtmFEN(pieces []dtmPiece, squares []int8, stm Color) string {
+	var board [8][8]byte
+	for i, piece := range pieces {
+		name := SquareName(to120[squares[i]])
+		c := "KQRBNP"[piece.Type]
+		if piece.Color == Black {
+			c += 'a' - 'A'
+		}
+		board['8'-name[1]][name[0]-'a'] = c
+	}
+
+	var sb strings.Builder
+	for rank, row := range board {
+		if rank > 0 {
+			sb.WriteByte('/')
+		}
+		empty := 0
+		for _, c := range row {
+			if c == 0 {
+				empty++
+				continue
+			}
+			if empty > 0 {
+				sb.WriteByte(byte('0' + empty))
+				empty = 0
+			}
+			sb.WriteByte(c)
+		}
+		if empty > 0 {
+			sb.WriteByte(byte('0' + empty))
+		}
+	}
+	if stm == White {
+		sb.WriteString(" w - - 0 1")
+	} else {
+		sb.WriteString(" b - - 0 1")
+	}
+	return sb.String()
+}
+
+// writeTBFromDTM writes the WDL and DTZ tables of a pawnless ending of three pieces from its DTM table. With no
+// capture or pawn move left to the winner, the distance to zeroing is the distance to mate. The DTZ table stores
+// the side to move of dtzSide: white as plain win distances, black through a map of loss distances in plies.
+// Every position of the ending is looked up in the DTM table, so the positions the symmetries of the Syzygy index
+// bring together must agree
+func writeTBFromDTM(t *testing.T, dir string, dtm *DTMTable, pieces []byte, dtzSide Color) {
+	t.Helper()
+	path := filepath.Join(dir, dtm.Name)
+	dtzFlags := byte(0)
+	if dtzSide == Black {
+		dtzFlags = tbFlagSTM | tbFlagMapped | tbFlagLossPlies
+	}
+
+	// 1. single value tables, to find the size of the table and the index of each position
+	draw := tbTestSide{flags: tbFlagSingleValue, single: int(Draw) + 2}
+	writeTBTable(t, path+".rtbw", false, pieces, []tbTestSide{draw, draw}, [4][]byte{})
+	writeTBTable(t, path+".rtbz", true, pieces, []tbTestSide{{flags: tbFlagSingleValue | dtzFlags}}, [4][]byte{})
+	if _, err := SetSyzygyPath(dir); err != nil {
+		t.Fatal(err)
+	}
+	table := tablebases.wdl[dtm.Name]
+	if !table.load() {
+		t.Fatalf("%s: placeholder table not loaded", dtm.Name)
+	}
+	d := table.get(0, 0)
+	n := 0
+	for d.groupLen[n] != 0 {
+		n++
+	}
+	tbSize := int(d.groupIdx[n])
+
+	// 2. the outcome and distance of every index, by side to move
+	wdl := [2][]int{make([]int, tbSize), make([]int, tbSize)}
+	plies := make([]int, tbSize)
+	for i := range wdl[0] {
+		wdl[0][i], wdl[1][i], plies[i] = -1, -1, -1
+	}
+
+	gs := &GameState{}
+	squares := make([]int8, len(dtm.pieces))
+	for idx := 0; idx < dtm.size(); idx++ {
+		stm := dtm.decode(idx, squares)
+		if !dtmLegal(dtm.pieces, squares, stm) {
+			continue
+		}
+		for i, piece := range dtm.pieces {
+			gs.putPiece(to120[squares[i]], &Piece{Type: piece.Type, Color: piece.Color, Value: pieceValues[piece.Type]})
+		}
+		gs.currColor = stm
+		_, _, i, _ := gs.tbIndex(table, false)
+		if i >= uint64(tbSize) {
+			t.Fatalf("%s: %s has the index %d, beyond the %d of the table",
+				dtm.Name, dtmFEN(dtm.pieces, squares, stm), int64(i), tbSize)
+		}
+		for _, sq := range squares {
+			gs.removePiece(to120[sq])
+		}
+
+		side, value, distance := 0, int(Draw)+2, 0
+		if stm == Black {
+			side = 1
+		}
+		if v := int(dtm.values[idx]); v > 0 {
+			value, distance = int(Win)+2, v-1
+			if v%2 == 1 {
+				value = int(Loss) + 2
+			}
+		}
+		if wdl[side][i] >= 0 && wdl[side][i] != value {
+			t.Fatalf("%s: %s has the value %d, another position of index %d %d",
+				dtm.Name, dtmFEN(dtm.pieces, squares, stm), value, i, wdl[side][i])
+		}
+		wdl[side][i] = value
+		if stm == dtzSide {
+			if plies[i] >= 0 && plies[i] != distance {
+				t.Fatalf("%s: %s is mate in %d plies, another position of index %d in %d",
+					dtm.Name, dtmFEN(dtm.pieces, squares, stm), distance, i, plies[i])
+			}
+			plies[i] = distance
+		}
+	}
+
+	// 3. the values, with the indexes of no legal position stored as draws. Black's losses are stored as the index
+	// of the plies to mate less one in the loss map, with a mated player one ply from the end of the game
+	var maps [4][]byte
+	for i := range wdl[0] {
+		for side := range wdl {
+			if wdl[side][i] < 0 {
+				wdl[side][i] = int(Draw) + 2
+			}
+		}
+		if dtzSide == Black && wdl[1][i] == int(Loss)+2 {
+			plies[i] = max(plies[i], 1) - 1
+			if bytes.IndexByte(maps[1], byte(plies[i])) < 0 {
+				maps[1] = append(maps[1], byte(plies[i]))
+			}
+		}
+	}
+	slices.Sort(maps[1])
+
+	dtzValues := make([]int, tbSize)
+	for i := range dtzValues {
+		switch {
+		case wdl[dtzSide.index()][i] == int(Draw)+2:
+		case dtzSide == White:
+			dtzValues[i] = (plies[i] - 1) / 2
+		default:
+			dtzValues[i] = bytes.IndexByte(maps[1], byte(plies[i]))
+		}
+	}
+
+	writeTBTable(t, path+".rtbw", false, pieces, []tbTestSide{{values: wdl[0]}, {values: wdl[1]}}, maps)
+	writeTBTable(t, path+".rtbz", true, pieces, []tbTestSide{{flags: dtzFlags, values: dtzValues}}, maps)
+}
+
+func TestProbeTables(t *testing.T) {
+	t.Cleanup(func() {
+		SetSyzygyPath("")
+		SetDTMPath("")
+	})
+
+	dtmDir, dir := t.TempDir(), t.TempDir()
+	if err := GenerateDTM(dtmDir, "KQvK", "KRvK"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := SetDTMPath(dtmDir); err != nil {
+		t.Fatal(err)
+	}
+
+	king, queen, rook, knight := tbPieceCode(King, White), tbPieceCode(Queen, White), tbPieceCode(Rook, White),
+		tbPieceCode(Knight, White)
+	blackKing := tbPieceCode(King, Black)
+	endings := []struct {
+		name    string
+		pieces  []byte
+		dtzSide Color
+	}{
+		{"KQvK", []byte{queen, king, blackKing}, White},
+		{"KRvK", []byte{rook, king, blackKing}, Black},
+	}
+	var tables []*DTMTable
+	for _, ending := range endings {
+		table, err := readDTM(filepath.Join(dtmDir, ending.name+".dtm"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		writeTBFromDTM(t, dir, table, ending.pieces, ending.dtzSide)
+		tables = append(tables, table)
+	}
+
+	// KNNvK stored as a single cursed win for white, and a blessed loss for black, to cover the fifty move rule
+	knn := []byte{king, blackKing, knight, knight}
+	writeTBTable(t, filepath.Join(dir, "KNNvK.rtbw"), false, knn, []tbTestSide{
+		{flags: tbFlagSingleValue, single: int(CursedWin) + 2},
+		{flags: tbFlagSingleValue, single: int(BlessedLoss) + 2},
+	}, [4][]byte{})
+	writeTBTable(t, filepath.Join(dir, "KNNvK.rtbz"), true, knn, []tbTestSide{{flags: tbFlagSingleValue, single: 5}},
+		[4][]byte{})
+
+	if n, err := SetSyzygyPath(dir); n != 3 || err != nil {
+		t.Fatalf("SetSyzygyPath = %d, %v, want 3 tables", n, err)
+	}
+
+	// the outcome and distance of positions of both sides to move across the tables, which the DTM tables give
+	t.Run("against DTM", func(t *testing.T) {
+		for _, table := range tables {
+			squares := make([]int8, len(table.pieces))
+			for idx := 0; idx < table.size(); idx += 31 {
+				stm := table.decode(idx, squares)
+				if !dtmLegal(table.pieces, squares, stm) {
+					continue
+				}
+
+				wantWDL, wantDTZ := Draw, 0
+				if value := int(table.values[idx]); value%2 == 0 && value > 0 {
+					wantWDL, wantDTZ = Win, value-1
+				} else if value%2 == 1 {
+					// a mated player is one ply from the end of the game
+					wantWDL, wantDTZ = Loss, -max(value-1, 1)
+				}
+
+				fen := dtmFEN(table.pieces, squares, stm)
+				gs := mustFEN(t, fen)
+				if wdl, ok := gs.ProbeWDL(); !ok || wdl != wantWDL {
+					t.Fatalf("%s: ProbeWDL = %v, %v, want %v", fen, wdl, ok, wantWDL)
+				}
+				if dtz, ok := gs.ProbeDTZ(); !ok || dtz != wantDTZ {
+					t.Fatalf("%s: ProbeDTZ = %d, %v, want %d", fen, dtz, ok, wantDTZ)
+				}
+			}
+		}
+	})
+
+	// the root moves of both players bring the mate one ply closer each time, until it is played
+	t.Run("play out", func(t *testing.T) {
+		for _, fen := range []string{"8/8/8/3k4/8/8/8/R3K3 w - - 0 1", "8/8/2k5/8/8/8/8/Q6K b - - 0 1"} {
+			gs := mustFEN(t, fen)
+			wantWDL, plies, _ := gs.ProbeDTM()
+			for ; plies > 0; plies-- {
+				move, wdl, ok := gs.ProbeRoot()
+				if !ok || wdl != wantWDL {
+					t.Fatalf("%s after %v: ProbeRoot = %v, %v, %v, want %v", fen, gs.Moves(), move, wdl, ok, wantWDL)
+				}
+				if err := gs.Play(move); err != nil {
+					t.Fatal(err)
+				}
+				if _, after, _ := gs.ProbeDTM(); after != plies-1 {
+					t.Fatalf("%s after %v: mate in %d plies, want %d", fen, gs.Moves(), after, plies-1)
+				}
+				wantWDL = -wantWDL
+			}
+			if _, reason := gs.Result(); reason != "checkmate" {
+				t.Errorf("%s: %v ends in %q, want checkmate", fen, gs.Moves(), reason)
+			}
+		}
+	})
+
+	t.Run("cursed and blessed", func(t *testing.T) {
+		tests := []struct {
+			name string
+			fen  string
+			wdl  WDL
+			dtz  int
+		}{
+			{"cursed win", "8/8/8/3k4/8/8/8/1NN1K3 w - - 0 1", CursedWin, 111},
+			{"blessed loss", "8/8/8/3k4/8/8/8/1NN1K3 b - - 0 1", BlessedLoss, -112},
+		}
+		for _, tt := range tests {
+			gs := mustFEN(t, tt.fen)
+			if wdl, ok := gs.ProbeWDL(); !ok || wdl != tt.wdl {
+				t.Errorf("%s: ProbeWDL = %v, %v, want %v", tt.name, wdl, ok, tt.wdl)
+			}
+			if dtz, ok := gs.ProbeDTZ(); !ok || dtz != tt.dtz {
+				t.Errorf("%s: ProbeDTZ = %d, %v, want %d", tt.name, dtz, ok, tt.dtz)
+			}
+			if move, wdl, ok := gs.ProbeRoot(); !ok || wdl != tt.wdl {
+				t.Errorf("%s: ProbeRoot = %v, %v, %v, want %v", tt.name, move, wdl, ok, tt.wdl)
+			}
+		}
+	})
+}
+
+// TestProbePublishedTables probes the KQvK and KRvK tables of the published Syzygy set, as found under
+// testdata/syzygy, and is skipped when they are missing. The outcome of every position checked must be the one of
+// the DTM tables. The distance may be rounded to moves by the table, so it is only checked within a ply
+func TestProbePublishedTables(t *testing.T) {
+	dir := filepath.Join("testdata", "syzygy")
+	for _, name := range []string{"KQvK.rtbw", "KQvK.rtbz", "KRvK.rtbw", "KRvK.rtbz"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Skipf("%s of the published Syzygy tables is missing from %s", name, dir)
+		}
+	}
+	t.Cleanup(func() { SetSyzygyPath("") })
+	if n, err := SetSyzygyPath(dir); n < 2 || err != nil {
+		t.Fatalf("SetSyzygyPath = %d, %v, want the KQvK and KRvK tables", n, err)
+	}
+
+	withinPly := func(dtz, want int) bool {
+		return sign(dtz) == sign(want) && dtz-want <= 1 && want-dtz <= 1
+	}
+
+	tests := []struct {
+		name string
+		fen  string
+		wdl  WDL
+		dtz  int
+	}{
+		{"queen mates in one", "7k/8/6K1/8/8/8/8/1Q6 w - - 0 1", Win, 1},
+		{"mated by the queen", "Q6k/8/6K1/8/8/8/8/8 b - - 0 1", Loss, -1},
+		{"rook mates in one", "7k/8/6K1/8/8/8/8/R7 w - - 0 1", Win, 1},
+		{"mated by the rook", "R6k/8/6K1/8/8/8/8/8 b - - 0 1", Loss, -1},
+		{"rook left to be taken", "8/8/8/8/8/8/1k6/1R5K b - - 0 1", Draw, 0},
+	}
+	for _, tt := range tests {
+		gs := mustFEN(t, tt.fen)
+		if wdl, ok := gs.ProbeWDL(); !ok || wdl != tt.wdl {
+			t.Errorf("%s: ProbeWDL = %v, %v, want %v", tt.name, wdl, ok, tt.wdl)
+		}
+		if dtz, ok := gs.ProbeDTZ(); !ok || !withinPly(dtz, tt.dtz) {
+			t.Errorf("%s: ProbeDTZ = %d, %v, want %d", tt.name, dtz, ok, tt.dtz)
+		}
+	}
+
+	dtmDir := t.TempDir()
+	if err := GenerateDTM(dtmDir, "KQvK", "KRvK"); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"KQvK", "KRvK"} {
+		table, err := readDTM(filepath.Join(dtmDir, name+".dtm"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		squares := make([]int8, len(table.pieces))
+		for idx := 0; idx < table.size(); idx += 31 {
+			stm := table.decode(idx, squares)
+			if !dtmLegal(table.pieces, squares, stm) {
+				continue
+			}
+
+			wantWDL, wantDTZ := Draw, 0
+			if value := int(table.values[idx]); value%2 == 0 && value > 0 {
+				wantWDL, wantDTZ = Win, value-1
+			} else if value%2 == 1 {
+				wantWDL, wantDTZ = Loss, -max(value-1, 1)
+			}
+
+			fen := dtmFEN(table.pieces, squares, stm)
+			gs := mustFEN(t, fen)
+			if wdl, ok := gs.ProbeWDL(); !ok || wdl != wantWDL {
+				t.Fatalf("%s: ProbeWDL = %v, %v, want %v", fen, wdl, ok, wantWDL)
+			}
+			if dtz, ok := gs.ProbeDTZ(); !ok || !withinPly(dtz, wantDTZ) {
+				t.Fatalf("%s: ProbeDTZ = %d, %v, want %d within a ply", fen, dtz, ok, wantDTZ)
+			}
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package game
+
+// bound tells how a score stored in the transposition table relates to the value of its position
+type bound uint8
+
+const (
+	exactBound bound = iota + 1
+	lowerBound       // the search failed high, the value is at least the score
+	upperBound       // the search failed low, the value is at most the score
+)
+
+// ttEntry is the result of searching a position, kept to be reused when the position is reached again
+type ttEntry struct {
+	key   uint64
+	move  Move
+	score int32
+	depth int8
+	bound bound
+}
+
+// ttSize is the number of entries of the transposition table of each game, a power of two
+const ttSize = 1 << 16
+
+// probeTT returns the score of the position stored in the transposition table when it was searched at least as
+// deep and settles the window, and otherwise the best move found by the earlier search, to be tried first
+func (gs *GameState) probeTT(depth int8, ply int, a, b int) (int, Move, bool) {
+	if gs.tt == nil {
+		return 0, Move{}, false
+	}
+	entry := &gs.tt[gs.hash&(ttSize-1)]
+	if entry.key != gs.hash || entry.bound == 0 {
+		return 0, Move{}, false
+	}
+	if entry.depth < depth {
+		return 0, entry.move, false
+	}
+
+	score := scoreFromTT(int(entry.score), ply)
+	switch {
+	case entry.bound == exactBound,
+		entry.bound == lowerBound && score >= b,
+		entry.bound == upperBound && score <= a:
+		return score, entry.move, true
+	}
+	return 0, entry.move, false
+}
+
+// storeTT keeps the result of searching the position, replacing an entry of another position or of a shallower
+// search of the same one
+func (gs *GameState) storeTT(depth int8, ply int, score int, bound bound, move Move) {
+	if gs.tt == nil {
+		gs.tt = make([]ttEntry, ttSize)
+	}
+	entry := &gs.tt[gs.hash&(ttSize-1)]
+	if entry.key == gs.hash && entry.depth > depth {
+		return
+	}
+	*entry = ttEntry{key: gs.hash, move: move, score: int32(scoreToTT(score, ply)), depth: depth, bound: bound}
+}
+
+// pvFromTT leaves in pvTable[ply] the principal variation of a position whose score came from the transposition
+// table, following the best moves stored for it and the positions they lead to for up to depth plies
+func (gs *GameState) pvFromTT(ply int, depth int8) {
+	length := 0
+	for ; length < int(depth) && ply+length < maxPly; length++ {
+		entry := &gs.tt[gs.hash&(ttSize-1)]
+		if entry.key != gs.hash || entry.bound != exactBound || !gs.isLegal(entry.move) {
+			break
+		}
+		gs.pvTable[ply][length] = entry.move
+		gs.executeMove(entry.move.Origin, entry.move.Destination, entry.move.MoveType)
+	}
+	for i := 0; i < length; i++ {
+		gs.Undo()
+	}
+	gs.pvLength[ply] = length
+}
+
+// isLegal returns true if the move is one of the legal moves of the position
+func (gs *GameState) isLegal(move Move) bool {
+	var moves MoveList
+	gs.legalMoves(&moves)
+	for _, m := range moves.Moves() {
+		if m == move {
+			return true
+		}
+	}
+	return false
+}
+
+// clearTT forgets every position searched, whose scores no longer hold once the evaluation changes
+func (gs *GameState) clearTT() {
+	clear(gs.tt)
+}
+
+// moveToFront moves the move to the start of the list if it is in it, keeping the order of the others
+func (l *MoveList) moveToFront(move Move) {
+	for i, m := range l.Moves() {
+		if m == move {
+			copy(l.moves[1:i+1], l.moves[:i])
+			l.moves[0] = move
+			return
+		}
+	}
+}
@@ -0,0 +1,272 @@
+package game
+
+import (
+	"bufio"
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Texel tuning: the weights of the evaluation are fitted to the results of the games the positions were taken from,
+// by minimizing the squared error between the results and a logistic function of the evaluation
+
+// LabeledPosition is a position with the result of its game from white's perspective: 1 for a win, 0.5 for a draw
+// and 0 for a loss
+type LabeledPosition struct {
+	FEN    string
+	Result float64
+}
+
+var tuneResults = map[string]float64{
+	"1-0": 1, "0-1": 0, "1/2-1/2": 0.5,
+	"1.0": 1, "1": 1, "0.5": 0.5, "0.0": 0, "0": 0,
+}
+
+// LoadLabeledPositions reads a file with one position per line: a FEN followed by the result, either as 1-0, 0-1
+// and 1/2-1/2 or as [1.0], [0.5] and [0.0]. Quotes and EPD opcodes around the result are ignored
+func LoadLabeledPositions(path string) ([]LabeledPosition, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var positions []LabeledPosition
+	scanner := bufio.NewScanner(file)
+	for number := 1; scanner.Scan(); number++ {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		if len(fields) < 5 {
+			return nil, fmt.Errorf("%s:%d: expected a FEN and a result", path, number)
+		}
+
+		// 1. the FEN, with or without its move counters
+		n := 4
+		if len(fields) >= 7 {
+			if _, err := strconv.Atoi(fields[4]); err == nil {
+				if _, err := strconv.Atoi(fields[5]); err == nil {
+					n = 6
+				}
+			}
+		}
+		fen := strings.Join(fields[:n], " ")
+		if _, err := NewGameFromFEN(fen); err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, number, err)
+		}
+
+		// 2. the first word after it that reads as a result
+		result, found := 0.0, false
+		for _, field := range fields[n:] {
+			if result, found = tuneResults[strings.Trim(field, "\"[];")]; found {
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("%s:%d: no result", path, number)
+		}
+
+		positions = append(positions, LabeledPosition{FEN: fen, Result: result})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return positions, nil
+}
+
+// tuneTerm is one weight of the evaluation counted in a position, positive for white
+type tuneTerm struct {
+	index  int
+	weight float64
+}
+
+// tuneVector returns pointers to the weights being tuned: the piece values other than the king's, the piece-square
+// tables, then the mobility, king safety and pawn structure weights
+func (p *EvalParams) tuneVector() []*int {
+	var vector []*int
+	for pieceType := Queen; pieceType <= Pawn; pieceType++ {
+		vector = append(vector, &p.PieceValues[pieceType])
+	}
+	for pieceType := King; pieceType <= Pawn; pieceType++ {
+		for i := range p.PST[pieceType] {
+			vector = append(vector, &p.PST[pieceType][i])
+		}
+	}
+	for pieceType := Queen; pieceType < Pawn; pieceType++ {
+		vector = append(vector, &p.Mobility[pieceType])
+	}
+	vector = append(vector, &p.KingShield, &p.DoubledPawn, &p.IsolatedPawn)
+	for rank := range p.PassedPawn {
+		vector = append(vector, &p.PassedPawn[rank])
+	}
+	return vector
+}
+
+// indices into tuneVector of the first weight of each group
+const (
+	tunePST          = 5
+	tuneMobility     = tunePST + 6*64
+	tuneKingShield   = tuneMobility + 4
+	tuneDoubledPawn  = tuneKingShield + 1
+	tuneIsolatedPawn = tuneDoubledPawn + 1
+	tunePassedPawn   = tuneIsolatedPawn + 1
+)
+
+// tuneTerms returns the weights counted by the evaluation of the position, in the order of tuneVector
+func (gs *GameState) tuneTerms() []tuneTerm {
+	var terms []tuneTerm
+	for _, color := range []Color{White, Black} {
+		sign := float64(color)
+		for pieceType := King; pieceType <= Pawn; pieceType++ {
+			for b := gs.pieces[color.index()][pieceType]; b != 0; {
+				sq := b.popLsb()
+				if pieceType != King {
+					terms = append(terms, tuneTerm{int(pieceType) - 1, sign})
+				}
+				terms = append(terms, tuneTerm{tunePST + int(pieceType)*64 + pstIndex(color, sq), sign})
+			}
+		}
+
+		for pieceType, squares := range gs.mobility(color) {
+			if squares > 0 {
+				terms = append(terms, tuneTerm{tuneMobility + pieceType - 1, sign * float64(squares)})
+			}
+		}
+		if shield := gs.kingShield(color); shield > 0 {
+			terms = append(terms, tuneTerm{tuneKingShield, sign * float64(shield)})
+		}
+		doubled, isolated, passed := gs.pawnStructure(color)
+		if doubled > 0 {
+			terms = append(terms, tuneTerm{tuneDoubledPawn, sign * float64(doubled)})
+		}
+		if isolated > 0 {
+			terms = append(terms, tuneTerm{tuneIsolatedPawn, sign * float64(isolated)})
+		}
+		for rank, pawns := range passed {
+			if pawns > 0 {
+				terms = append(terms, tuneTerm{tunePassedPawn + rank, sign * float64(pawns)})
+			}
+		}
+	}
+	return terms
+}
+
+// quietLeaf plays out the principal variation of the quiescence search, so that the evaluation of the position
+// reached is the quiescence evaluation of the original one
+func (gs *GameState) quietLeaf() {
+	gs.quiesce(0, -infinity, infinity)
+	pv := append([]Move(nil), gs.pvTable[0][:gs.pvLength[0]]...)
+	for _, move := range pv {
+		gs.executeMove(move.Origin, move.Destination, move.MoveType)
+	}
+}
+
+// tuneError returns the mean squared error between the results and the predictions of the weights
+func tuneError(terms [][]tuneTerm, results []float64, weights []float64, k float64) float64 {
+	var sum float64
+	for i, position := range terms {
+		diff := results[i] - sigmoid(k*tuneEval(position, weights))
+		sum += diff * diff
+	}
+	return sum / float64(len(terms))
+}
+
+func tuneEval(terms []tuneTerm, weights []float64) float64 {
+	var eval float64
+	for _, term := range terms {
+		eval += term.weight * weights[term.index]
+	}
+	return eval
+}
+
+func sigmoid(x float64) float64 {
+	return 1 / (1 + math.Exp(-x))
+}
+
+// Tune fits the weights to the positions, starting from the given ones, with the given number of gradient descent
+// iterations. Each position is first resolved to the quiet position at the end of its quiescence search with the
+// starting weights. Progress is called after every iteration with the current error
+func Tune(positions []LabeledPosition, start *EvalParams, iterations int, progress func(iteration int, err float64)) (*EvalParams, error) {
+	if len(positions) == 0 {
+		return nil, fmt.Errorf("no positions to tune on")
+	}
+
+	// 1. the terms of the quiet position reached from each position
+	terms := make([][]tuneTerm, 0, len(positions))
+	results := make([]float64, 0, len(positions))
+	for _, position := range positions {
+		gs, err := NewGameFromFEN(position.FEN)
+		if err != nil {
+			return nil, err
+		}
+		gs.params = start
+		gs.quietLeaf()
+		if gs.pieces[0][King] == 0 || gs.pieces[1][King] == 0 {
+			continue
+		}
+		terms = append(terms, gs.tuneTerms())
+		results = append(results, position.Result)
+	}
+	if len(terms) == 0 {
+		return nil, fmt.Errorf("none of the %d positions has both kings left after its quiescence search", len(positions))
+	}
+
+	params := *start
+	vector := params.tuneVector()
+	// the weights are fitted in pawns, which the scale of the logistic function and the learning rate are set for
+	weights := make([]float64, len(vector))
+	for i, weight := range vector {
+		weights[i] = float64(*weight) / 100
+	}
+
+	// 2. the scale of the logistic function that best fits the starting weights
+	k, best := 1.0, math.Inf(1)
+	for step := 1.0; step >= 0.001; step /= 10 {
+		for candidate, last := math.Max(k-10*step, step), k+10*step; candidate <= last; candidate += step {
+			if err := tuneError(terms, results, weights, candidate); err < best {
+				k, best = candidate, err
+			}
+		}
+	}
+
+	// 3. Adam gradient descent on the weights
+	const (
+		rate  = 0.01
+		beta1 = 0.9
+		beta2 = 0.999
+	)
+	m := make([]float64, len(weights))
+	v := make([]float64, len(weights))
+	gradient := make([]float64, len(weights))
+	for iteration := 1; iteration <= iterations; iteration++ {
+		clear(gradient)
+		for i, position := range terms {
+			s := sigmoid(k * tuneEval(position, weights))
+			g := -2 * (results[i] - s) * s * (1 - s) * k / float64(len(terms))
+			for _, term := range position {
+				gradient[term.index] += g * term.weight
+			}
+		}
+
+		for i := range weights {
+			m[i] = beta1*m[i] + (1-beta1)*gradient[i]
+			v[i] = beta2*v[i] + (1-beta2)*gradient[i]*gradient[i]
+			mHat := m[i] / (1 - math.Pow(beta1, float64(iteration)))
+			vHat := v[i] / (1 - math.Pow(beta2, float64(iteration)))
+			weights[i] -= rate * mHat / (math.Sqrt(vHat) + 1e-8)
+		}
+
+		if progress != nil {
+			progress(iteration, tuneError(terms, results, weights, k))
+		}
+	}
+
+	for i, weight := range vector {
+		*weight = int(math.Round(weights[i] * 100))
+	}
+	return &params, nil
+}
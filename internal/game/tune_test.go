@@ -0,0 +1,148 @@
+package game
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestEvalParamsSaveLoad(t *testing.T) {
+	params := DefaultEvalParams()
+	params.PieceValues[Knight] = 320
+	params.PST[Pawn][52] = -7
+	params.Mobility[Bishop] = 4
+	params.KingShield = 12
+	params.PassedPawn[6] = 120
+
+	path := filepath.Join(t.TempDir(), "weights.txt")
+	if err := params.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadEvalParams(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *loaded != *params {
+		t.Errorf("LoadEvalParams read back %+v, want %+v", *loaded, *params)
+	}
+}
+
+func TestLoadEvalParams(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		check   func(p *EvalParams) bool
+		wantErr bool
+	}{
+		{"missing terms keep their default", "value queen 950 # stronger queen\n", func(p *EvalParams) bool {
+			return p.PieceValues[Queen] == 950 && p.PieceValues[Rook] == DefaultEvalParams().PieceValues[Rook]
+		}, false},
+		{"passed pawns by rank", "passed pawn 0 1 2 3 4 5 6 0", func(p *EvalParams) bool {
+			return p.PassedPawn == [8]int{0, 1, 2, 3, 4, 5, 6, 0}
+		}, false},
+		{"invalid number", "value queen lots", nil, true},
+		{"too few numbers", "passed pawn 1 2 3", nil, true},
+		{"unknown piece", "value dragon 1000", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "weights.txt")
+			if err := os.WriteFile(path, []byte(tt.content), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			params, err := LoadEvalParams(path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("LoadEvalParams error %v, want error %v", err, tt.wantErr)
+			}
+			if err == nil && !tt.check(params) {
+				t.Errorf("LoadEvalParams read %+v", *params)
+			}
+		})
+	}
+}
+
+func TestLoadLabeledPositions(t *testing.T) {
+	tests := []struct {
+		name    string
+		line    string
+		fen     string
+		result  float64
+		wantErr bool
+	}{
+		{"PGN result", StartFEN + " 1-0", StartFEN, 1, false},
+		{"bracketed result", "4k3/8/8/8/8/8/8/4K3 w - - [0.5]", "4k3/8/8/8/8/8/8/4K3 w - -", 0.5, false},
+		{"EPD opcode", `4k3/8/8/8/8/8/8/3QK3 w - - c9 "0-1";`, "4k3/8/8/8/8/8/8/3QK3 w - -", 0, false},
+		{"no result", "4k3/8/8/8/8/8/8/4K3 w - - bm Ke2;", "", 0, true},
+		{"invalid FEN", "4k3/8/8/8/8/8/8/4K4 w - - 1-0", "", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "positions.epd")
+			if err := os.WriteFile(path, []byte("# comment\n\n"+tt.line+"\n"), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			positions, err := LoadLabeledPositions(path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("LoadLabeledPositions error %v, want error %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if len(positions) != 1 || positions[0].FEN != tt.fen || positions[0].Result != tt.result {
+				t.Errorf("LoadLabeledPositions = %+v, want %s with result %v", positions, tt.fen, tt.result)
+			}
+		})
+	}
+}
+
+func TestTune(t *testing.T) {
+	// the side with the extra knight wins, so tuning from a worthless knight has to raise its value
+	positions := []LabeledPosition{
+		{"4k3/pppp4/8/8/8/8/PPPP4/1N2K3 w - - 0 1", 1},
+		{"4k3/pppp4/8/8/8/8/PPPP4/2N1K3 b - - 0 1", 1},
+		{"1n2k3/pppp4/8/8/8/8/PPPP4/4K3 w - - 0 1", 0},
+		{"2n1k3/pppp4/8/8/8/8/PPPP4/4K3 b - - 0 1", 0},
+		{"4k3/pppp4/8/8/8/8/PPPP4/4K3 w - - 0 1", 0.5},
+		{"1n2k3/pppp4/8/8/8/8/PPPP4/1N2K3 w - - 0 1", 0.5},
+	}
+	start := DefaultEvalParams()
+	start.PieceValues[Knight] = 0
+
+	var first, last float64
+	params, err := Tune(positions, start, 100, func(iteration int, err float64) {
+		if iteration == 1 {
+			first = err
+		}
+		last = err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if last >= first {
+		t.Errorf("error went from %v to %v, want it lower", first, last)
+	}
+	if params.PieceValues[Knight] <= 0 {
+		t.Errorf("knight value %d, want it raised above 0", params.PieceValues[Knight])
+	}
+	if start.PieceValues[Knight] != 0 {
+		t.Error("Tune changed the starting weights")
+	}
+
+	if _, err := Tune(nil, start, 1, nil); err == nil {
+		t.Error("Tune without positions succeeded")
+	}
+
+	// the player to move takes the king, which leaves nothing to tune on
+	kingTaken := []LabeledPosition{{"R3k3/8/8/8/8/8/8/4K3 w - - 0 1", 1}}
+	if params, err := Tune(kingTaken, start, 1, nil); err == nil {
+		t.Errorf("Tune without a position left succeeded with %+v", params.PieceValues)
+	}
+
+	// a single won position fits better the steeper the logistic function, which the scale search must not follow
+	// forever
+	if _, err := Tune([]LabeledPosition{positions[0]}, start, 1, nil); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,68 @@
+package game
+
+// Zobrist keys, xored together into the hash of a position
+var (
+	zobristPieces    [2][6][64]uint64
+	zobristCastling  [16]uint64
+	zobristEnPassant [8]uint64
+	zobristSide      uint64
+)
+
+func init() {
+	state := uint64(0x2545f4914f6cdd1d)
+	next := func() uint64 {
+		state ^= state >> 12
+		state ^= state << 25
+		state ^= state >> 27
+		return state * 0x9e3779b97f4a7c15
+	}
+
+	for color := range zobristPieces {
+		for pieceType := range zobristPieces[color] {
+			for sq := range zobristPieces[color][pieceType] {
+				zobristPieces[color][pieceType][sq] = next()
+			}
+		}
+	}
+	for i := range zobristCastling {
+		zobristCastling[i] = next()
+	}
+	for i := range zobristEnPassant {
+		zobristEnPassant[i] = next()
+	}
+	zobristSide = next()
+}
+
+// Hash returns the Zobrist hash of the position. Positions that repeat have the same hash
+func (gs *GameState) Hash() uint64 {
+	return gs.hash
+}
+
+// computeHash returns the hash of the position computed from scratch
+func (gs *GameState) computeHash() uint64 {
+	var hash uint64
+	for color := range gs.pieces {
+		for pieceType := range gs.pieces[color] {
+			for pieces := gs.pieces[color][pieceType]; pieces != 0; {
+				hash ^= zobristPieces[color][pieceType][pieces.popLsb()]
+			}
+		}
+	}
+	if gs.currColor == Black {
+		hash ^= zobristSide
+	}
+	return hash ^ gs.stateHash()
+}
+
+// stateHash returns the part of the hash covering the castling rights and the en passant square.
+// The en passant square only counts when the current player has a pawn that can capture on it
+func (gs *GameState) stateHash() uint64 {
+	hash := zobristCastling[gs.castling]
+	if gs.enPassantSquare != 0 {
+		square := to64[gs.enPassantSquare]
+		if pawnAttacks[(-gs.currColor).index()][square]&gs.pieces[gs.currColor.index()][Pawn] != 0 {
+			hash ^= zobristEnPassant[square%8]
+		}
+	}
+	return hash
+}
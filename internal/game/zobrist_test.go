@@ -0,0 +1,94 @@
+package game
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestHashIncremental(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for game := 0; game < 20; game++ {
+		gs := NewGame()
+		var fens []string
+		var hashes []uint64
+		for ply := 0; ply < 120; ply++ {
+			fens, hashes = append(fens, gs.FEN()), append(hashes, gs.Hash())
+			if !gs.ExecuteRandomMove(rng, nil) {
+				break
+			}
+			if got, want := gs.Hash(), gs.computeHash(); got != want {
+				t.Fatalf("after %v: hash %x, computed from scratch %x", gs.Moves(), got, want)
+			}
+		}
+
+		// undoing every move restores each position and its hash
+		for i := len(gs.Moves()) - 1; i >= 0; i-- {
+			if !gs.Undo() {
+				t.Fatalf("undo of move %d failed", i+1)
+			}
+			if got := gs.FEN(); got != fens[i] {
+				t.Fatalf("undo of move %d: %s, want %s", i+1, got, fens[i])
+			}
+			if got := gs.Hash(); got != hashes[i] {
+				t.Fatalf("undo of move %d: hash %x, want %x", i+1, got, hashes[i])
+			}
+		}
+		if gs.Undo() {
+			t.Fatal("undo succeeded at the start of the game")
+		}
+	}
+}
+
+func TestHashTranspositions(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b []string
+		same bool
+	}{
+		{"knights in either order", []string{"g1f3", "g8f6", "b1c3"}, []string{"b1c3", "g8f6", "g1f3"}, true},
+		{"knights out and back", []string{"g1f3", "g8f6", "f3g1", "f6g8"}, nil, true},
+		{"knights through other squares", []string{"g1f3", "g8f6", "f3g1"}, []string{"g1h3", "g8f6", "h3g1"}, true},
+		{"castling rights lost", []string{"e2e4", "e7e5", "e1e2", "e8e7", "e2e1", "e7e8"},
+			[]string{"e2e4", "e7e5", "g1f3", "g8f6", "f3g1", "f6g8"}, false},
+		{"en passant square", []string{"e2e4", "g8f6", "e4e5", "d7d5"},
+			[]string{"e2e3", "d7d6", "e3e4", "g8f6", "e4e5", "d6d5"}, false},
+		{"en passant nobody can take", []string{"e2e4", "g8f6", "g1f3", "f6g8", "f3g1"}, []string{"e2e4"}, true},
+	}
+
+	play := func(t *testing.T, moves []string) *GameState {
+		gs := NewGame()
+		for _, s := range moves {
+			move, err := gs.ParseMove(s)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := gs.Play(move); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return gs
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, b := play(t, tt.a), play(t, tt.b)
+			// positions meant to differ share the placement and the player to move, so the hashes can only differ
+			// on what the case is about
+			if fa, fb := strings.Fields(a.FEN()), strings.Fields(b.FEN()); !tt.same && (fa[0] != fb[0] || fa[1] != fb[1]) {
+				t.Fatalf("%v and %v: reach %s and %s", tt.a, tt.b, a.FEN(), b.FEN())
+			}
+			if same := a.Hash() == b.Hash(); same != tt.same {
+				t.Errorf("%v and %v: same hash %v, want %v", tt.a, tt.b, same, tt.same)
+			}
+		})
+	}
+}
+
+func TestHashSideToMove(t *testing.T) {
+	white := mustFEN(t, "4k3/8/8/8/8/8/8/4K2R w - - 0 1")
+	black := mustFEN(t, "4k3/8/8/8/8/8/8/4K2R b - - 0 1")
+	if white.Hash() == black.Hash() {
+		t.Errorf("the same position with either player to move has the same hash %x", white.Hash())
+	}
+}
@@ -0,0 +1,176 @@
+package match
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/alejandrodavidmalavet/GoChess/internal/game"
+)
+
+// Engine plays the moves of one side in the games of a match
+type Engine interface {
+	Name() string
+
+	// Move returns the move to play in the current position of the game, and its score in centipawns from the
+	// point of view of the player to move, mates scored as by game.MateIn. The clock holds the time both players
+	// have left
+	Move(g *Game, clock *Clock) (game.Move, int, error)
+
+	Close() error
+}
+
+// Player is an engine taking part in a match. New is called once for every game played in parallel
+type Player struct {
+	Name string
+	New  func() (Engine, error)
+}
+
+// Internal is this repository's engine, searching with its own evaluation weights and limits. It keeps its own copy
+// of the game, so that the transposition table it fills is never shared with, or cleared by, its opponent
+type Internal struct {
+	name   string
+	params *game.EvalParams
+	limits game.Limits
+
+	// the engine's copy of the game being played, and how many of its moves it holds
+	number int
+	state  *game.GameState
+	played int
+}
+
+// NewInternal returns an engine searching with the weights and limits. Nil weights are the defaults, and the
+// clock of a timed match further limits the time of each move
+func NewInternal(name string, params *game.EvalParams, limits game.Limits) *Internal {
+	if params == nil {
+		params = game.DefaultEvalParams()
+	}
+	return &Internal{name: name, params: params, limits: limits}
+}
+
+// InternalPlayer returns a player using the internal engine
+func InternalPlayer(name string, params *game.EvalParams, limits game.Limits) Player {
+	return Player{Name: name, New: func() (Engine, error) {
+		return NewInternal(name, params, limits), nil
+	}}
+}
+
+func (e *Internal) Name() string {
+	return e.name
+}
+
+func (e *Internal) Move(g *Game, clock *Clock) (game.Move, int, error) {
+	limits := e.limits
+	if allocation := clock.Allocation(g.SideToMove()); allocation > 0 && (limits.Time == 0 || allocation < limits.Time) {
+		limits.Time = allocation
+	}
+
+	if err := e.follow(g); err != nil {
+		return game.Move{}, 0, fmt.Errorf("%s: %w", e.name, err)
+	}
+	line, ok := e.state.Search(limits)
+	if !ok {
+		return game.Move{}, 0, fmt.Errorf("%s: no move found", e.name)
+	}
+	return line.Move, line.Score, nil
+}
+
+// follow brings the engine's copy of the game up to date with the moves played since its last move, starting over
+// from the opening for a new game
+func (e *Internal) follow(g *Game) error {
+	if e.state == nil || e.number != g.Number || e.played > len(g.Moves) {
+		state, err := game.NewGameFromFEN(g.Opening)
+		if err != nil {
+			return err
+		}
+		state.SetEvalParams(e.params)
+		e.number, e.state, e.played = g.Number, state, 0
+	}
+	for ; e.played < len(g.Moves); e.played++ {
+		if err := e.state.Play(g.Moves[e.played]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *Internal) Close() error {
+	return nil
+}
+
+// TimeControl is the time each player has for the game and the time added after each of their moves.
+// A zero base time means the games are not timed
+type TimeControl struct {
+	Base      time.Duration
+	Increment time.Duration
+}
+
+// ParseTimeControl reads a time control written as seconds plus increment, e.g. 10+0.1 or 60
+func ParseTimeControl(s string) (TimeControl, error) {
+	var base, increment float64
+	if _, err := fmt.Sscanf(s, "%g+%g", &base, &increment); err != nil {
+		if _, err := fmt.Sscanf(s, "%g", &base); err != nil {
+			return TimeControl{}, fmt.Errorf("time control %q: expected seconds+increment, e.g. 10+0.1", s)
+		}
+	}
+	if base <= 0 || increment < 0 {
+		return TimeControl{}, fmt.Errorf("time control %q: times must be positive", s)
+	}
+	return TimeControl{
+		Base:      time.Duration(base * float64(time.Second)),
+		Increment: time.Duration(increment * float64(time.Second)),
+	}, nil
+}
+
+func (tc TimeControl) String() string {
+	if tc.Base == 0 {
+		return "none"
+	}
+	return fmt.Sprintf("%g+%g", tc.Base.Seconds(), tc.Increment.Seconds())
+}
+
+// Clock keeps the time left of both players, white first
+type Clock struct {
+	TimeControl
+	Remaining [2]time.Duration
+}
+
+// NewClock returns a clock with the full base time for both players
+func NewClock(tc TimeControl) *Clock {
+	return &Clock{TimeControl: tc, Remaining: [2]time.Duration{tc.Base, tc.Base}}
+}
+
+// Timed returns true if the game is played on the clock
+func (c *Clock) Timed() bool {
+	return c.Base > 0
+}
+
+// Allocation returns the time a player should spend on their move: a share of the time left plus most of the
+// increment, keeping a reserve against overshooting. Zero if the game is not timed
+func (c *Clock) Allocation(color game.Color) time.Duration {
+	if !c.Timed() {
+		return 0
+	}
+	remaining := c.Remaining[side(color)]
+	return min(remaining/30+c.Increment*3/4, remaining/2)
+}
+
+// Spend takes the time of a move off the player's clock and adds the increment. It returns false if their flag fell
+func (c *Clock) Spend(color game.Color, elapsed time.Duration) bool {
+	if !c.Timed() {
+		return true
+	}
+	c.Remaining[side(color)] -= elapsed
+	if c.Remaining[side(color)] < 0 {
+		return false
+	}
+	c.Remaining[side(color)] += c.Increment
+	return true
+}
+
+// side returns the index of the color, white first
+func side(color game.Color) int {
+	if color == game.White {
+		return 0
+	}
+	return 1
+}
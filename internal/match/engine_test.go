@@ -0,0 +1,58 @@
+package match
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alejandrodavidmalavet/GoChess/internal/game"
+)
+
+func TestParseTimeControl(t *testing.T) {
+	tests := []struct {
+		s    string
+		want TimeControl
+		ok   bool
+	}{
+		{"10+0.1", TimeControl{10 * time.Second, 100 * time.Millisecond}, true},
+		{"60", TimeControl{Base: time.Minute}, true},
+		{"0.5+0", TimeControl{Base: 500 * time.Millisecond}, true},
+		{"180+2", TimeControl{3 * time.Minute, 2 * time.Second}, true},
+		{"", TimeControl{}, false},
+		{"fast", TimeControl{}, false},
+		{"0+1", TimeControl{}, false},
+		{"-5", TimeControl{}, false},
+		{"10+-1", TimeControl{}, false},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseTimeControl(tt.s)
+		if (err == nil) != tt.ok {
+			t.Errorf("ParseTimeControl(%q): error %v, want ok %v", tt.s, err, tt.ok)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseTimeControl(%q) = %+v, want %+v", tt.s, got, tt.want)
+		}
+		if tt.ok && got.String() != tt.s && got.String() != tt.s+"+0" {
+			t.Errorf("ParseTimeControl(%q) prints as %s", tt.s, got)
+		}
+	}
+}
+
+func TestClock(t *testing.T) {
+	clock := NewClock(TimeControl{Base: 10 * time.Second, Increment: time.Second})
+	if got, want := clock.Allocation(game.White), 10*time.Second/30+time.Second*3/4; got != want {
+		t.Errorf("allocation %v, want %v", got, want)
+	}
+	if !clock.Spend(game.White, 3*time.Second) || clock.Remaining != [2]time.Duration{8 * time.Second, 10 * time.Second} {
+		t.Errorf("after 3s of white's: %v, want 8s and 10s left", clock.Remaining)
+	}
+	if clock.Spend(game.Black, 11*time.Second) {
+		t.Errorf("black spent 11s of 10s without losing on time")
+	}
+
+	untimed := NewClock(TimeControl{})
+	if untimed.Timed() || untimed.Allocation(game.White) != 0 || !untimed.Spend(game.White, time.Hour) {
+		t.Errorf("a game without a clock is timed")
+	}
+}
@@ -0,0 +1,287 @@
+package match
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/alejandrodavidmalavet/GoChess/internal/game"
+)
+
+// Game is one game of a match
+type Game struct {
+	Number       int
+	Opening      string // FEN of the starting position
+	White, Black string
+	State        *game.GameState
+	Moves        []game.Move
+	Result       game.GameResult
+	Reason       string
+}
+
+// SideToMove returns the color of the player to move
+func (g *Game) SideToMove() game.Color {
+	return g.State.SideToMove()
+}
+
+// Adjudication ends games early once both engines agree on the outcome. Zero values disable each rule
+type Adjudication struct {
+	// a game is lost once both engines score it at least ResignScore against the same side for ResignMoves
+	// moves in a row each
+	ResignScore int // centipawns
+	ResignMoves int
+
+	// a game is drawn from move DrawMoveNumber once both engines score it within DrawScore of equal for
+	// DrawMoves moves in a row each
+	DrawScore      int // centipawns
+	DrawMoves      int
+	DrawMoveNumber int
+
+	// a game reaching MaxMoves full moves is drawn
+	MaxMoves int
+}
+
+// DefaultAdjudication resigns lost games at 8 pawns, draws quiet games from move 40 and stops games at move 200
+var DefaultAdjudication = Adjudication{
+	ResignScore: 800, ResignMoves: 3,
+	DrawScore: 10, DrawMoves: 8, DrawMoveNumber: 40,
+	MaxMoves: 200,
+}
+
+// Options configure a match
+type Options struct {
+	Games        int // rounded up to an even number, each opening being played with both colors
+	Concurrency  int
+	Openings     []string // FENs, played in turn
+	TimeControl  TimeControl
+	Adjudication Adjudication
+
+	// SPRT stops the match as soon as it accepts or rejects the change, if set
+	SPRT *SPRT
+
+	// Progress is called after every game with the score so far
+	Progress func(g *Game, stats Stats)
+}
+
+// Report is the outcome of a match
+type Report struct {
+	Stats Stats
+
+	// the final state of the SPRT, if one was run
+	LLR    float64
+	Status SPRTStatus
+}
+
+// Run plays a match between two players and returns the score of the first. Games are played in pairs from the
+// same opening with the colors swapped
+func Run(players [2]Player, options Options) (Report, error) {
+	if len(options.Openings) == 0 {
+		options.Openings = DefaultOpenings
+	}
+	concurrency := max(options.Concurrency, 1)
+	games := options.Games + options.Games%2
+
+	var (
+		mu       sync.Mutex
+		report   Report
+		firstErr error
+		wg       sync.WaitGroup
+		once     sync.Once
+	)
+	jobs := make(chan int)
+	done := make(chan struct{})
+	stop := func() { once.Do(func() { close(done) }) }
+
+	// 1. every worker has its own pair of engines and takes the next game number
+	for w := 0; w < concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			var engines [2]Engine
+			for i, player := range players {
+				engine, err := player.New()
+				if err != nil {
+					// without its engines the worker takes no games, so the match ends here
+					mu.Lock()
+					if firstErr == nil {
+						firstErr = fmt.Errorf("%s: %w", player.Name, err)
+					}
+					mu.Unlock()
+					stop()
+					return
+				}
+				defer engine.Close()
+				engines[i] = engine
+			}
+
+			for number := range jobs {
+				g, first, err := playGame(engines, number, options)
+				if err != nil {
+					mu.Lock()
+					if firstErr == nil {
+						firstErr = err
+					}
+					mu.Unlock()
+					continue
+				}
+
+				mu.Lock()
+				report.Stats.add(g.Result, first)
+				if options.Progress != nil {
+					options.Progress(g, report.Stats)
+				}
+				if options.SPRT != nil {
+					report.LLR = options.SPRT.LLR(report.Stats)
+					if report.Status = options.SPRT.Status(report.Stats); report.Status != SPRTContinue {
+						stop()
+					}
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+	// 2. hand out the games until they are all played, the SPRT has concluded or a worker has failed
+feed:
+	for number := 0; number < games; number++ {
+		select {
+		case jobs <- number:
+		case <-done:
+			break feed
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	if firstErr != nil {
+		return report, firstErr
+	}
+	return report, nil
+}
+
+// playGame plays the game with the given number and returns it along with the color of the first player
+func playGame(engines [2]Engine, number int, options Options) (*Game, game.Color, error) {
+	opening := options.Openings[(number/2)%len(options.Openings)]
+	state, err := game.NewGameFromFEN(opening)
+	if err != nil {
+		return nil, 0, fmt.Errorf("opening %q: %w", opening, err)
+	}
+
+	// the first player is white in even games and black in odd ones
+	first := game.White
+	white, black := engines[0], engines[1]
+	if number%2 == 1 {
+		first = game.Black
+		white, black = black, white
+	}
+
+	g := &Game{Number: number + 1, Opening: opening, White: white.Name(), Black: black.Name(), State: state}
+	clock := NewClock(options.TimeControl)
+	adjudicator := adjudicator{Adjudication: options.Adjudication}
+
+	for {
+		if result, reason := state.Result(); result != game.Unfinished {
+			g.Result, g.Reason = result, reason
+			return g, first, nil
+		}
+		if result, reason := adjudicator.maxMoves(g); result != game.Unfinished {
+			g.Result, g.Reason = result, reason
+			return g, first, nil
+		}
+
+		color := state.SideToMove()
+		engine := white
+		if color == game.Black {
+			engine = black
+		}
+
+		start := time.Now()
+		move, score, err := engine.Move(g, clock)
+		elapsed := time.Since(start)
+
+		switch {
+		case err != nil:
+			g.Result, g.Reason = loss(color), fmt.Sprintf("%s failed: %v", engine.Name(), err)
+		case !clock.Spend(color, elapsed):
+			g.Result, g.Reason = loss(color), engine.Name()+" lost on time"
+		case state.Play(move) != nil:
+			g.Result, g.Reason = loss(color), fmt.Sprintf("%s played the illegal move %v", engine.Name(), move)
+		}
+		if g.Result != game.Unfinished {
+			return g, first, nil
+		}
+		g.Moves = append(g.Moves, move)
+
+		if result, reason := adjudicator.score(color, score); result != game.Unfinished {
+			g.Result, g.Reason = result, reason
+			return g, first, nil
+		}
+	}
+}
+
+// loss returns the result of a game lost by the color
+func loss(color game.Color) game.GameResult {
+	if color == game.White {
+		return game.BlackWon
+	}
+	return game.WhiteWon
+}
+
+// adjudicator follows the scores reported during a game
+type adjudicator struct {
+	Adjudication
+	moves       int
+	resignSide  game.Color
+	resignCount int
+	drawCount   int
+}
+
+// score records the score of a move from the mover's point of view and returns the adjudicated result, if any
+func (a *adjudicator) score(color game.Color, score int) (game.GameResult, string) {
+	a.moves++
+
+	// 1. resignation: the scores of both engines, turned to white's point of view, agree on a loser
+	white := score * int(color)
+	switch {
+	case a.ResignMoves == 0 || abs(white) < a.ResignScore:
+		a.resignCount = 0
+	case white < 0 && a.resignSide == game.White, white > 0 && a.resignSide == game.Black:
+		a.resignCount++
+	default:
+		a.resignSide, a.resignCount = game.Black, 1
+		if white < 0 {
+			a.resignSide = game.White
+		}
+	}
+	if a.ResignMoves > 0 && a.resignCount >= 2*a.ResignMoves {
+		return loss(a.resignSide), "adjudicated loss"
+	}
+
+	// 2. draw: a quiet position past the opening
+	if a.DrawMoves > 0 && (a.moves+1)/2 >= a.DrawMoveNumber && abs(score) <= a.DrawScore {
+		a.drawCount++
+	} else {
+		a.drawCount = 0
+	}
+	if a.DrawMoves > 0 && a.drawCount >= 2*a.DrawMoves {
+		return game.Drawn, "adjudicated draw"
+	}
+
+	return game.Unfinished, ""
+}
+
+// maxMoves returns a draw once the game is too long
+func (a *adjudicator) maxMoves(g *Game) (game.GameResult, string) {
+	if a.MaxMoves > 0 && len(g.Moves) >= 2*a.MaxMoves {
+		return game.Drawn, "move limit"
+	}
+	return game.Unfinished, ""
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
@@ -0,0 +1,130 @@
+package match
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/alejandrodavidmalavet/GoChess/internal/game"
+)
+
+// scripted is an engine playing the first legal move with a fixed score from its own point of view, or failing
+type scripted struct {
+	name  string
+	score int
+	err   error
+}
+
+func (e *scripted) Name() string {
+	return e.name
+}
+
+func (e *scripted) Move(g *Game, clock *Clock) (game.Move, int, error) {
+	if e.err != nil {
+		return game.Move{}, 0, e.err
+	}
+	return g.State.LegalMoves()[0], e.score, nil
+}
+
+func (e *scripted) Close() error {
+	return nil
+}
+
+func TestAdjudication(t *testing.T) {
+	tests := []struct {
+		name         string
+		scores       [2]int // of the first and second player, from their own point of view
+		number       int
+		adjudication Adjudication
+		result       game.GameResult
+		reason       string
+		plies        int
+	}{
+		{"resign", [2]int{900, -900}, 0, Adjudication{ResignScore: 800, ResignMoves: 2}, game.WhiteWon, "adjudicated loss", 4},
+		{"resign with colors swapped", [2]int{900, -900}, 1, Adjudication{ResignScore: 800, ResignMoves: 2},
+			game.BlackWon, "adjudicated loss", 4},
+		{"resign takes both engines", [2]int{900, 900}, 0, Adjudication{ResignScore: 800, ResignMoves: 2, MaxMoves: 5},
+			game.Drawn, "move limit", 10},
+		{"resign below the score", [2]int{700, -700}, 0, Adjudication{ResignScore: 800, ResignMoves: 2, MaxMoves: 5},
+			game.Drawn, "move limit", 10},
+		{"draw", [2]int{0, 5}, 0, Adjudication{DrawScore: 10, DrawMoves: 2, DrawMoveNumber: 3}, game.Drawn, "adjudicated draw", 8},
+		{"draw outside the score", [2]int{0, 50}, 0, Adjudication{DrawScore: 10, DrawMoves: 2, DrawMoveNumber: 3, MaxMoves: 5},
+			game.Drawn, "move limit", 10},
+		{"move limit", [2]int{0, 0}, 0, Adjudication{MaxMoves: 3}, game.Drawn, "move limit", 6},
+	}
+
+	start := []string{game.StartFEN}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			engines := [2]Engine{&scripted{name: "first", score: tt.scores[0]}, &scripted{name: "second", score: tt.scores[1]}}
+			g, _, err := playGame(engines, tt.number, Options{Openings: start, Adjudication: tt.adjudication})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if g.Result != tt.result || g.Reason != tt.reason || len(g.Moves) != tt.plies {
+				t.Errorf("%v by %q after %d plies, want %v by %q after %d", g.Result, g.Reason, len(g.Moves),
+					tt.result, tt.reason, tt.plies)
+			}
+		})
+	}
+}
+
+func TestPlayGameFailure(t *testing.T) {
+	engines := [2]Engine{&scripted{name: "first"}, &scripted{name: "second", err: errors.New("crashed")}}
+	g, first, err := playGame(engines, 0, Options{Openings: []string{game.StartFEN}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first != game.White || g.Result != game.WhiteWon || !strings.Contains(g.Reason, "second failed") {
+		t.Errorf("first player %v, %v by %q, want white winning as second failed", first, g.Result, g.Reason)
+	}
+}
+
+func TestRun(t *testing.T) {
+	players := [2]Player{
+		InternalPlayer("depth 2", nil, game.Limits{Depth: 2}),
+		InternalPlayer("depth 1", nil, game.Limits{Depth: 1}),
+	}
+
+	var mu sync.Mutex
+	var games []*Game
+	report, err := Run(players, Options{
+		Games:        5,
+		Concurrency:  3,
+		Openings:     DefaultOpenings[:2],
+		Adjudication: Adjudication{MaxMoves: 20},
+		Progress: func(g *Game, stats Stats) {
+			mu.Lock()
+			defer mu.Unlock()
+			games = append(games, g)
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the games are rounded up to pairs, each opening played with both colors
+	if report.Stats.Games() != 6 || len(games) != 6 {
+		t.Fatalf("%d games reported and %d played, want 6", report.Stats.Games(), len(games))
+	}
+	sort.Slice(games, func(i, j int) bool { return games[i].Number < games[j].Number })
+	for i, g := range games {
+		first, opening := "depth 2", DefaultOpenings[i/2%2]
+		if i%2 == 1 {
+			first = "depth 1"
+		}
+		if g.Number != i+1 || g.White != first || g.Opening != opening || g.Result == game.Unfinished {
+			t.Errorf("game %d: %s against %s from %s, %v", g.Number, g.White, g.Black, g.Opening, g.Result)
+		}
+	}
+}
+
+func TestRunEngineFailure(t *testing.T) {
+	broken := Player{Name: "broken", New: func() (Engine, error) { return nil, errors.New("no such engine") }}
+	players := [2]Player{InternalPlayer("GoChess", nil, game.Limits{Depth: 1}), broken}
+	if _, err := Run(players, Options{Games: 4, Concurrency: 2}); err == nil || !strings.Contains(err.Error(), "broken") {
+		t.Errorf("Run with an engine that cannot start: error %v", err)
+	}
+}
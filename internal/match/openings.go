@@ -0,0 +1,89 @@
+package match
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/alejandrodavidmalavet/GoChess/internal/game"
+)
+
+// openingLines are short main lines of common openings in coordinate notation
+var openingLines = []string{
+	"e2e4 e7e5 g1f3 b8c6 f1b5 a7a6",
+	"e2e4 e7e5 g1f3 b8c6 f1c4 f8c5",
+	"e2e4 c7c5 g1f3 d7d6 d2d4 c5d4 f3d4 g8f6",
+	"e2e4 c7c5 b1c3 b8c6 g2g3",
+	"e2e4 e7e6 d2d4 d7d5 b1c3 g8f6",
+	"e2e4 c7c6 d2d4 d7d5 e4e5 c8f5",
+	"e2e4 d7d6 d2d4 g8f6 b1c3 g7g6",
+	"d2d4 d7d5 c2c4 e7e6 b1c3 g8f6",
+	"d2d4 d7d5 c2c4 c7c6 g1f3 g8f6",
+	"d2d4 g8f6 c2c4 g7g6 b1c3 f8g7 e2e4 d7d6",
+	"d2d4 g8f6 c2c4 e7e6 b1c3 f8b4",
+	"d2d4 f7f5 g2g3 g8f6 f1g2 g7g6",
+	"c2c4 e7e5 b1c3 g8f6 g1f3 b8c6",
+	"g1f3 d7d5 g2g3 g8f6 f1g2 c7c6",
+	"e2e4 e7e5 f2f4 e5f4 g1f3",
+	"d2d4 d7d5 c1f4 g8f6 e2e3 c7c5",
+}
+
+// DefaultOpenings are the FENs reached by the built-in opening lines
+var DefaultOpenings = func() []string {
+	openings := make([]string, 0, len(openingLines))
+	for _, line := range openingLines {
+		gs := game.NewGame()
+		for _, s := range strings.Fields(line) {
+			move, err := gs.ParseMove(s)
+			if err != nil {
+				panic(fmt.Sprintf("opening %q: %v", line, err))
+			}
+			gs.Play(move)
+		}
+		openings = append(openings, gs.FEN())
+	}
+	return openings
+}()
+
+// LoadOpenings reads a file with one FEN or EPD position per line
+func LoadOpenings(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var openings []string
+	scanner := bufio.NewScanner(file)
+	for number := 1; scanner.Scan(); number++ {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		if len(fields) < 4 {
+			return nil, fmt.Errorf("%s:%d: expected a FEN", path, number)
+		}
+
+		// EPD lines carry operations instead of the move counters
+		n := 4
+		if len(fields) >= 6 {
+			if _, err := strconv.Atoi(fields[4]); err == nil {
+				n = 6
+			}
+		}
+		fen := strings.Join(fields[:n], " ")
+		if _, err := game.NewGameFromFEN(fen); err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, number, err)
+		}
+		openings = append(openings, fen)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(openings) == 0 {
+		return nil, fmt.Errorf("%s: no openings", path)
+	}
+	return openings, nil
+}
@@ -0,0 +1,68 @@
+package match
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/alejandrodavidmalavet/GoChess/internal/game"
+)
+
+func TestLoadOpenings(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []string
+		ok      bool
+	}{
+		{"FENs", "rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq - 0 1\n" +
+			"rnbqkbnr/pppp1ppp/8/4p3/4P3/8/PPPP1PPP/RNBQKBNR w KQkq - 0 2\n", []string{
+			"rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq - 0 1",
+			"rnbqkbnr/pppp1ppp/8/4p3/4P3/8/PPPP1PPP/RNBQKBNR w KQkq - 0 2",
+		}, true},
+		{"EPD with operations", "rnbqkbnr/pppppppp/8/8/3P4/8/PPP1PPPP/RNBQKBNR b KQkq - id \"d4\"; c0 \"queen's pawn\";\n",
+			[]string{"rnbqkbnr/pppppppp/8/8/3P4/8/PPP1PPPP/RNBQKBNR b KQkq -"}, true},
+		{"comments and blank lines", "# openings\n\n4k3/8/8/8/8/8/8/4K2R w K - 0 1\n",
+			[]string{"4k3/8/8/8/8/8/8/4K2R w K - 0 1"}, true},
+		{"too few fields", "4k3/8/8/8/8/8/8/4K2R w\n", nil, false},
+		{"invalid FEN", "4k3/8/8/8/8/8/8/4K2R w X - 0 1\n", nil, false},
+		{"no openings", "# nothing\n", nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "openings.epd")
+			if err := os.WriteFile(path, []byte(tt.content), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			openings, err := LoadOpenings(path)
+			if (err == nil) != tt.ok {
+				t.Fatalf("error %v, want ok %v", err, tt.ok)
+			}
+			if !reflect.DeepEqual(openings, tt.want) {
+				t.Errorf("got %q, want %q", openings, tt.want)
+			}
+		})
+	}
+
+	if _, err := LoadOpenings(filepath.Join(t.TempDir(), "missing.epd")); err == nil {
+		t.Error("a missing file loaded")
+	}
+}
+
+func TestDefaultOpenings(t *testing.T) {
+	if len(DefaultOpenings) != len(openingLines) {
+		t.Fatalf("%d openings from %d lines", len(DefaultOpenings), len(openingLines))
+	}
+	seen := make(map[string]bool)
+	for _, fen := range DefaultOpenings {
+		if _, err := game.NewGameFromFEN(fen); err != nil {
+			t.Errorf("%s: %v", fen, err)
+		}
+		if seen[fen] {
+			t.Errorf("%s is played twice", fen)
+		}
+		seen[fen] = true
+	}
+}
@@ -0,0 +1,121 @@
+package match
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/alejandrodavidmalavet/GoChess/internal/game"
+)
+
+// Stats counts the results of a match from the first player's point of view
+type Stats struct {
+	Wins, Draws, Losses int
+}
+
+// add counts the result of a game where the first player had the given color
+func (s *Stats) add(result game.GameResult, first game.Color) {
+	switch {
+	case result == game.Drawn:
+		s.Draws++
+	case (result == game.WhiteWon) == (first == game.White):
+		s.Wins++
+	default:
+		s.Losses++
+	}
+}
+
+// Games returns the number of games played
+func (s Stats) Games() int {
+	return s.Wins + s.Draws + s.Losses
+}
+
+// Score returns the average score of the first player, 1 for a win and 0.5 for a draw
+func (s Stats) Score() float64 {
+	if s.Games() == 0 {
+		return 0.5
+	}
+	return (float64(s.Wins) + 0.5*float64(s.Draws)) / float64(s.Games())
+}
+
+// variance returns the variance of the score of a single game
+func (s Stats) variance() float64 {
+	n := float64(s.Games())
+	mean := s.Score()
+	w, d, l := float64(s.Wins)/n, float64(s.Draws)/n, float64(s.Losses)/n
+	return w*(1-mean)*(1-mean) + d*(0.5-mean)*(0.5-mean) + l*mean*mean
+}
+
+// eloFromScore returns the Elo difference that predicts the expected score
+func eloFromScore(score float64) float64 {
+	score = math.Min(math.Max(score, 1e-6), 1-1e-6)
+	return -400 * math.Log10(1/score-1)
+}
+
+// scoreFromElo returns the expected score of a player stronger by the Elo difference
+func scoreFromElo(elo float64) float64 {
+	return 1 / (1 + math.Pow(10, -elo/400))
+}
+
+// Elo returns the Elo difference of the first player and the margin of its 95% confidence interval
+func (s Stats) Elo() (float64, float64) {
+	if s.Games() == 0 {
+		return 0, math.Inf(1)
+	}
+	score := s.Score()
+	margin := 1.959964 * math.Sqrt(s.variance()/float64(s.Games()))
+	return eloFromScore(score), (eloFromScore(score+margin) - eloFromScore(score-margin)) / 2
+}
+
+func (s Stats) String() string {
+	elo, margin := s.Elo()
+	return fmt.Sprintf("W %d D %d L %d, score %.1f%%, Elo %+.1f ± %.1f", s.Wins, s.Draws, s.Losses, 100*s.Score(), elo, margin)
+}
+
+// SPRT is a sequential probability ratio test of the hypothesis that the first player is stronger by Elo1 against
+// the hypothesis that it is stronger by Elo0, with the given false positive and false negative rates
+type SPRT struct {
+	Elo0, Elo1  float64
+	Alpha, Beta float64
+}
+
+// SPRTStatus is the state of a test
+type SPRTStatus int
+
+const (
+	SPRTContinue SPRTStatus = iota
+	SPRTPass
+	SPRTFail
+)
+
+func (s SPRTStatus) String() string {
+	return [...]string{"continue", "pass", "fail"}[s]
+}
+
+// LLR returns the log likelihood ratio of the results, in the normal approximation of the game scores
+func (t SPRT) LLR(s Stats) float64 {
+	variance := s.variance()
+	if s.Games() == 0 || variance == 0 {
+		return 0
+	}
+	score := s.Score()
+	s0, s1 := scoreFromElo(t.Elo0), scoreFromElo(t.Elo1)
+	return float64(s.Games()) / (2 * variance) * ((score-s0)*(score-s0) - (score-s1)*(score-s1))
+}
+
+// Bounds returns the LLR below which the test fails and above which it passes
+func (t SPRT) Bounds() (float64, float64) {
+	return math.Log(t.Beta / (1 - t.Alpha)), math.Log((1 - t.Beta) / t.Alpha)
+}
+
+// Status returns whether the results accept, reject or do not yet decide the test
+func (t SPRT) Status(s Stats) SPRTStatus {
+	llr := t.LLR(s)
+	lower, upper := t.Bounds()
+	switch {
+	case llr >= upper:
+		return SPRTPass
+	case llr <= lower:
+		return SPRTFail
+	}
+	return SPRTContinue
+}
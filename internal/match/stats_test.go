@@ -0,0 +1,81 @@
+package match
+
+import (
+	"math"
+	"testing"
+
+	"github.com/alejandrodavidmalavet/GoChess/internal/game"
+)
+
+func TestStatsElo(t *testing.T) {
+	tests := []struct {
+		stats       Stats
+		elo, margin float64
+	}{
+		{Stats{60, 20, 20}, 147.191, 66.013},
+		{Stats{10, 80, 10}, 0, 30.532},
+		{Stats{30, 40, 50}, -58.451, 51.512},
+		{Stats{500, 0, 500}, 0, 21.562},
+	}
+
+	for _, tt := range tests {
+		elo, margin := tt.stats.Elo()
+		if math.Abs(elo-tt.elo) > 0.001 || math.Abs(margin-tt.margin) > 0.001 {
+			t.Errorf("%+v: Elo %.3f ± %.3f, want %.3f ± %.3f", tt.stats, elo, margin, tt.elo, tt.margin)
+		}
+	}
+
+	if elo, margin := (Stats{}).Elo(); elo != 0 || !math.IsInf(margin, 1) {
+		t.Errorf("no games: Elo %v ± %v, want 0 ± infinity", elo, margin)
+	}
+}
+
+func TestStatsAdd(t *testing.T) {
+	var s Stats
+	s.add(game.WhiteWon, game.White)
+	s.add(game.BlackWon, game.Black)
+	s.add(game.BlackWon, game.White)
+	s.add(game.Drawn, game.Black)
+	if want := (Stats{Wins: 2, Draws: 1, Losses: 1}); s != want {
+		t.Errorf("got %+v, want %+v", s, want)
+	}
+	if s.Games() != 4 || s.Score() != 0.625 {
+		t.Errorf("%d games scoring %v, want 4 scoring 0.625", s.Games(), s.Score())
+	}
+}
+
+func TestSPRT(t *testing.T) {
+	tests := []struct {
+		sprt   SPRT
+		stats  Stats
+		llr    float64
+		status SPRTStatus
+	}{
+		{SPRT{0, 5, 0.05, 0.05}, Stats{60, 20, 20}, 0.8832, SPRTContinue},
+		{SPRT{0, 5, 0.05, 0.05}, Stats{30, 40, 50}, -0.4699, SPRTContinue},
+		{SPRT{0, 5, 0.05, 0.05}, Stats{600, 200, 200}, 8.8321, SPRTPass},
+		{SPRT{0, 5, 0.05, 0.05}, Stats{300, 400, 500}, -4.6992, SPRTFail},
+		{SPRT{0, 10, 0.05, 0.05}, Stats{520, 960, 480}, 0.6661, SPRTContinue},
+		{SPRT{-5, 5, 0.05, 0.05}, Stats{100, 100, 100}, 0, SPRTContinue},
+		{SPRT{0, 5, 0.05, 0.05}, Stats{}, 0, SPRTContinue},
+		{SPRT{0, 5, 0.05, 0.05}, Stats{Wins: 50}, 0, SPRTContinue}, // no variance to test against
+	}
+
+	for _, tt := range tests {
+		if llr := tt.sprt.LLR(tt.stats); math.Abs(llr-tt.llr) > 0.0001 {
+			t.Errorf("%+v, %+v: LLR %.4f, want %.4f", tt.sprt, tt.stats, llr, tt.llr)
+		}
+		if status := tt.sprt.Status(tt.stats); status != tt.status {
+			t.Errorf("%+v, %+v: %v, want %v", tt.sprt, tt.stats, status, tt.status)
+		}
+	}
+
+	lower, upper := SPRT{0, 5, 0.05, 0.05}.Bounds()
+	if math.Abs(lower+2.9444) > 0.0001 || math.Abs(upper-2.9444) > 0.0001 {
+		t.Errorf("bounds %.4f and %.4f, want -2.9444 and 2.9444", lower, upper)
+	}
+	lower, upper = SPRT{0, 5, 0.05, 0.1}.Bounds()
+	if math.Abs(lower-math.Log(0.1/0.95)) > 1e-9 || math.Abs(upper-math.Log(0.9/0.05)) > 1e-9 {
+		t.Errorf("bounds %.4f and %.4f, want %.4f and %.4f", lower, upper, math.Log(0.1/0.95), math.Log(0.9/0.05))
+	}
+}
@@ -0,0 +1,247 @@
+package match
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/alejandrodavidmalavet/GoChess/internal/game"
+)
+
+// uciTimeout is how long an engine may take beyond its own time to answer, before it is considered hung
+const uciTimeout = 5 * time.Second
+
+// UCI is an external engine run as a separate process and driven over its stdin and stdout
+type UCI struct {
+	name   string
+	limits game.Limits
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	lines  chan string
+	number int
+	score  int
+}
+
+// NewUCI starts the engine command and sets its options. Without a clock, each move is searched to the limits
+func NewUCI(name string, command []string, options map[string]string, limits game.Limits) (*UCI, error) {
+	if len(command) == 0 {
+		return nil, fmt.Errorf("%s: no command", name)
+	}
+
+	cmd := exec.Command(command[0], command[1:]...)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return connectUCI(name, cmd, stdin, stdout, options, limits)
+}
+
+// connectUCI starts a session with an engine reading commands from stdin and answering on stdout. The command
+// running the engine, if there is one, is waited for when the session is closed
+func connectUCI(name string, cmd *exec.Cmd, stdin io.WriteCloser, stdout io.Reader, options map[string]string,
+	limits game.Limits) (*UCI, error) {
+	e := &UCI{name: name, limits: limits, cmd: cmd, stdin: stdin, lines: make(chan string, 64)}
+
+	// 1. the engine's output, read line by line in the background
+	go func() {
+		scanner := bufio.NewScanner(stdout)
+		for scanner.Scan() {
+			e.lines <- scanner.Text()
+		}
+		close(e.lines)
+	}()
+
+	// 2. the handshake and the options
+	if err := e.send("uci"); err != nil {
+		e.Close()
+		return nil, err
+	}
+	if _, err := e.expect("uciok", uciTimeout); err != nil {
+		e.Close()
+		return nil, err
+	}
+	for option, value := range options {
+		if err := e.send(fmt.Sprintf("setoption name %s value %s", option, value)); err != nil {
+			e.Close()
+			return nil, err
+		}
+	}
+	if err := e.ready(); err != nil {
+		e.Close()
+		return nil, err
+	}
+
+	return e, nil
+}
+
+// UCIPlayer returns a player running the engine command
+func UCIPlayer(name string, command []string, options map[string]string, limits game.Limits) Player {
+	return Player{Name: name, New: func() (Engine, error) {
+		return NewUCI(name, command, options, limits)
+	}}
+}
+
+func (e *UCI) Name() string {
+	return e.name
+}
+
+func (e *UCI) Move(g *Game, clock *Clock) (game.Move, int, error) {
+	// 1. a new game is announced before its first position
+	if g.Number != e.number {
+		e.number = g.Number
+		if err := e.send("ucinewgame"); err != nil {
+			return game.Move{}, 0, err
+		}
+		if err := e.ready(); err != nil {
+			return game.Move{}, 0, err
+		}
+	}
+
+	// 2. the position, as the opening and the moves played from it
+	position := "position fen " + g.Opening
+	if len(g.Moves) > 0 {
+		moves := make([]string, len(g.Moves))
+		for i, move := range g.Moves {
+			moves[i] = move.String()
+		}
+		position += " moves " + strings.Join(moves, " ")
+	}
+	if err := e.send(position); err != nil {
+		return game.Move{}, 0, err
+	}
+
+	// 3. the search, limited by the clock if there is one and by the engine's limits otherwise
+	var search []string
+	timeout := uciTimeout
+	if clock.Timed() {
+		ms := func(d time.Duration) string { return strconv.FormatInt(d.Milliseconds(), 10) }
+		search = append(search, "wtime", ms(clock.Remaining[0]), "btime", ms(clock.Remaining[1]),
+			"winc", ms(clock.Increment), "binc", ms(clock.Increment))
+		timeout += clock.Remaining[side(g.SideToMove())]
+	}
+	if e.limits.Depth > 0 {
+		search = append(search, "depth", strconv.Itoa(int(e.limits.Depth)))
+	}
+	if e.limits.Nodes > 0 {
+		search = append(search, "nodes", strconv.Itoa(e.limits.Nodes))
+	}
+	if e.limits.Time > 0 {
+		search = append(search, "movetime", strconv.FormatInt(e.limits.Time.Milliseconds(), 10))
+		timeout += e.limits.Time
+	}
+	if len(search) == 0 {
+		search = append(search, "depth", "4")
+	}
+	if err := e.send("go " + strings.Join(search, " ")); err != nil {
+		return game.Move{}, 0, err
+	}
+
+	// 4. the best move, with the last score reported before it
+	e.score = 0
+	line, err := e.expect("bestmove", timeout)
+	if err != nil {
+		return game.Move{}, 0, err
+	}
+	fields := strings.Fields(line)
+	if len(fields) < 2 {
+		return game.Move{}, 0, fmt.Errorf("%s: %q has no move", e.name, line)
+	}
+	move, err := g.State.ParseMove(fields[1])
+	if err != nil {
+		return game.Move{}, 0, fmt.Errorf("%s: %w", e.name, err)
+	}
+	return move, e.score, nil
+}
+
+// Close asks the engine to quit, and kills it if it does not in time
+func (e *UCI) Close() error {
+	e.send("quit")
+	e.stdin.Close()
+	if e.cmd == nil {
+		return nil
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- e.cmd.Wait() }()
+	select {
+	case err := <-done:
+		return err
+	case <-time.After(uciTimeout):
+		e.cmd.Process.Kill()
+		return <-done
+	}
+}
+
+// send writes a command to the engine
+func (e *UCI) send(command string) error {
+	if _, err := io.WriteString(e.stdin, command+"\n"); err != nil {
+		return fmt.Errorf("%s: %w", e.name, err)
+	}
+	return nil
+}
+
+// ready waits until the engine has processed the commands sent so far
+func (e *UCI) ready() error {
+	if err := e.send("isready"); err != nil {
+		return err
+	}
+	_, err := e.expect("readyok", uciTimeout)
+	return err
+}
+
+// expect reads the engine's output until a line starting with the keyword, keeping the scores of the info lines
+// read along the way
+func (e *UCI) expect(keyword string, timeout time.Duration) (string, error) {
+	deadline := time.After(timeout)
+	for {
+		select {
+		case line, ok := <-e.lines:
+			if !ok {
+				return "", fmt.Errorf("%s: engine exited while waiting for %s", e.name, keyword)
+			}
+			fields := strings.Fields(line)
+			if len(fields) == 0 {
+				continue
+			}
+			if fields[0] == keyword {
+				return line, nil
+			}
+			if fields[0] == "info" {
+				e.readScore(fields)
+			}
+		case <-deadline:
+			return "", fmt.Errorf("%s: no %s after %v", e.name, keyword, timeout)
+		}
+	}
+}
+
+// readScore keeps the score of an info line
+func (e *UCI) readScore(fields []string) {
+	for i := 0; i+2 < len(fields); i++ {
+		if fields[i] != "score" {
+			continue
+		}
+		value, err := strconv.Atoi(fields[i+2])
+		if err != nil {
+			return
+		}
+		switch fields[i+1] {
+		case "cp":
+			e.score = value
+		case "mate":
+			e.score = game.MateIn(value)
+		}
+		return
+	}
+}
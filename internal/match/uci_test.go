@@ -0,0 +1,163 @@
+package match
+
+import (
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alejandrodavidmalavet/GoChess/internal/game"
+	"github.com/alejandrodavidmalavet/GoChess/internal/uci"
+)
+
+// serveEnv makes the test binary serve the engine over UCI on its stdin and stdout, so that it can be run as an
+// external engine
+const serveEnv = "GOCHESS_SERVE_UCI"
+
+func TestMain(m *testing.M) {
+	if os.Getenv(serveEnv) != "" {
+		if err := uci.Serve(os.Stdin, os.Stdout); err != nil {
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// serveProcess starts this repository's engine as a separate process, through the test binary
+func serveProcess(t *testing.T, limits game.Limits) *UCI {
+	t.Helper()
+	t.Setenv(serveEnv, "1")
+	e, err := NewUCI("GoChess process", []string{os.Args[0]}, nil, limits)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { e.Close() })
+	return e
+}
+
+// serve connects a client to this repository's engine served over UCI through pipes, in place of a process
+func serve(t *testing.T, limits game.Limits) *UCI {
+	t.Helper()
+	commands, stdin := io.Pipe()
+	stdout, answers := io.Pipe()
+	go func() {
+		uci.Serve(commands, answers)
+		answers.Close()
+	}()
+
+	e, err := connectUCI("GoChess", nil, stdin, stdout, nil, limits)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { e.Close() })
+	return e
+}
+
+func TestUCIMove(t *testing.T) {
+	tests := []struct {
+		name  string
+		fen   string
+		moves []string
+		want  string
+		score int
+	}{
+		{"mate in one", "6k1/5ppp/8/8/8/8/8/R5K1 w - - 0 1", nil, "a1a8", game.MateIn(1)},
+		{"mate in one after moves", "6k1/5ppp/8/8/8/8/8/R5K1 w - - 0 1", []string{"g1f1", "g8h8"}, "a1a8", game.MateIn(1)},
+		{"winning a queen", "4k3/8/8/3q4/8/8/8/3RK3 w - - 0 1", nil, "d1d5", 0},
+	}
+
+	// the engine served through pipes, then run as a process
+	for _, e := range []*UCI{serve(t, game.Limits{Depth: 2}), serveProcess(t, game.Limits{Depth: 2})} {
+		for i, test := range tests {
+			state, err := game.NewGameFromFEN(test.fen)
+			if err != nil {
+				t.Fatal(err)
+			}
+			g := &Game{Number: i + 1, Opening: test.fen, State: state}
+			for _, s := range test.moves {
+				move, err := state.ParseMove(s)
+				if err != nil {
+					t.Fatal(err)
+				}
+				state.Play(move)
+				g.Moves = append(g.Moves, move)
+			}
+
+			move, score, err := e.Move(g, NewClock(TimeControl{}))
+			if err != nil {
+				t.Errorf("%s: %s: %v", e.Name(), test.name, err)
+				continue
+			}
+			if move.String() != test.want {
+				t.Errorf("%s: %s: played %v, want %s", e.Name(), test.name, move, test.want)
+			}
+			if test.score != 0 && score != test.score {
+				t.Errorf("%s: %s: scored %d, want %d", e.Name(), test.name, score, test.score)
+			}
+		}
+	}
+}
+
+func TestUCIStop(t *testing.T) {
+	e := serve(t, game.Limits{})
+
+	// 1. an infinite search answers once stopped
+	for _, command := range []string{"position startpos", "go infinite"} {
+		if err := e.send(command); err != nil {
+			t.Fatal(err)
+		}
+	}
+	time.Sleep(50 * time.Millisecond)
+	if err := e.send("stop"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := e.expect("bestmove", uciTimeout); err != nil {
+		t.Fatal(err)
+	}
+
+	// 2. the engine is ready while an infinite search runs, and the search still answers the stop sent after
+	for _, command := range []string{"position startpos", "go infinite", "isready"} {
+		if err := e.send(command); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := e.expect("readyok", uciTimeout); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.send("stop"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := e.expect("bestmove", uciTimeout); err != nil {
+		t.Fatal(err)
+	}
+
+	// 3. stopping without a search running leaves the next search alone
+	for _, command := range []string{"stop", "position startpos moves e2e4", "go depth 2"} {
+		if err := e.send(command); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var pv []string
+	for {
+		select {
+		case line, ok := <-e.lines:
+			if !ok {
+				t.Fatal("engine exited")
+			}
+			if _, moves, ok := strings.Cut(line, " pv "); ok && strings.HasPrefix(line, "info") {
+				pv = strings.Fields(moves)
+			}
+			if !strings.HasPrefix(line, "bestmove") {
+				continue
+			}
+			if len(pv) < 3 {
+				t.Errorf("depth 2 search after a stop answered with the line %v", pv)
+			}
+			return
+		case <-time.After(uciTimeout):
+			t.Fatal("no bestmove")
+		}
+	}
+}
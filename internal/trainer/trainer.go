@@ -0,0 +1,172 @@
+// Package trainer keeps the progress of a player solving puzzles: their rating and every attempt, saved to disk so
+// that it carries over from one session to the next
+package trainer
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io/fs"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/alejandrodavidmalavet/GoChess/internal/game"
+)
+
+// InitialRating is the rating of a player who has not tried any puzzle yet
+const InitialRating = 1200
+
+// k is how many rating points a single puzzle can win or lose at most
+const k = 32
+
+// Attempt is a puzzle tried by the player, and their rating once it was scored
+type Attempt struct {
+	Time   time.Time
+	Puzzle string // the key of the puzzle
+	Solved bool
+	Rating int
+}
+
+// Progress is the rating of the player and the puzzles they tried, oldest first
+type Progress struct {
+	Rating  int
+	History []Attempt
+}
+
+// Key identifies a puzzle by its position and solution
+func Key(p game.Puzzle) string {
+	moves := make([]string, len(p.Solution))
+	for i, move := range p.Solution {
+		moves[i] = move.String()
+	}
+	return p.FEN + " " + strings.Join(moves, " ")
+}
+
+// Difficulty returns the rating of the puzzle, estimated from its solution and themes as puzzles are not rated when
+// they are extracted: every move of the player after the first and every sacrifice make it harder
+func Difficulty(p game.Puzzle) int {
+	rating := 1000 + 250*((len(p.Solution)+1)/2-1)
+	for _, theme := range p.Themes {
+		if theme == "sacrifice" {
+			rating += 200
+		}
+	}
+	return rating
+}
+
+// Load reads the progress saved at the path, or returns a new one if there is no file yet
+func Load(path string) (*Progress, error) {
+	progress := &Progress{Rating: InitialRating}
+	file, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return progress, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	r := csv.NewReader(file)
+	r.FieldsPerRecord = 4
+	records, err := r.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	for i, record := range records {
+		t, err := time.Parse(time.RFC3339, record[0])
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, i+1, err)
+		}
+		rating, err := strconv.Atoi(record[3])
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: invalid rating %q", path, i+1, record[3])
+		}
+		attempt := Attempt{Time: t, Puzzle: record[1], Solved: record[2] == "solved", Rating: rating}
+		progress.History = append(progress.History, attempt)
+		progress.Rating = rating
+	}
+	return progress, nil
+}
+
+// Save writes the progress to the path, one attempt per line
+func (p *Progress) Save(path string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	w := csv.NewWriter(file)
+	for _, attempt := range p.History {
+		result := "failed"
+		if attempt.Solved {
+			result = "solved"
+		}
+		w.Write([]string{attempt.Time.Format(time.RFC3339), attempt.Puzzle, result, strconv.Itoa(attempt.Rating)})
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return err
+	}
+	return file.Close()
+}
+
+// Record scores an attempt at the puzzle like a game against a player of its difficulty, and returns the rating
+// points won or lost
+func (p *Progress) Record(puzzle game.Puzzle, solved bool) int {
+	expected := 1 / (1 + math.Pow(10, float64(Difficulty(puzzle)-p.Rating)/400))
+	score := 0.0
+	if solved {
+		score = 1
+	}
+	change := int(math.Round(k * (score - expected)))
+
+	p.Rating += change
+	p.History = append(p.History, Attempt{Time: time.Now(), Puzzle: Key(puzzle), Solved: solved, Rating: p.Rating})
+	return change
+}
+
+// Solved returns the number of distinct puzzles tried, and of those solved at least once
+func (p *Progress) Solved() (tried, solved int) {
+	results := map[string]bool{}
+	for _, attempt := range p.History {
+		results[attempt.Puzzle] = results[attempt.Puzzle] || attempt.Solved
+	}
+	for _, ok := range results {
+		if ok {
+			solved++
+		}
+	}
+	return len(results), solved
+}
+
+// Next picks the puzzle to try next: one never tried if there is any, otherwise one failed before, and among those
+// the one whose difficulty is closest to the player's rating. It returns false once every puzzle has been solved
+func (p *Progress) Next(puzzles []game.Puzzle) (game.Puzzle, bool) {
+	tried := map[string]bool{}
+	solved := map[string]bool{}
+	for _, attempt := range p.History {
+		tried[attempt.Puzzle] = true
+		solved[attempt.Puzzle] = solved[attempt.Puzzle] || attempt.Solved
+	}
+
+	best, found, bestFresh, bestGap := game.Puzzle{}, false, false, 0
+	for _, puzzle := range puzzles {
+		key := Key(puzzle)
+		if solved[key] {
+			continue
+		}
+		fresh := !tried[key]
+		gap := Difficulty(puzzle) - p.Rating
+		if gap < 0 {
+			gap = -gap
+		}
+		if !found || (fresh && !bestFresh) || (fresh == bestFresh && gap < bestGap) {
+			best, found, bestFresh, bestGap = puzzle, true, fresh, gap
+		}
+	}
+	return best, found
+}
@@ -0,0 +1,209 @@
+package trainer
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/alejandrodavidmalavet/GoChess/internal/game"
+)
+
+// puzzle returns a puzzle whose solution plays the moves from the position
+func puzzle(t *testing.T, fen string, moves []string, themes ...string) game.Puzzle {
+	t.Helper()
+	gs, err := game.NewGameFromFEN(fen)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := game.Puzzle{FEN: fen, Themes: themes}
+	for _, s := range moves {
+		move, err := gs.ParseMove(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := gs.Play(move); err != nil {
+			t.Fatal(err)
+		}
+		p.Solution = append(p.Solution, move)
+	}
+	return p
+}
+
+// testPuzzles returns puzzles rated 1000, 1250 and 1500
+func testPuzzles(t *testing.T) []game.Puzzle {
+	return []game.Puzzle{
+		puzzle(t, "6k1/5ppp/8/8/8/8/8/R5K1 w - - 0 1", []string{"a1a8"}, "mateIn1"),
+		puzzle(t, "7k/8/8/8/8/8/R7/1R4K1 w - - 0 1", []string{"a2a7", "h8g8", "b1b8"}, "mateIn2"),
+		puzzle(t, game.StartFEN, []string{"e2e4", "e7e5", "g1f3", "b8c6", "f1c4"}),
+	}
+}
+
+func TestDifficulty(t *testing.T) {
+	puzzles := testPuzzles(t)
+	tests := []struct {
+		name   string
+		puzzle game.Puzzle
+		want   int
+	}{
+		{"one move", puzzles[0], 1000},
+		{"two moves", puzzles[1], 1250},
+		{"three moves", puzzles[2], 1500},
+		{"sacrifice", puzzle(t, "6k1/5ppp/8/8/8/8/8/R5K1 w - - 0 1", []string{"a1a8"}, "mateIn1", "sacrifice"), 1200},
+	}
+
+	for _, tt := range tests {
+		if got := Difficulty(tt.puzzle); got != tt.want {
+			t.Errorf("%s: Difficulty = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestRecord(t *testing.T) {
+	puzzles := testPuzzles(t)
+	tests := []struct {
+		name   string
+		rating int
+		puzzle game.Puzzle
+		solved bool
+		change int
+	}{
+		{"solving an easier puzzle", 1200, puzzles[0], true, 8},
+		{"failing an easier puzzle", 1200, puzzles[0], false, -24},
+		{"solving an even puzzle", 1250, puzzles[1], true, 16},
+		{"failing an even puzzle", 1250, puzzles[1], false, -16},
+		{"solving a harder puzzle", 1200, puzzles[2], true, 27},
+		{"failing a much easier puzzle", 2500, puzzles[0], false, -32},
+	}
+
+	for _, tt := range tests {
+		p := &Progress{Rating: tt.rating}
+		if change := p.Record(tt.puzzle, tt.solved); change != tt.change || p.Rating != tt.rating+tt.change {
+			t.Errorf("%s: changed by %d to %d, want %d", tt.name, change, p.Rating, tt.change)
+		}
+		if len(p.History) != 1 {
+			t.Fatalf("%s: %d attempts recorded", tt.name, len(p.History))
+		}
+		attempt := p.History[0]
+		if attempt.Puzzle != Key(tt.puzzle) || attempt.Solved != tt.solved || attempt.Rating != p.Rating {
+			t.Errorf("%s: recorded %+v", tt.name, attempt)
+		}
+	}
+}
+
+func TestNext(t *testing.T) {
+	puzzles := testPuzzles(t)
+	keys := map[string]int{}
+	for i, p := range puzzles {
+		keys[Key(p)] = i
+	}
+
+	tests := []struct {
+		name    string
+		rating  int
+		history []Attempt
+		want    int // index of the puzzle, -1 for none
+	}{
+		{"closest untried", 1200, nil, 1},
+		{"closest untried to a low rating", 800, nil, 0},
+		{"untried before failed", 1250, []Attempt{{Puzzle: Key(puzzles[1])}}, 0},
+		{"untried before closer failed", 1550, []Attempt{{Puzzle: Key(puzzles[2])}}, 1},
+		{"closest failed", 1400, []Attempt{
+			{Puzzle: Key(puzzles[0])}, {Puzzle: Key(puzzles[1])}, {Puzzle: Key(puzzles[2])},
+		}, 2},
+		{"never solved ones", 1400, []Attempt{
+			{Puzzle: Key(puzzles[0])}, {Puzzle: Key(puzzles[1])}, {Puzzle: Key(puzzles[2]), Solved: true},
+		}, 1},
+		{"solved after failing", 1400, []Attempt{
+			{Puzzle: Key(puzzles[0])}, {Puzzle: Key(puzzles[1])}, {Puzzle: Key(puzzles[2])},
+			{Puzzle: Key(puzzles[2]), Solved: true}, {Puzzle: Key(puzzles[1]), Solved: true},
+		}, 0},
+		{"all solved", 1200, []Attempt{
+			{Puzzle: Key(puzzles[0]), Solved: true}, {Puzzle: Key(puzzles[1]), Solved: true},
+			{Puzzle: Key(puzzles[2]), Solved: true},
+		}, -1},
+	}
+
+	for _, tt := range tests {
+		p := &Progress{Rating: tt.rating, History: tt.history}
+		next, ok := p.Next(puzzles)
+		got := -1
+		if ok {
+			got = keys[Key(next)]
+		}
+		if got != tt.want {
+			t.Errorf("%s: Next = puzzle %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestSolved(t *testing.T) {
+	puzzles := testPuzzles(t)
+	p := &Progress{Rating: InitialRating}
+	p.Record(puzzles[0], false)
+	p.Record(puzzles[0], true)
+	p.Record(puzzles[0], true)
+	p.Record(puzzles[1], false)
+	p.Record(puzzles[1], false)
+	if tried, solved := p.Solved(); tried != 2 || solved != 1 {
+		t.Errorf("Solved() = %d tried, %d solved, want 2 and 1", tried, solved)
+	}
+}
+
+func TestLoadSave(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.csv")
+
+	// a missing file is a new player
+	progress, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if progress.Rating != InitialRating || len(progress.History) != 0 {
+		t.Fatalf("new progress %+v", progress)
+	}
+
+	puzzles := testPuzzles(t)
+	progress.Record(puzzles[0], true)
+	progress.Record(puzzles[1], false)
+	progress.Record(puzzles[2], true)
+	if err := progress.Save(path); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.Rating != progress.Rating || len(loaded.History) != len(progress.History) {
+		t.Fatalf("loaded rating %d with %d attempts, want %d with %d", loaded.Rating, len(loaded.History),
+			progress.Rating, len(progress.History))
+	}
+	for i, attempt := range loaded.History {
+		want := progress.History[i]
+		if !attempt.Time.Equal(want.Time.Truncate(time.Second)) || attempt.Puzzle != want.Puzzle ||
+			attempt.Solved != want.Solved || attempt.Rating != want.Rating {
+			t.Errorf("attempt %d: loaded %+v, want %+v", i+1, attempt, want)
+		}
+	}
+}
+
+func TestLoadInvalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{"missing fields", "2026-01-02T15:04:05Z,key,solved\n"},
+		{"invalid time", "yesterday,key,solved,1200\n"},
+		{"invalid rating", "2026-01-02T15:04:05Z,key,solved,high\n"},
+	}
+
+	for _, tt := range tests {
+		path := filepath.Join(t.TempDir(), "history.csv")
+		if err := os.WriteFile(path, []byte(tt.content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := Load(path); err == nil {
+			t.Errorf("%s: loaded", tt.name)
+		}
+	}
+}
@@ -0,0 +1,282 @@
+// Package uci runs the engine behind the Universal Chess Interface, so that GUIs and match runners can drive it as
+// a separate process over stdin and stdout
+package uci
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/alejandrodavidmalavet/GoChess/internal/game"
+)
+
+// Serve answers the UCI commands read from r on w until quit is received or r ends
+func Serve(r io.Reader, w io.Writer) error {
+	e := &engine{out: bufio.NewWriter(w), gs: game.NewGame()}
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+
+		switch fields[0] {
+		case "uci":
+			e.send("id name GoChess")
+			e.send("id author GoChess authors")
+			e.send("option name SyzygyPath type string default <empty>")
+			e.send("option name DTMPath type string default <empty>")
+			e.send("option name EvalFile type string default <empty>")
+			e.send("option name BookFile type string default <empty>")
+			e.send("option name BookDepth type spin default 0 min 0 max 1000")
+			e.send("uciok")
+		case "isready":
+			// answered at once, without waiting for an infinite search that only a later stop ends
+			e.send("readyok")
+		case "setoption":
+			e.wait()
+			e.setOption(fields[1:])
+		case "ucinewgame":
+			e.wait()
+			e.gs = game.NewGame()
+		case "position":
+			e.wait()
+			if err := e.position(fields[1:]); err != nil {
+				e.send("info string " + err.Error())
+			}
+		case "go":
+			e.wait()
+			e.search(fields[1:])
+		case "stop":
+			e.stop()
+		case "quit":
+			e.stop()
+			e.wait()
+			return e.out.Flush()
+		default:
+			e.send("info string unknown command " + fields[0])
+		}
+	}
+
+	e.stop()
+	e.wait()
+	return scanner.Err()
+}
+
+// engine is the state of a UCI session
+type engine struct {
+	mu  sync.Mutex
+	out *bufio.Writer
+
+	gs     *game.GameState
+	params *game.EvalParams
+
+	// the opening book and the number of full moves it is played for, 0 for as long as it has moves
+	book      *game.Book
+	bookDepth int
+
+	// the search running in the background, if any
+	searching sync.WaitGroup
+	stopped   chan struct{}
+}
+
+// send writes a line of output, which searches in the background may do at any time
+func (e *engine) send(line string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.out.WriteString(line + "\n")
+	e.out.Flush()
+}
+
+// setOption handles "setoption name <name> value <value>"
+func (e *engine) setOption(fields []string) {
+	line := strings.Join(fields, " ")
+	name, value, _ := strings.Cut(strings.TrimPrefix(line, "name "), " value ")
+	if value == "<empty>" {
+		value = ""
+	}
+
+	var err error
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "syzygypath":
+		_, err = game.SetSyzygyPath(value)
+	case "dtmpath":
+		_, err = game.SetDTMPath(value)
+	case "evalfile":
+		e.params = nil
+		if value != "" {
+			e.params, err = game.LoadEvalParams(value)
+		}
+	case "bookfile":
+		e.book = nil
+		if value != "" {
+			e.book, err = game.LoadBook(value)
+		}
+	case "bookdepth":
+		e.bookDepth, err = strconv.Atoi(value)
+	default:
+		err = fmt.Errorf("unknown option %q", name)
+	}
+	if err != nil {
+		e.send("info string " + err.Error())
+	}
+}
+
+// position handles "position startpos|fen <fen> [moves <moves>]"
+func (e *engine) position(fields []string) error {
+	if len(fields) == 0 {
+		return fmt.Errorf("position: missing startpos or fen")
+	}
+
+	// 1. the starting position
+	var gs *game.GameState
+	rest := fields[1:]
+	switch fields[0] {
+	case "startpos":
+		gs = game.NewGame()
+	case "fen":
+		n := 0
+		for n < len(rest) && rest[n] != "moves" {
+			n++
+		}
+		var err error
+		if gs, err = game.NewGameFromFEN(strings.Join(rest[:n], " ")); err != nil {
+			return err
+		}
+		rest = rest[n:]
+	default:
+		return fmt.Errorf("position: expected startpos or fen, got %q", fields[0])
+	}
+
+	// 2. the moves played from it
+	if len(rest) > 0 && rest[0] == "moves" {
+		for _, s := range rest[1:] {
+			move, err := gs.ParseMove(s)
+			if err != nil {
+				return err
+			}
+			if err := gs.Play(move); err != nil {
+				return err
+			}
+		}
+	}
+
+	e.gs = gs
+	return nil
+}
+
+// search handles "go", searching in the background until the limits are reached or stop is received
+func (e *engine) search(fields []string) {
+	limits := game.Limits{Book: e.book, BookDepth: e.bookDepth}
+	var wtime, btime, winc, binc time.Duration
+	movesToGo := 0
+
+	for i := 0; i < len(fields); i++ {
+		if fields[i] == "infinite" {
+			limits.Infinite = true
+			continue
+		}
+		if i+1 >= len(fields) {
+			break
+		}
+		value, err := strconv.Atoi(fields[i+1])
+		if err != nil {
+			continue
+		}
+		ms := time.Duration(value) * time.Millisecond
+		switch fields[i] {
+		case "depth":
+			limits.Depth = int8(min(max(value, 1), math.MaxInt8))
+		case "nodes":
+			limits.Nodes = value
+		case "movetime":
+			limits.Time = ms
+		case "wtime":
+			wtime = ms
+		case "btime":
+			btime = ms
+		case "winc":
+			winc = ms
+		case "binc":
+			binc = ms
+		case "movestogo":
+			movesToGo = value
+		}
+		i++
+	}
+
+	// the time of a move on the clock: a share of the time left plus most of the increment
+	remaining, increment := wtime, winc
+	if e.gs.SideToMove() == game.Black {
+		remaining, increment = btime, binc
+	}
+	if remaining > 0 {
+		share := time.Duration(30)
+		if movesToGo > 0 {
+			share = time.Duration(movesToGo + 1)
+		}
+		allocation := min(remaining/share+increment*3/4, remaining/2)
+		if limits.Time == 0 || allocation < limits.Time {
+			limits.Time = allocation
+		}
+	}
+
+	gs := e.gs
+	if e.params != nil {
+		gs.SetEvalParams(e.params)
+	}
+
+	e.stopped = make(chan struct{})
+	stopped := e.stopped
+	limits.Stop = stopped
+	e.searching.Add(1)
+	go func() {
+		defer e.searching.Done()
+		start := time.Now()
+		line, ok := gs.Search(limits)
+
+		// an infinite search only answers once it is stopped
+		if limits.Infinite {
+			<-stopped
+		}
+		if !ok {
+			e.send("bestmove 0000")
+			return
+		}
+
+		pv := make([]string, len(line.PV))
+		for i, move := range line.PV {
+			pv[i] = move.String()
+		}
+		score := fmt.Sprintf("cp %d", line.Score)
+		if moves, ok := game.MateMoves(line.Score); ok {
+			score = fmt.Sprintf("mate %d", moves)
+		}
+		elapsed := time.Since(start).Milliseconds()
+		e.send(fmt.Sprintf("info score %s time %d pv %s", score, elapsed, strings.Join(pv, " ")))
+		e.send("bestmove " + line.Move.String())
+	}()
+}
+
+// stop ends the search running in the background, if any
+func (e *engine) stop() {
+	if e.stopped == nil {
+		return
+	}
+	select {
+	case <-e.stopped:
+	default:
+		close(e.stopped)
+	}
+}
+
+// wait blocks until the search running in the background has answered
+func (e *engine) wait() {
+	e.searching.Wait()
+}
@@ -1,9 +1,20 @@
 package main
 
 import (
+	"errors"
 	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"sort"
+	"strings"
+	"time"
 
+	"github.com/alejandrodavidmalavet/GoChess/internal/analysis"
 	"github.com/alejandrodavidmalavet/GoChess/internal/game"
+	"github.com/alejandrodavidmalavet/GoChess/internal/match"
+	"github.com/alejandrodavidmalavet/GoChess/internal/trainer"
+	"github.com/alejandrodavidmalavet/GoChess/internal/uci"
 )
 
 type Choice int
@@ -14,10 +25,45 @@ const (
 	UndoMove
 	BestMove
 	AIVsAI
+	Analyze
+	SkillMove
+	FindMate
+	LoadFEN
+	Perft
+	PerftSuite
+	Bench
+	SyzygyPath
+	EndgameTables
+	Tune
+	LoadEvalParams
+	Match
+	OpeningBook
+	BuildBook
+	ExplainEval
+	Hint
+	AnnotateGame
+	ExtractPuzzles
+	PuzzleTrainer
+	Motifs
+	PawnStructure
 )
 
 func main() {
+	// run as a UCI engine, for GUIs and match runners
+	if len(os.Args) > 1 && os.Args[1] == "uci" {
+		if err := uci.Serve(os.Stdin, os.Stdout); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	gs := game.NewGame()
+	rng := rand.New(rand.NewSource(time.Now().UnixNano()))
+
+	// the opening book the best moves are played from, and the full moves it is played for
+	var book *game.Book
+	bookDepth := 0
 	for {
 		gs.PrettyPrint()
 
@@ -28,12 +74,33 @@ func main() {
 			"[", UndoMove, "] Undo Move\n",
 			"[", BestMove, "] Best Move\n",
 			"[", AIVsAI, "] AI v AI\n",
+			"[", Analyze, "] Analyze\n",
+			"[", SkillMove, "] Skill Move\n",
+			"[", FindMate, "] Find Mate\n",
+			"[", LoadFEN, "] Load FEN\n",
+			"[", Perft, "] Perft\n",
+			"[", PerftSuite, "] Perft Suite\n",
+			"[", Bench, "] Bench\n",
+			"[", SyzygyPath, "] Syzygy Path\n",
+			"[", EndgameTables, "] Endgame Tables\n",
+			"[", Tune, "] Tune Evaluation\n",
+			"[", LoadEvalParams, "] Load Evaluation\n",
+			"[", Match, "] Match\n",
+			"[", OpeningBook, "] Opening Book\n",
+			"[", BuildBook, "] Build Book\n",
+			"[", ExplainEval, "] Explain Evaluation\n",
+			"[", Hint, "] Hint\n",
+			"[", AnnotateGame, "] Annotate Game\n",
+			"[", ExtractPuzzles, "] Extract Puzzles\n",
+			"[", PuzzleTrainer, "] Puzzle Trainer\n",
+			"[", Motifs, "] Tactical Motifs\n",
+			"[", PawnStructure, "] Pawn Structure\n",
 			"Choice: ")
 		fmt.Scanln(&c)
 
 		switch c {
 		case RandomMove:
-			if ok := gs.ExecuteRandomMove(); !ok {
+			if ok := gs.ExecuteRandomMove(rng, nil); !ok {
 				fmt.Println("Invalid move")
 			}
 		case CustomMove:
@@ -67,18 +134,599 @@ func main() {
 			var depth int8
 			fmt.Print("Depth: ")
 			fmt.Scanln(&depth)
-			gs.ExecuteBestMove(depth)
+			gs.ExecuteBestMoveWith(game.Limits{Depth: depth, Book: book, BookDepth: bookDepth, Rand: rng})
 		case AIVsAI:
 			var depth int8
 			fmt.Print("Depth: ")
 			fmt.Scanln(&depth)
 			for {
-				gs.ExecuteBestMove(depth)
+				gs.ExecuteBestMoveWith(game.Limits{Depth: depth, Book: book, BookDepth: bookDepth, Rand: rng})
 				gs.PrettyPrint()
 			}
+		case Analyze:
+			var depth int8
+			var count int
+			fmt.Print("Depth: ")
+			fmt.Scanln(&depth)
+			fmt.Print("Lines (0 for all): ")
+			fmt.Scanln(&count)
+			for i, line := range gs.MultiPV(depth, count) {
+				fmt.Printf("%d. %v (%s):", i+1, line.Move, game.FormatScore(line.Score))
+				for _, move := range line.PV {
+					fmt.Printf(" %v", move)
+				}
+				fmt.Println()
+			}
+		case SkillMove:
+			var level int
+			fmt.Print("Level (0-", len(game.SkillLevels)-1, "): ")
+			fmt.Scanln(&level)
+			if level < 0 || level >= len(game.SkillLevels) {
+				fmt.Println("Invalid level")
+				break
+			}
+			if ok := gs.ExecuteSkillMove(game.SkillLevels[level], rng); !ok {
+				fmt.Println("Invalid move")
+			}
+		case FindMate:
+			var n int
+			fmt.Print("Moves: ")
+			fmt.Scanln(&n)
+			line, ok := gs.FindMate(n)
+			if !ok {
+				fmt.Printf("No mate in %d\n", n)
+				break
+			}
+			fmt.Printf("Mate in %d:", (len(line)+1)/2)
+			for _, move := range line {
+				fmt.Printf(" %v", move)
+			}
+			fmt.Println()
+		case LoadFEN:
+			fmt.Print("FEN: ")
+			loaded, err := game.NewGameFromFEN(readLine())
+			if err != nil {
+				fmt.Println(err)
+				break
+			}
+			gs = loaded
+		case Perft:
+			fmt.Print("FEN (empty for the current position): ")
+			position := gs
+			if fen := readLine(); fen != "" {
+				loaded, err := game.NewGameFromFEN(fen)
+				if err != nil {
+					fmt.Println(err)
+					break
+				}
+				position = loaded
+			}
+
+			var depth int
+			fmt.Print("Depth: ")
+			fmt.Scanln(&depth)
+
+			start := time.Now()
+			divide := position.Divide(depth)
+			moves := make([]game.Move, 0, len(divide))
+			var total uint64
+			for move, nodes := range divide {
+				moves = append(moves, move)
+				total += nodes
+			}
+			sort.Slice(moves, func(i, j int) bool { return moves[i].String() < moves[j].String() })
+			for _, move := range moves {
+				fmt.Printf("%v: %d\n", move, divide[move])
+			}
+			fmt.Printf("Nodes: %d (%v)\n", total, time.Since(start))
+		case PerftSuite:
+			var depth int
+			fmt.Print("Depth: ")
+			fmt.Scanln(&depth)
+			for _, position := range game.PerftSuite {
+				if err := position.Verify(depth); err != nil {
+					fmt.Println("FAIL", err)
+				} else {
+					fmt.Println("ok  ", position.Name)
+				}
+			}
+		case Bench:
+			for _, result := range game.Bench(4, 2) {
+				fmt.Printf("%s: %d nodes in %v (%.0f nodes/s)\n", result.Name, result.Nodes, result.Elapsed, result.NodesPerSecond())
+			}
+		case SyzygyPath:
+			fmt.Print("Tablebase directory (blank to disable): ")
+			count, err := game.SetSyzygyPath(strings.TrimSpace(readLine()))
+			if err != nil {
+				fmt.Println(err)
+				break
+			}
+			fmt.Printf("%d tablebases found\n", count)
+			if move, wdl, ok := gs.ProbeRoot(); ok {
+				fmt.Printf("Tablebase move: %v (%v)\n", move, wdl)
+			}
+		case EndgameTables:
+			fmt.Print("Table directory (blank to disable): ")
+			dir := strings.TrimSpace(readLine())
+			if dir != "" {
+				fmt.Printf("Endings to generate (blank for %s, - for none): ", strings.Join(game.DefaultDTMEndings, ","))
+				endings := game.DefaultDTMEndings
+				switch line := strings.TrimSpace(readLine()); line {
+				case "":
+				case "-":
+					endings = nil
+				default:
+					endings = strings.Split(line, ",")
+				}
+				if err := game.GenerateDTM(dir, endings...); err != nil {
+					fmt.Println(err)
+					break
+				}
+			}
+			count, err := game.SetDTMPath(dir)
+			if err != nil {
+				fmt.Println(err)
+				break
+			}
+			fmt.Printf("%d tables found\n", count)
+			if move, wdl, plies, ok := gs.DTMMove(); ok {
+				fmt.Printf("Table move: %v (%v in %d plies)\n", move, wdl, plies)
+			}
+		case Tune:
+			fmt.Print("Labeled positions file: ")
+			positions, err := game.LoadLabeledPositions(strings.TrimSpace(readLine()))
+			if err != nil {
+				fmt.Println(err)
+				break
+			}
+			var iterations int
+			fmt.Print("Iterations: ")
+			fmt.Scanln(&iterations)
+			fmt.Print("Output file: ")
+			output := strings.TrimSpace(readLine())
+
+			params, err := game.Tune(positions, game.DefaultEvalParams(), iterations, func(iteration int, err float64) {
+				if iteration%50 == 0 || iteration == iterations {
+					fmt.Printf("Iteration %d: error %.6f\n", iteration, err)
+				}
+			})
+			if err != nil {
+				fmt.Println(err)
+				break
+			}
+			if err := params.Save(output); err != nil {
+				fmt.Println(err)
+				break
+			}
+			fmt.Printf("Tuned %d positions into %s\n", len(positions), output)
+		case LoadEvalParams:
+			fmt.Print("Evaluation file: ")
+			params, err := game.LoadEvalParams(strings.TrimSpace(readLine()))
+			if err != nil {
+				fmt.Println(err)
+				break
+			}
+			game.UseEvalParams(params)
+			gs.SetEvalParams(params)
+		case Match:
+			if err := runMatch(); err != nil {
+				fmt.Println(err)
+			}
+		case OpeningBook:
+			fmt.Print("Polyglot book file (blank to disable): ")
+			path := strings.TrimSpace(readLine())
+			if path == "" {
+				book = nil
+				break
+			}
+			loaded, err := game.LoadBook(path)
+			if err != nil {
+				fmt.Println(err)
+				break
+			}
+			book, bookDepth = loaded, 0
+			fmt.Print("Book depth in moves (0 for no limit): ")
+			fmt.Scanln(&bookDepth)
+			fmt.Printf("%d book entries loaded\n", book.Len())
+			for _, move := range book.Moves(gs) {
+				fmt.Printf("Book move: %v (weight %d)\n", move.Move, move.Weight)
+			}
+		case BuildBook:
+			if err := buildBook(); err != nil {
+				fmt.Println(err)
+			}
+		case ExplainEval:
+			fmt.Print("\n", gs.Explain())
+		case PawnStructure:
+			fmt.Print("\n", analysis.Pawns(gs))
+		case Motifs:
+			findings := gs.Motifs()
+			if len(findings) == 0 {
+				fmt.Println("\nNo tactical motifs")
+			}
+			for _, f := range findings {
+				fmt.Println(f)
+			}
+		case AnnotateGame:
+			if err := annotateGame(gs); err != nil {
+				fmt.Println(err)
+			}
+		case ExtractPuzzles:
+			if err := extractPuzzles(); err != nil {
+				fmt.Println(err)
+			}
+		case PuzzleTrainer:
+			if err := puzzleTrainer(); err != nil {
+				fmt.Println(err)
+			}
+		case Hint:
+			depth := int8(game.HintDepth)
+			fmt.Printf("Depth (blank for %d): ", depth)
+			if line := readLine(); line != "" {
+				if _, err := fmt.Sscan(line, &depth); err != nil {
+					fmt.Printf("depth %q: expected a number\n", line)
+					break
+				}
+			}
+			hints := gs.Hints(depth)
+			for i, hint := range hints {
+				fmt.Printf("%2d. %-8s %7s", i+1, hint.SAN, game.FormatScore(hint.Score))
+				_, bestMates := game.MateMoves(hints[0].Score)
+				_, mates := game.MateMoves(hint.Score)
+				switch {
+				case hint.Loss == 0:
+				case bestMates || mates:
+					// the difference between a mate and a score means nothing in pawns
+					fmt.Printf("  (the best is %s)", game.FormatScore(hints[0].Score))
+				default:
+					fmt.Printf("  (%.2f worse than the best)", float64(hint.Loss)/100)
+				}
+				fmt.Println()
+			}
 		default:
 			fmt.Println("Invalid choice")
 		}
 	}
 
 }
+
+// runMatch asks for two engine configurations and plays a match between them
+func runMatch() error {
+	var players [2]match.Player
+	for i, name := range []string{"A", "B"} {
+		fmt.Printf("Engine %s UCI command (blank for internal): ", name)
+		command := strings.Fields(readLine())
+		params := game.DefaultEvalParams()
+		var book *game.Book
+		if len(command) == 0 {
+			fmt.Printf("Engine %s evaluation file (blank for default): ", name)
+			if path := strings.TrimSpace(readLine()); path != "" {
+				loaded, err := game.LoadEvalParams(path)
+				if err != nil {
+					return err
+				}
+				params = loaded
+			}
+			fmt.Printf("Engine %s opening book file (blank for none): ", name)
+			if path := strings.TrimSpace(readLine()); path != "" {
+				loaded, err := game.LoadBook(path)
+				if err != nil {
+					return err
+				}
+				book = loaded
+			}
+		}
+		var depth int8
+		fmt.Printf("Engine %s depth limit (0 for none): ", name)
+		fmt.Scanln(&depth)
+		if len(command) > 0 {
+			players[i] = match.UCIPlayer(name, command, nil, game.Limits{Depth: depth})
+		} else {
+			players[i] = match.InternalPlayer(name, params, game.Limits{Depth: depth, Book: book})
+		}
+	}
+
+	options := match.Options{Adjudication: match.DefaultAdjudication}
+	fmt.Print("Games: ")
+	fmt.Scanln(&options.Games)
+	fmt.Print("Concurrency: ")
+	fmt.Scanln(&options.Concurrency)
+	fmt.Print("Time control in seconds, e.g. 10+0.1 (blank for none): ")
+	if tc := strings.TrimSpace(readLine()); tc != "" {
+		timeControl, err := match.ParseTimeControl(tc)
+		if err != nil {
+			return err
+		}
+		options.TimeControl = timeControl
+	}
+	fmt.Print("Openings file (blank for built-in): ")
+	if path := strings.TrimSpace(readLine()); path != "" {
+		openings, err := match.LoadOpenings(path)
+		if err != nil {
+			return err
+		}
+		options.Openings = openings
+	}
+	fmt.Print("SPRT elo0 elo1 (blank for none): ")
+	if line := strings.TrimSpace(readLine()); line != "" {
+		sprt := match.SPRT{Alpha: 0.05, Beta: 0.05}
+		if _, err := fmt.Sscan(line, &sprt.Elo0, &sprt.Elo1); err != nil {
+			return fmt.Errorf("sprt %q: expected two Elo values", line)
+		}
+		options.SPRT = &sprt
+	}
+
+	options.Progress = func(g *match.Game, stats match.Stats) {
+		fmt.Printf("Game %d: %s vs %s %v (%s) | %v\n", g.Number, g.White, g.Black, g.Result, g.Reason, stats)
+	}
+	report, err := match.Run(players, options)
+	fmt.Println("Result:", report.Stats)
+	if options.SPRT != nil {
+		lower, upper := options.SPRT.Bounds()
+		fmt.Printf("SPRT: LLR %.2f (%.2f, %.2f) %v\n", report.LLR, lower, upper, report.Status)
+	}
+	return err
+}
+
+// buildBook asks for a PGN file and the filters of the book, and writes the book built from its games
+func buildBook() error {
+	fmt.Print("PGN file: ")
+	file, err := os.Open(strings.TrimSpace(readLine()))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	options := game.DefaultBookOptions
+	fmt.Printf("Maximum ply (blank for %d, 0 for no limit): ", options.MaxPly)
+	if line := strings.TrimSpace(readLine()); line != "" {
+		if _, err := fmt.Sscan(line, &options.MaxPly); err != nil {
+			return fmt.Errorf("ply %q: expected a number", line)
+		}
+	}
+	fmt.Printf("Minimum games per move (blank for %d): ", options.MinGames)
+	if line := strings.TrimSpace(readLine()); line != "" {
+		if _, err := fmt.Sscan(line, &options.MinGames); err != nil {
+			return fmt.Errorf("games %q: expected a number", line)
+		}
+	}
+	fmt.Print("Minimum score per move in percent (blank for none): ")
+	if line := strings.TrimSpace(readLine()); line != "" {
+		var percent float64
+		if _, err := fmt.Sscan(line, &percent); err != nil {
+			return fmt.Errorf("score %q: expected a number", line)
+		}
+		options.MinScore = percent / 100
+	}
+	fmt.Print("Output file: ")
+	output := strings.TrimSpace(readLine())
+
+	book, report, err := game.BuildBook(file, options)
+	if err != nil {
+		return err
+	}
+	if err := book.Save(output); err != nil {
+		return err
+	}
+	fmt.Printf("%d book entries from %d games written to %s (%d games skipped)\n", book.Len(), report.Games, output, report.Skipped)
+	return nil
+}
+
+// annotateGame asks for a PGN file, or takes the game being played, and writes its games with the inaccuracies,
+// mistakes and blunders found by the engine annotated
+func annotateGame(gs *game.GameState) error {
+	fmt.Print("PGN file (blank for the current game): ")
+	var games []*game.PGNGame
+	if path := readLine(); path != "" {
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+
+		// games that cannot be read are reported and left out
+		pr := game.NewPGNReader(file)
+		for {
+			g, err := pr.Next()
+			if err == io.EOF {
+				break
+			}
+			var pgnErr *game.PGNError
+			if errors.As(err, &pgnErr) {
+				fmt.Println(err)
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			games = append(games, g)
+		}
+	} else {
+		games = []*game.PGNGame{gs.PGN()}
+	}
+
+	options := game.DefaultAnnotateOptions
+	fmt.Printf("Depth (blank for %d): ", options.Depth)
+	if line := readLine(); line != "" {
+		if _, err := fmt.Sscan(line, &options.Depth); err != nil {
+			return fmt.Errorf("depth %q: expected a number", line)
+		}
+	}
+	fmt.Print("Output file (blank to print): ")
+	output := os.Stdout
+	if path := readLine(); path != "" {
+		file, err := os.Create(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		output = file
+	}
+
+	for i, g := range games {
+		analysis, err := g.Analyze(options, func(done, total int) {
+			fmt.Fprintf(os.Stderr, "\rgame %d of %d: %d/%d moves", i+1, len(games), done, total)
+		})
+		fmt.Fprintln(os.Stderr)
+		if err != nil {
+			return fmt.Errorf("game %d: %w", i+1, err)
+		}
+		annotated, err := g.Annotate(analysis)
+		if err != nil {
+			return fmt.Errorf("game %d: %w", i+1, err)
+		}
+		if err := annotated.Write(output); err != nil {
+			return err
+		}
+	}
+	if output != os.Stdout {
+		return output.Close()
+	}
+	return nil
+}
+
+// extractPuzzles asks for a PGN file and writes the puzzles found in its games
+func extractPuzzles() error {
+	fmt.Print("PGN file: ")
+	file, err := os.Open(readLine())
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	options := game.DefaultPuzzleOptions
+	fmt.Printf("Depth (blank for %d): ", options.Depth)
+	if line := readLine(); line != "" {
+		if _, err := fmt.Sscan(line, &options.Depth); err != nil {
+			return fmt.Errorf("depth %q: expected a number", line)
+		}
+	}
+	fmt.Printf("Longest solution in moves (blank for %d): ", options.MaxMoves)
+	if line := readLine(); line != "" {
+		if _, err := fmt.Sscan(line, &options.MaxMoves); err != nil {
+			return fmt.Errorf("moves %q: expected a number", line)
+		}
+	}
+	fmt.Print("Output file: ")
+	output := readLine()
+
+	puzzles, report, err := game.ExtractPuzzles(file, options, func(games, puzzles int) {
+		fmt.Printf("\r%d games, %d puzzles", games, puzzles)
+	})
+	fmt.Println()
+	if err != nil {
+		return err
+	}
+	if err := game.SavePuzzles(output, puzzles); err != nil {
+		return err
+	}
+	fmt.Printf("%d puzzles from %d games written to %s (%d games skipped)\n", len(puzzles), report.Games, output, report.Skipped)
+	return nil
+}
+
+// puzzleTrainer asks for a puzzle file and has the player solve its puzzles one after the other. Their rating and
+// the puzzles they tried are kept in a history file next to it
+func puzzleTrainer() error {
+	fmt.Print("Puzzle file: ")
+	path := readLine()
+	puzzles, err := game.LoadPuzzles(path)
+	if err != nil {
+		return err
+	}
+	historyPath := path + ".history"
+	progress, err := trainer.Load(historyPath)
+	if err != nil {
+		return err
+	}
+
+	for {
+		puzzle, ok := progress.Next(puzzles)
+		if !ok {
+			fmt.Println("Every puzzle is solved")
+			return nil
+		}
+		tried, solved := progress.Solved()
+		fmt.Printf("\nRating %d, %d solved of %d tried. This puzzle is rated %d\n", progress.Rating, solved, tried,
+			trainer.Difficulty(puzzle))
+
+		change := progress.Record(puzzle, solvePuzzle(puzzle))
+		if err := progress.Save(historyPath); err != nil {
+			return err
+		}
+		fmt.Printf("Rating %d (%+d)\n", progress.Rating, change)
+
+		fmt.Print("Next puzzle? (y/N): ")
+		if answer := strings.ToLower(readLine()); answer != "y" && answer != "yes" {
+			return nil
+		}
+	}
+}
+
+// solvePuzzle shows the puzzle and plays it out with the player, who moves in UCI notation or SAN. The opponent's
+// replies are played for them. It returns true if they found every move, any mate being as good as the solution's
+func solvePuzzle(puzzle game.Puzzle) bool {
+	gs, err := game.NewGameFromFEN(puzzle.FEN)
+	if err != nil {
+		fmt.Println(err)
+		return false
+	}
+
+	for i := 0; i < len(puzzle.Solution); {
+		gs.PrettyPrint()
+		fmt.Printf("\n%v to play. Move (blank to give up): ", gs.CurrentPlayer())
+		input := readLine()
+		move, err := gs.ParseMove(input)
+		if err != nil {
+			move, err = gs.ParseSAN(input)
+		}
+		if input != "" && err != nil {
+			fmt.Println(err)
+			continue
+		}
+
+		// a wrong move shows the rest of the solution
+		if input == "" || move != puzzle.Solution[i] {
+			if input != "" {
+				gs.Play(move)
+				if _, reason := gs.Result(); reason == "checkmate" {
+					gs.PrettyPrint()
+					fmt.Println("\nSolved!")
+					return true
+				}
+				gs.Undo()
+			}
+			fmt.Print("Wrong, the solution is")
+			for _, move := range puzzle.Solution[i:] {
+				fmt.Print(" ", gs.SAN(move))
+				gs.Play(move)
+			}
+			fmt.Println()
+			return false
+		}
+
+		gs.Play(move)
+		if i+1 < len(puzzle.Solution) {
+			reply := puzzle.Solution[i+1]
+			fmt.Printf("Opponent plays %s\n", gs.SAN(reply))
+			gs.Play(reply)
+		}
+		i += 2
+	}
+
+	gs.PrettyPrint()
+	fmt.Println("\nSolved!")
+	return true
+}
+
+// readLine reads a whole line from stdin, one byte at a time so that later scans see the remaining input
+func readLine() string {
+	var line []byte
+	b := make([]byte, 1)
+	for {
+		if n, err := os.Stdin.Read(b); n == 0 || err != nil || b[0] == '\n' {
+			break
+		}
+		line = append(line, b[0])
+	}
+	return strings.TrimSpace(string(line))
+}